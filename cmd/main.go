@@ -11,11 +11,17 @@ import (
 
 	"github.com/GroVlAn/doc-store/internal/caching"
 	"github.com/GroVlAn/doc-store/internal/config"
+	"github.com/GroVlAn/doc-store/internal/directory"
+	"github.com/GroVlAn/doc-store/internal/events"
 	"github.com/GroVlAn/doc-store/internal/handler"
+	"github.com/GroVlAn/doc-store/internal/keyring"
 	mongoclient "github.com/GroVlAn/doc-store/internal/mongo"
+	"github.com/GroVlAn/doc-store/internal/notify"
+	"github.com/GroVlAn/doc-store/internal/oidc"
 	repository "github.com/GroVlAn/doc-store/internal/repostiory"
 	"github.com/GroVlAn/doc-store/internal/server"
 	"github.com/GroVlAn/doc-store/internal/service"
+	"github.com/GroVlAn/doc-store/internal/worker"
 	"github.com/patrickmn/go-cache"
 	"github.com/rs/zerolog"
 )
@@ -58,25 +64,171 @@ func main() {
 
 	r := repository.New(mgc.Client())
 
+	if err := r.CreateIndexes(ctx); err != nil {
+		l.Fatal().Err(err).Msg("failed to create indexes")
+	}
+
 	fr := repository.NewFileRepository()
 
 	c := cache.New(cfg.Cache.DefaultExpiration, cfg.Cache.CleanupInterval)
 
 	caching := caching.New(c)
 
-	s := service.New(service.Deps{
-		UserRepo:       r,
-		TokenRepo:      r,
-		Cache:          caching,
-		DocumentRepo:   r,
-		FileRepo:       fr,
-		DefaultTimeout: cfg.Service.DefaultTimeout,
-		HashCost:       cfg.Service.HashCost,
-		TokenEndTTL:    cfg.Service.TokenEndTTl,
-		SecretKey:      cfg.Service.SecretKey,
-	})
+	bus := events.NewBus()
+
+	changes, err := r.WatchDocuments(ctx)
+	if err != nil {
+		l.Warn().Err(err).Msg("change streams are unavailable, using in-process events")
+	} else {
+		bus.Attach(ctx, changes)
+	}
+
+	hashes := service.NewHashLimiter(cfg.Auth.HashConcurrency)
+
+	deps := service.Deps{
+		UserRepo:        r,
+		TokenRepo:       r,
+		Cache:           caching,
+		DocumentRepo:    r,
+		FileRepo:        fr,
+		RetentionRepo:   r,
+		VersionRepo:     r,
+		CommentRepo:     r,
+		RelationRepo:    r,
+		APIKeyRepo:      r,
+		SigningKeyRepo:  r,
+		OIDCStateRepo:   r,
+		TwoFactorRepo:   r,
+		ResetRepo:       r,
+		AttemptRepo:     r,
+		Keys:            keyring.New(),
+		AuditRepo:       r,
+		WebhookRepo:     r,
+		WebhookClient:   service.NewWebhookClient(cfg.Webhook.AllowPrivate),
+		Events:          bus,
+		DefaultTimeout:  cfg.Service.DefaultTimeout,
+		HashCost:        cfg.Service.HashCost,
+		Hashes:          hashes,
+		AccessTokenTTL:  cfg.Service.AccessTokenTTL,
+		RefreshTokenTTL: cfg.Service.RefreshTokenTTL,
+		SecretKey:       cfg.Service.SecretKey,
+		TrashRetention:  cfg.Service.TrashRetention,
+		Admins:          cfg.Service.Admins,
+		LockTTL:         cfg.Service.LockTTL,
+		LockMaxTTL:      cfg.Service.LockMaxTTL,
+		RequireIfMatch:  cfg.Service.RequireIfMatch,
+
+		ImportMaxEntrySize: cfg.Import.MaxEntrySize,
+
+		AuthProvision: cfg.Auth.Provision,
+		Throttle: service.LoginThrottle{
+			Window:      cfg.Auth.Throttle.Window,
+			BaseDelay:   cfg.Auth.Throttle.BaseDelay,
+			MaxDelay:    cfg.Auth.Throttle.MaxDelay,
+			LockoutTime: cfg.Auth.Throttle.LockoutTime,
+			Login:       service.AttemptLimit{Free: cfg.Auth.Throttle.LoginFree, Lockout: cfg.Auth.Throttle.LoginLockout},
+			IP:          service.AttemptLimit{Free: cfg.Auth.Throttle.IPFree, Lockout: cfg.Auth.Throttle.IPLockout},
+		},
+
+		PasswordResetTTL: cfg.PasswordReset.TTL,
+		PasswordResetURL: cfg.PasswordReset.URL,
+
+		TOTPIssuer:        cfg.TwoFactor.Issuer,
+		ChallengeTTL:      cfg.TwoFactor.ChallengeTTL,
+		ChallengeAttempts: cfg.TwoFactor.MaxAttempts,
+
+		SigningAlgorithm: cfg.Service.Signing.Algorithm,
+		KeyRotation:      cfg.Service.Signing.Rotation,
+		KeyGracePeriod:   cfg.Service.Signing.GracePeriod,
+		KeyPublishDelay:  cfg.Service.Signing.CheckInterval,
+
+		OIDCLoginClaim: cfg.OIDC.LoginClaim,
+		OIDCProvision:  cfg.OIDC.Provision,
+		OIDCStateTTL:   cfg.OIDC.StateTTL,
+
+		WebhookTimeout:      cfg.Webhook.Timeout,
+		WebhookBackoff:      cfg.Webhook.Backoff,
+		WebhookMaxAttempts:  cfg.Webhook.MaxAttempts,
+		WebhookAllowPrivate: cfg.Webhook.AllowPrivate,
+	}
 
-	h := handler.New(l, s, s)
+	if r.SupportsTransactions(ctx) {
+		deps.Tx = r
+	} else {
+		l.Warn().Msg("transactions are unavailable, webhook deliveries are enqueued separately from changes")
+	}
+
+	if cfg.OIDC.Enabled {
+		deps.OIDC = oidc.New(oidc.Config{
+			Issuer:       cfg.OIDC.Issuer,
+			ClientID:     cfg.OIDC.ClientID,
+			ClientSecret: cfg.OIDC.ClientSecret,
+			RedirectURL:  cfg.OIDC.RedirectURL,
+			Scopes:       cfg.OIDC.Scopes,
+		})
+	}
+
+	switch cfg.Notifier.Kind {
+	case "log":
+		deps.Notifier = notify.NewLog(l)
+	case "smtp":
+		deps.Notifier = notify.NewSMTP(notify.SMTPConfig{
+			Host:     cfg.Notifier.SMTP.Host,
+			Port:     cfg.Notifier.SMTP.Port,
+			Username: cfg.Notifier.SMTP.Username,
+			Password: cfg.Notifier.SMTP.Password,
+			From:     cfg.Notifier.SMTP.From,
+			Timeout:  cfg.Notifier.SMTP.Timeout,
+		})
+	default:
+		l.Fatal().Msgf("unknown notifier: %s", cfg.Notifier.Kind)
+	}
+
+	for _, provider := range cfg.Auth.Providers {
+		switch provider {
+		case service.ProviderPassword:
+			deps.Authenticators = append(deps.Authenticators, service.NewPasswordAuthenticator(r, hashes))
+		case directory.ProviderLDAP:
+			deps.Authenticators = append(deps.Authenticators, directory.New(directory.Config{
+				URL:            cfg.LDAP.URL,
+				StartTLS:       cfg.LDAP.StartTLS,
+				BindDN:         cfg.LDAP.BindDN,
+				BindPassword:   cfg.LDAP.BindPassword,
+				BaseDN:         cfg.LDAP.BaseDN,
+				UserFilter:     cfg.LDAP.UserFilter,
+				GroupAttribute: cfg.LDAP.GroupAttribute,
+				GroupRoles:     cfg.LDAP.GroupRoles,
+				Timeout:        cfg.LDAP.Timeout,
+			}, nil))
+		default:
+			l.Fatal().Msgf("unknown auth provider: %s", provider)
+		}
+	}
+
+	s := service.New(deps)
+
+	if err := s.RotateSigningKeys(ctx); err != nil {
+		l.Fatal().Err(err).Msg("failed to load signing keys")
+	}
+
+	go worker.New(l, "signing key rotation", cfg.Service.Signing.CheckInterval, s.RotateSigningKeys).Run(ctx)
+	go worker.New(l, "trash purger", cfg.Service.TrashPurgeInterval, s.PurgeTrash).Run(ctx)
+	go worker.New(l, "expiration reaper", cfg.Service.ExpirationInterval, s.RemoveExpired).Run(ctx)
+	go worker.New(l, "webhook delivery", cfg.Webhook.Interval, s.DeliverWebhooks).Run(ctx)
+
+	h := handler.New(l, handler.Deps{
+		UserService:      s,
+		DocumentService:  s,
+		RetentionService: s,
+		AuditService:     s,
+		WebhookService:   s,
+		EventService:     s,
+		AccountService:   s,
+		CommentService:   s,
+		RelationService:  s,
+		AllowedOrigins:   cfg.HTTP.AllowedOrigins,
+		ImportMaxSize:    cfg.Import.MaxSize,
+	})
 
 	server := server.New(
 		h.Handler(),
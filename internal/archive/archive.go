@@ -0,0 +1,112 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+const (
+	FormatZip   = "zip"
+	FormatTarGz = "tar.gz"
+)
+
+var ErrUnsupportedFormat = errors.New("unsupported archive format")
+
+// Writer streams entries into an archive without buffering it in memory.
+type Writer interface {
+	Add(name string, size int64, modTime time.Time, r io.Reader) error
+	Close() error
+}
+
+func NewWriter(w io.Writer, format string) (Writer, error) {
+	switch format {
+	case FormatZip, "":
+		return &zipWriter{zw: zip.NewWriter(w)}, nil
+	case FormatTarGz:
+		gw := gzip.NewWriter(w)
+
+		return &tarGzWriter{gw: gw, tw: tar.NewWriter(gw)}, nil
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+func ContentType(format string) string {
+	if format == FormatTarGz {
+		return "application/gzip"
+	}
+
+	return "application/zip"
+}
+
+func Extension(format string) string {
+	if format == FormatTarGz {
+		return FormatTarGz
+	}
+
+	return FormatZip
+}
+
+type zipWriter struct {
+	zw *zip.Writer
+}
+
+func (z *zipWriter) Add(name string, size int64, modTime time.Time, r io.Reader) error {
+	header := &zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: modTime,
+	}
+
+	w, err := z.zw.CreateHeader(header)
+	if err != nil {
+		return fmt.Errorf("creating zip entry: %w", err)
+	}
+
+	if _, err := io.Copy(w, r); err != nil {
+		return fmt.Errorf("writing zip entry: %w", err)
+	}
+
+	return nil
+}
+
+func (z *zipWriter) Close() error {
+	return z.zw.Close()
+}
+
+type tarGzWriter struct {
+	gw *gzip.Writer
+	tw *tar.Writer
+}
+
+func (t *tarGzWriter) Add(name string, size int64, modTime time.Time, r io.Reader) error {
+	header := &tar.Header{
+		Name:    name,
+		Mode:    0o644,
+		Size:    size,
+		ModTime: modTime,
+	}
+
+	if err := t.tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("creating tar entry: %w", err)
+	}
+
+	if _, err := io.CopyN(t.tw, r, size); err != nil {
+		return fmt.Errorf("writing tar entry: %w", err)
+	}
+
+	return nil
+}
+
+func (t *tarGzWriter) Close() error {
+	if err := t.tw.Close(); err != nil {
+		return err
+	}
+
+	return t.gw.Close()
+}
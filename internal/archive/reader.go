@@ -0,0 +1,105 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+)
+
+// Reader iterates over archive entries in the order they were written.
+// Next returns io.EOF after the last entry.
+type Reader interface {
+	Next() (string, io.Reader, error)
+	Close() error
+}
+
+func NewReader(r io.ReaderAt, size int64, format string) (Reader, error) {
+	switch format {
+	case FormatZip, "":
+		zr, err := zip.NewReader(r, size)
+		if err != nil {
+			return nil, fmt.Errorf("opening zip: %w", err)
+		}
+
+		return &zipReader{zr: zr}, nil
+	case FormatTarGz:
+		gr, err := gzip.NewReader(io.NewSectionReader(r, 0, size))
+		if err != nil {
+			return nil, fmt.Errorf("opening gzip: %w", err)
+		}
+
+		return &tarGzReader{gr: gr, tr: tar.NewReader(gr)}, nil
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+type zipReader struct {
+	zr      *zip.Reader
+	next    int
+	current io.ReadCloser
+}
+
+func (z *zipReader) Next() (string, io.Reader, error) {
+	if err := z.closeCurrent(); err != nil {
+		return "", nil, err
+	}
+
+	for z.next < len(z.zr.File) {
+		file := z.zr.File[z.next]
+		z.next++
+
+		if file.FileInfo().IsDir() {
+			continue
+		}
+
+		rc, err := file.Open()
+		if err != nil {
+			return "", nil, fmt.Errorf("opening zip entry: %w", err)
+		}
+		z.current = rc
+
+		return file.Name, rc, nil
+	}
+
+	return "", nil, io.EOF
+}
+
+func (z *zipReader) Close() error {
+	return z.closeCurrent()
+}
+
+func (z *zipReader) closeCurrent() error {
+	if z.current == nil {
+		return nil
+	}
+
+	err := z.current.Close()
+	z.current = nil
+
+	return err
+}
+
+type tarGzReader struct {
+	gr *gzip.Reader
+	tr *tar.Reader
+}
+
+func (t *tarGzReader) Next() (string, io.Reader, error) {
+	for {
+		header, err := t.tr.Next()
+		if err != nil {
+			return "", nil, err
+		}
+
+		if header.Typeflag == tar.TypeReg {
+			return header.Name, t.tr, nil
+		}
+	}
+}
+
+func (t *tarGzReader) Close() error {
+	return t.gr.Close()
+}
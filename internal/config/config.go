@@ -13,6 +13,7 @@ type HTTP struct {
 	MaxHeaderBytes    int           `yaml:"max_header_bytes"`
 	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout"`
 	WriteTimeout      time.Duration `yaml:"write_timeout"`
+	AllowedOrigins    []string      `yaml:"allowed_origins"`
 }
 
 type Mongo struct {
@@ -20,10 +21,127 @@ type Mongo struct {
 }
 
 type Service struct {
-	DefaultTimeout time.Duration `yaml:"default_timeout"`
-	HashCost       int           `yaml:"hash_cost"`
-	TokenEndTTl    time.Duration `yaml:"token_end_ttl"`
-	SecretKey      string        `env:"SECRET_KEY" env-required:"true"`
+	DefaultTimeout     time.Duration `yaml:"default_timeout"`
+	HashCost           int           `yaml:"hash_cost"`
+	AccessTokenTTL     time.Duration `yaml:"access_token_ttl"`
+	RefreshTokenTTL    time.Duration `yaml:"refresh_token_ttl"`
+	SecretKey          string        `env:"SECRET_KEY" env-required:"true"`
+	TrashRetention     time.Duration `yaml:"trash_retention"`
+	TrashPurgeInterval time.Duration `yaml:"trash_purge_interval"`
+	ExpirationInterval time.Duration `yaml:"expiration_interval"`
+	Admins             []string      `yaml:"admins"`
+	LockTTL            time.Duration `yaml:"lock_ttl"`
+	LockMaxTTL         time.Duration `yaml:"lock_max_ttl"`
+	RequireIfMatch     bool          `yaml:"require_if_match"`
+	Signing            Signing       `yaml:"signing"`
+}
+
+// Signing configures access token signing. With HS256 tokens are signed with
+// SECRET_KEY, RS256 and EdDSA keys are generated and rotated by the service.
+// The grace period should outlast an access token and the check interval.
+type Signing struct {
+	Algorithm     string        `yaml:"algorithm" env-default:"HS256"`
+	Rotation      time.Duration `yaml:"rotation"`
+	GracePeriod   time.Duration `yaml:"grace_period"`
+	CheckInterval time.Duration `yaml:"check_interval"`
+}
+
+// OIDC configures login through an OpenID Connect provider. LoginClaim names
+// the ID token claim used as the doc-store login.
+type OIDC struct {
+	Enabled      bool          `yaml:"enabled"`
+	Issuer       string        `yaml:"issuer"`
+	ClientID     string        `yaml:"client_id"`
+	ClientSecret string        `env:"OIDC_CLIENT_SECRET"`
+	RedirectURL  string        `yaml:"redirect_url"`
+	Scopes       []string      `yaml:"scopes"`
+	LoginClaim   string        `yaml:"login_claim"`
+	Provision    bool          `yaml:"provision"`
+	StateTTL     time.Duration `yaml:"state_ttl"`
+}
+
+// Auth lists the authenticators tried on login in order, "password" checks
+// the stored password and "ldap" binds against the directory.
+type Auth struct {
+	Providers []string `yaml:"providers"`
+	Provision bool     `yaml:"provision"`
+	// HashConcurrency bounds concurrent bcrypt operations, 0 means one per CPU.
+	HashConcurrency int      `yaml:"hash_concurrency"`
+	Throttle        Throttle `yaml:"throttle"`
+}
+
+// Throttle configures failed login tracking per login and per IP: after the
+// free attempts the delay doubles up to max_delay, after lockout attempts the
+// login or IP is locked for lockout_time.
+type Throttle struct {
+	Window       time.Duration `yaml:"window"`
+	BaseDelay    time.Duration `yaml:"base_delay"`
+	MaxDelay     time.Duration `yaml:"max_delay"`
+	LockoutTime  time.Duration `yaml:"lockout_time"`
+	LoginFree    int           `yaml:"login_free"`
+	LoginLockout int           `yaml:"login_lockout"`
+	IPFree       int           `yaml:"ip_free"`
+	IPLockout    int           `yaml:"ip_lockout"`
+}
+
+// LDAP configures the directory authenticator. UserFilter gets the escaped
+// login in place of %s, GroupRoles maps group DNs to roles.
+type LDAP struct {
+	URL            string            `yaml:"url"`
+	StartTLS       bool              `yaml:"start_tls"`
+	BindDN         string            `yaml:"bind_dn"`
+	BindPassword   string            `env:"LDAP_BIND_PASSWORD"`
+	BaseDN         string            `yaml:"base_dn"`
+	UserFilter     string            `yaml:"user_filter"`
+	GroupAttribute string            `yaml:"group_attribute"`
+	GroupRoles     map[string]string `yaml:"group_roles"`
+	Timeout        time.Duration     `yaml:"timeout"`
+}
+
+// TwoFactor configures TOTP. A login with 2FA must be completed within the
+// challenge TTL and the allowed number of attempts.
+type TwoFactor struct {
+	Issuer       string        `yaml:"issuer"`
+	ChallengeTTL time.Duration `yaml:"challenge_ttl"`
+	MaxAttempts  int           `yaml:"max_attempts"`
+}
+
+// PasswordReset configures reset tokens, URL is sent with the token in
+// place of %s and the bare token is sent without it.
+type PasswordReset struct {
+	TTL time.Duration `yaml:"ttl"`
+	URL string        `yaml:"url"`
+}
+
+// Notifier selects how notifications are delivered: "log" writes them to the
+// log for development, "smtp" sends mail.
+type Notifier struct {
+	Kind string `yaml:"kind" env-default:"log"`
+	SMTP SMTP   `yaml:"smtp"`
+}
+
+type SMTP struct {
+	Host     string        `yaml:"host"`
+	Port     int           `yaml:"port"`
+	Username string        `yaml:"username"`
+	Password string        `env:"SMTP_PASSWORD"`
+	From     string        `yaml:"from"`
+	Timeout  time.Duration `yaml:"timeout"`
+}
+
+type Webhook struct {
+	Interval     time.Duration `yaml:"interval"`
+	Timeout      time.Duration `yaml:"timeout"`
+	Backoff      time.Duration `yaml:"backoff"`
+	MaxAttempts  int           `yaml:"max_attempts"`
+	AllowPrivate bool          `yaml:"allow_private"`
+}
+
+// Import bounds account imports: max_size is the size of the request body,
+// max_entry_size the uncompressed size of every archive entry.
+type Import struct {
+	MaxSize      int64 `yaml:"max_size"`
+	MaxEntrySize int64 `yaml:"max_entry_size"`
 }
 
 type Cache struct {
@@ -32,10 +150,18 @@ type Cache struct {
 }
 
 type Config struct {
-	HTTP    HTTP    `yaml:"http"`
-	Service Service `yaml:"service"`
-	Mongo   Mongo   `yaml:"mongo"`
-	Cache   Cache   `yaml:"cache"`
+	HTTP          HTTP          `yaml:"http"`
+	Service       Service       `yaml:"service"`
+	Mongo         Mongo         `yaml:"mongo"`
+	Cache         Cache         `yaml:"cache"`
+	Webhook       Webhook       `yaml:"webhook"`
+	OIDC          OIDC          `yaml:"oidc"`
+	Auth          Auth          `yaml:"auth"`
+	LDAP          LDAP          `yaml:"ldap"`
+	TwoFactor     TwoFactor     `yaml:"two_factor"`
+	PasswordReset PasswordReset `yaml:"password_reset"`
+	Notifier      Notifier      `yaml:"notifier"`
+	Import        Import        `yaml:"import"`
 }
 
 func New(path string) (*Config, error) {
@@ -0,0 +1,48 @@
+package core
+
+import "time"
+
+const (
+	ConflictRename    = "rename"
+	ConflictSkip      = "skip"
+	ConflictOverwrite = "overwrite"
+
+	ManifestName = "manifest.json"
+)
+
+// ExportManifest describes the content of an account archive. It is the
+// first entry of the archive so that an import can verify entries as they
+// are read.
+type ExportManifest struct {
+	Version   int              `json:"version"`
+	Login     string           `json:"login"`
+	Exported  time.Time        `json:"exported"`
+	Documents []ExportDocument `json:"documents"`
+}
+
+type ExportDocument struct {
+	Document Document     `json:"document"`
+	Json     *ExportEntry `json:"json,omitempty"`
+	File     *ExportEntry `json:"file,omitempty"`
+}
+
+type ExportEntry struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// AccountDeletion confirms the deletion with the password and a second factor
+// when the user has them. Documents are transferred to the TransferTo login
+// when it is set and deleted otherwise.
+type AccountDeletion struct {
+	Token      string `json:"token,omitempty"`
+	Password   string `json:"pswd"`
+	Code       string `json:"code,omitempty"`
+	TransferTo string `json:"transfer_to,omitempty"`
+}
+
+type ExportRequest struct {
+	Token  string `json:"token"`
+	Format string `json:"format"`
+}
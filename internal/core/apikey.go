@@ -0,0 +1,48 @@
+package core
+
+import (
+	"slices"
+	"time"
+)
+
+const (
+	// APIKeyPrefix starts every API key so it is told apart from a JWT.
+	APIKeyPrefix = "dsk_"
+
+	ScopeDocsRead   = "docs:read"
+	ScopeDocsWrite  = "docs:write"
+	ScopeDocsDelete = "docs:delete"
+	ScopeShare      = "share"
+)
+
+// Scopes lists the scopes an API key can be granted.
+var Scopes = []string{ScopeDocsRead, ScopeDocsWrite, ScopeDocsDelete, ScopeShare}
+
+// APIKey is a long-lived credential of a user for automation. Only the hash
+// of the key is stored.
+type APIKey struct {
+	ID         string     `json:"id" bson:"_id"`
+	Token      string     `json:"token,omitempty" bson:"-"`
+	UserID     string     `json:"-" bson:"user_id"`
+	Login      string     `json:"-" bson:"login"`
+	Name       string     `json:"name" bson:"name"`
+	Prefix     string     `json:"prefix" bson:"prefix"`
+	Hash       string     `json:"-" bson:"hash"`
+	Scopes     []string   `json:"scopes" bson:"scopes"`
+	AllowedIPs []string   `json:"allowed_ips,omitempty" bson:"allowed_ips,omitempty"`
+	TTL        string     `json:"ttl,omitempty" bson:"-"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty" bson:"expires_at,omitempty"`
+	Created    time.Time  `json:"created" bson:"created"`
+	LastUsed   *time.Time `json:"last_used,omitempty" bson:"last_used,omitempty"`
+}
+
+// HasScope reports whether the principal may act within the scope. Access
+// tokens are not limited by scopes, API keys hold the scopes they were
+// granted.
+func (p Principal) HasScope(scope string) bool {
+	if len(p.APIKeyID) == 0 {
+		return true
+	}
+
+	return len(scope) > 0 && slices.Contains(p.Scopes, scope)
+}
@@ -0,0 +1,92 @@
+package core
+
+import "time"
+
+const (
+	AuditResultSuccess = "success"
+	AuditResultFailure = "failure"
+)
+
+const (
+	ActionRegister  = "user.register"
+	ActionAuth      = "user.auth"
+	ActionLogout    = "user.logout"
+	ActionRefresh   = "user.refresh"
+	ActionOIDC      = "user.oidc"
+	ActionTwoFactor = "user.2fa"
+
+	ActionPasswordChange       = "user.password_change"
+	ActionPasswordResetRequest = "user.password_reset_request"
+	ActionPasswordReset        = "user.password_reset"
+
+	ActionSessionRevoke    = "session.revoke"
+	ActionSessionRevokeAll = "session.revoke_all"
+
+	ActionAPIKeyCreate = "apikey.create"
+	ActionAPIKeyDelete = "apikey.delete"
+
+	ActionTOTPEnable     = "2fa.enable"
+	ActionTOTPDisable    = "2fa.disable"
+	ActionRecoveryCodes  = "2fa.recovery_codes"
+	ActionTwoFactorRules = "2fa.policy"
+
+	ActionDocumentCreate  = "document.create"
+	ActionDocumentRead    = "document.read"
+	ActionDocumentList    = "document.list"
+	ActionDocumentUpdate  = "document.update"
+	ActionDocumentShare   = "document.share"
+	ActionDocumentDelete  = "document.delete"
+	ActionDocumentRestore = "document.restore"
+	ActionDocumentPurge   = "document.purge"
+	ActionTrashList       = "trash.list"
+
+	ActionDocumentLock        = "document.lock"
+	ActionDocumentUnlock      = "document.unlock"
+	ActionDocumentForceUnlock = "document.force_unlock"
+
+	ActionCommentCreate  = "comment.create"
+	ActionCommentUpdate  = "comment.update"
+	ActionCommentResolve = "comment.resolve"
+	ActionCommentDelete  = "comment.delete"
+
+	ActionRelationCreate = "relation.create"
+	ActionRelationDelete = "relation.delete"
+
+	ActionLegalHoldPlace   = "hold.place"
+	ActionLegalHoldRelease = "hold.release"
+	ActionPolicyCreate     = "retention.create"
+	ActionPolicyDelete     = "retention.delete"
+
+	ActionWebhookCreate = "webhook.create"
+	ActionWebhookDelete = "webhook.delete"
+
+	ActionAccountExport = "account.export"
+	ActionAccountImport = "account.import"
+	ActionAccountDelete = "account.delete"
+
+	ActionAccessDenied = "access.denied"
+)
+
+// AuditEvent is an append-only record of an operation performed by a user.
+type AuditEvent struct {
+	ID         string    `json:"id" bson:"_id"`
+	Actor      string    `json:"actor" bson:"actor"`
+	Action     string    `json:"action" bson:"action"`
+	DocumentID string    `json:"document_id,omitempty" bson:"document_id,omitempty"`
+	Path       string    `json:"path,omitempty" bson:"path,omitempty"`
+	IP         string    `json:"ip" bson:"ip"`
+	UserAgent  string    `json:"user_agent" bson:"user_agent"`
+	Result     string    `json:"result" bson:"result"`
+	Error      string    `json:"error,omitempty" bson:"error,omitempty"`
+	Time       time.Time `json:"time" bson:"time"`
+}
+
+type AuditFilter struct {
+	Token      string     `json:"token"`
+	Actor      string     `json:"actor"`
+	Action     string     `json:"action"`
+	DocumentID string     `json:"document_id"`
+	From       *time.Time `json:"from"`
+	To         *time.Time `json:"to"`
+	Limit      int64      `json:"limit"`
+}
@@ -0,0 +1,44 @@
+package core
+
+import (
+	"io"
+	"io/fs"
+)
+
+type BulkDocument struct {
+	Meta Document
+	File []byte
+}
+
+type BulkDeleteRequest struct {
+	Token  string          `json:"token"`
+	IDs    []string        `json:"ids"`
+	Filter *DocumentFilter `json:"filter,omitempty"`
+}
+
+// BulkResult is the outcome of a single item of a bulk request.
+type BulkResult struct {
+	Index  int    `json:"index"`
+	ID     string `json:"id,omitempty"`
+	Name   string `json:"name,omitempty"`
+	Status int    `json:"status"`
+	Error  string `json:"error,omitempty"`
+	Err    error  `json:"-"`
+}
+
+type ArchiveRequest struct {
+	Token  string          `json:"token"`
+	IDs    []string        `json:"ids"`
+	Filter *DocumentFilter `json:"filter,omitempty"`
+	Format string          `json:"format"`
+}
+
+// ArchiveEntry is a single file of an archive, either a stored file or
+// the JSON body of a document.
+type ArchiveEntry struct {
+	Name     string
+	Document Document
+	Json     []byte
+	// Open reads the stored file of the entry, JSON entries have none.
+	Open func() (io.ReadCloser, fs.FileInfo, error)
+}
@@ -0,0 +1,21 @@
+package core
+
+import "time"
+
+// Comment is a remark on a document, optionally anchored to a JSON pointer
+// of the document body or a page of its file. Replies reference the first
+// comment of their thread.
+type Comment struct {
+	ID         string     `json:"id" bson:"_id"`
+	Token      string     `json:"token,omitempty" bson:"-"`
+	DocumentID string     `json:"document_id" bson:"document_id"`
+	ParentID   string     `json:"parent_id,omitempty" bson:"parent_id,omitempty"`
+	Author     string     `json:"author" bson:"author"`
+	Body       string     `json:"body" bson:"body"`
+	JSONPath   string     `json:"json_path,omitempty" bson:"json_path,omitempty"`
+	Page       int        `json:"page,omitempty" bson:"page,omitempty"`
+	Resolved   bool       `json:"resolved" bson:"resolved"`
+	ResolvedBy string     `json:"resolved_by,omitempty" bson:"resolved_by,omitempty"`
+	Created    time.Time  `json:"created" bson:"created"`
+	Updated    *time.Time `json:"updated,omitempty" bson:"updated,omitempty"`
+}
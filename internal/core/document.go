@@ -2,6 +2,18 @@ package core
 
 import "time"
 
+const (
+	SortAsc  = "asc"
+	SortDesc = "desc"
+
+	// AnyRevision is the expected revision of "If-Match: *", it matches any
+	// existing document.
+	AnyRevision int64 = -1
+)
+
+// Attributes holds user-defined key/value metadata attached to a document.
+type Attributes map[string]string
+
 type DocumentRequest struct {
 	Meta Document `json:"meta"`
 	Json []byte   `json:"json,omitempty"`
@@ -9,21 +21,45 @@ type DocumentRequest struct {
 }
 
 type Document struct {
-	ID      string    `json:"-" bson:"_id"`
-	Name    string    `json:"name" bson:"name"`
-	Mime    string    `json:"mime" bson:"mime"`
-	File    bool      `json:"file" bson:"file"`
-	Token   string    `json:"token" bson:"-"`
-	Json    []byte    `json:"-" bson:"json"`
-	Public  bool      `json:"public" bson:"public"`
-	Created time.Time `json:"created" bson:"created"`
-	Grant   []string  `json:"grant" bson:"grant"`
+	ID         string     `json:"id" bson:"_id"`
+	OwnerID    string     `json:"-" bson:"owner_id"`
+	Name       string     `json:"name" bson:"name"`
+	Mime       string     `json:"mime" bson:"mime"`
+	File       bool       `json:"file" bson:"file"`
+	Token      string     `json:"token,omitempty" bson:"-"`
+	Json       []byte     `json:"-" bson:"json"`
+	Public     bool       `json:"public" bson:"public"`
+	Created    time.Time  `json:"created" bson:"created"`
+	Grant      []string   `json:"grant" bson:"grant"`
+	Attributes Attributes `json:"attributes,omitempty" bson:"attributes,omitempty"`
+	Tags       []string   `json:"tags,omitempty" bson:"tags,omitempty"`
+	Folder     string     `json:"folder,omitempty" bson:"folder,omitempty"`
+	LegalHold  bool       `json:"legal_hold" bson:"legal_hold"`
+	DeletedAt  *time.Time `json:"deleted_at,omitempty" bson:"deleted_at,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty" bson:"expires_at,omitempty"`
+	TTL        string     `json:"ttl,omitempty" bson:"-"`
+	Lock       *Lock      `json:"lock,omitempty" bson:"lock,omitempty"`
+	Revision   int64      `json:"revision" bson:"revision"`
+	IfMatch    *int64     `json:"-" bson:"-"`
+}
+
+type DocumentUpdate struct {
+	Token      string     `json:"token"`
+	Attributes Attributes `json:"attributes"`
+	Unset      []string   `json:"unset"`
+	IfMatch    *int64     `json:"-"`
 }
 
 type DocumentFilter struct {
-	Token string `json:"token"`
-	Login string `json:"login"`
-	Key   string `json:"key"`
-	Value string `json:"value"`
-	Limit int64  `json:"limit"`
+	Token      string     `json:"token"`
+	Login      string     `json:"login"`
+	OwnerID    string     `json:"-"`
+	Key        string     `json:"key"`
+	Value      string     `json:"value"`
+	Attributes Attributes `json:"attributes"`
+	Tag        string     `json:"tag"`
+	Folder     string     `json:"folder"`
+	Sort       string     `json:"sort"`
+	Order      string     `json:"order"`
+	Limit      int64      `json:"limit"`
 }
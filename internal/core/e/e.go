@@ -1,6 +1,9 @@
 package e
 
-import "errors"
+import (
+	"errors"
+	"time"
+)
 
 var (
 	ErrInvalidPassword  = errors.New("invalid password")
@@ -8,6 +11,61 @@ var (
 	ErrUserAlreadyExist = errors.New("user already exist")
 	ErrUserNotFound     = errors.New("user not found")
 	ErrNoDocuments      = errors.New("documents not found")
+	ErrDocumentExist    = errors.New("document already exist")
+	ErrInvalidMeta      = errors.New("invalid document meta")
+	ErrInvalidName      = errors.New("invalid document name")
+	ErrInvalidArchive   = errors.New("invalid archive")
+	ErrInvalidConflict  = errors.New("invalid conflict resolution")
+	ErrInvalidAttribute = errors.New("invalid attribute")
+	ErrInvalidSort      = errors.New("invalid sort")
+	ErrInvalidFilter    = errors.New("invalid document filter")
+	ErrInvalidTTL       = errors.New("invalid document expiration")
+
+	ErrDocumentRetained = errors.New("document is retained")
+	ErrDocumentLocked   = errors.New("document is locked")
+	ErrInvalidLock      = errors.New("invalid lock duration")
+	ErrInvalidPolicy    = errors.New("invalid retention policy")
+	ErrForbidden        = errors.New("forbidden")
+	ErrInvalidWebhook   = errors.New("invalid webhook")
+	ErrWebhookNotFound  = errors.New("webhook not found")
+	ErrWebhookAddress   = errors.New("webhook address is not allowed")
+	ErrNoDeliveries     = errors.New("no webhook deliveries")
+
+	ErrPreconditionFailed   = errors.New("document revision does not match")
+	ErrPreconditionRequired = errors.New("If-Match header is required")
+
+	ErrVersionNotFound = errors.New("document version not found")
+	ErrNotDiffable     = errors.New("document versions can not be compared")
+	ErrFileTooLarge    = errors.New("file is too large")
+
+	ErrCommentNotFound = errors.New("comment not found")
+	ErrInvalidComment  = errors.New("invalid comment")
+
+	ErrRelationNotFound = errors.New("relation not found")
+	ErrRelationExist    = errors.New("relation already exist")
+	ErrInvalidRelation  = errors.New("invalid relation")
+
+	ErrRefreshTokenReused = errors.New("refresh token reuse detected")
+	ErrSessionNotFound    = errors.New("session not found")
+
+	ErrOIDCDisabled   = errors.New("oidc login is disabled")
+	ErrOIDCFailed     = errors.New("oidc login failed")
+	ErrAccountLinked  = errors.New("account is linked to another identity")
+	ErrInvalidAPIKey  = errors.New("invalid api key")
+	ErrAPIKeyNotFound = errors.New("api key not found")
+
+	ErrInvalidTOTP          = errors.New("invalid two-factor code")
+	ErrTwoFactorEnabled     = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnabled  = errors.New("two-factor authentication is not enabled")
+	ErrTwoFactorRequired    = errors.New("two-factor authentication is required")
+	ErrInvalidChallenge     = errors.New("invalid two-factor challenge")
+	ErrInvalidTwoFactorRule = errors.New("invalid two-factor policy")
+
+	ErrInvalidEmail      = errors.New("invalid email")
+	ErrInvalidResetToken = errors.New("invalid password reset token")
+	ErrInvalidTransfer   = errors.New("invalid account transfer")
+
+	ErrBusy = errors.New("server is busy")
 
 	ErrEmptyBody = errors.New("empty data")
 )
@@ -51,6 +109,16 @@ func (ed *ErrDelete) Unwrap() error {
 	return ed.Err
 }
 
+// ErrTooManyAttempts rejects a login of a login or IP, or a second factor code
+// of a user, with too many recent failures until RetryAfter has passed.
+type ErrTooManyAttempts struct {
+	RetryAfter time.Duration
+}
+
+func (eta *ErrTooManyAttempts) Error() string {
+	return "too many failed attempts"
+}
+
 type ErrInvalidToken struct {
 	Msg string
 	Err error
@@ -0,0 +1,23 @@
+package core
+
+import "time"
+
+// Lock is an exclusive check-out of a document. While it is active only its
+// owner may replace, update or delete the document.
+type Lock struct {
+	Owner     string    `json:"owner" bson:"owner"`
+	Reason    string    `json:"reason,omitempty" bson:"reason,omitempty"`
+	Created   time.Time `json:"created" bson:"created"`
+	ExpiresAt time.Time `json:"expires_at" bson:"expires_at"`
+}
+
+type LockRequest struct {
+	Token  string `json:"token"`
+	TTL    string `json:"ttl"`
+	Reason string `json:"reason"`
+}
+
+// Active reports whether the lock still holds at the given time.
+func (l *Lock) Active(now time.Time) bool {
+	return l != nil && now.Before(l.ExpiresAt)
+}
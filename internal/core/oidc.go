@@ -0,0 +1,11 @@
+package core
+
+import "time"
+
+// OIDCState is an authorization request waiting for its callback.
+type OIDCState struct {
+	State     string    `bson:"_id"`
+	Nonce     string    `bson:"nonce"`
+	Verifier  string    `bson:"verifier"`
+	ExpiresAt time.Time `bson:"expires_at"`
+}
@@ -0,0 +1,20 @@
+package core
+
+import "time"
+
+// Relation is a typed link from the source document to the target document,
+// e.g. "belongs_to" or "attachment_of".
+type Relation struct {
+	ID       string    `json:"id" bson:"_id"`
+	Token    string    `json:"token,omitempty" bson:"-"`
+	SourceID string    `json:"source_id" bson:"source_id"`
+	TargetID string    `json:"target_id" bson:"target_id"`
+	Type     string    `json:"type" bson:"type"`
+	Creator  string    `json:"creator" bson:"creator"`
+	Created  time.Time `json:"created" bson:"created"`
+}
+
+type DocumentRelations struct {
+	Outgoing []Relation `json:"outgoing"`
+	Incoming []Relation `json:"incoming"`
+}
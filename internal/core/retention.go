@@ -0,0 +1,17 @@
+package core
+
+import "time"
+
+// RetentionPolicy keeps documents with the given tag or in the given folder
+// from being deleted, replaced or expired until KeepFor has passed since
+// their creation.
+type RetentionPolicy struct {
+	ID      string        `json:"id" bson:"_id"`
+	Token   string        `json:"token,omitempty" bson:"-"`
+	Name    string        `json:"name" bson:"name"`
+	Tag     string        `json:"tag,omitempty" bson:"tag,omitempty"`
+	Folder  string        `json:"folder,omitempty" bson:"folder,omitempty"`
+	Keep    string        `json:"keep" bson:"-"`
+	KeepFor time.Duration `json:"-" bson:"keep_for"`
+	Created time.Time     `json:"created" bson:"created"`
+}
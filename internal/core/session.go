@@ -0,0 +1,14 @@
+package core
+
+import "time"
+
+// Session is a login of a user, it lasts while its refresh token is valid.
+type Session struct {
+	ID        string    `json:"id"`
+	Created   time.Time `json:"created"`
+	ExpiresAt time.Time `json:"expires_at"`
+	LastUsed  time.Time `json:"last_used"`
+	IP        string    `json:"ip,omitempty"`
+	UserAgent string    `json:"user_agent,omitempty"`
+	Current   bool      `json:"current"`
+}
@@ -0,0 +1,14 @@
+package core
+
+import "time"
+
+// SigningKey is a stored access token signing key. The private key is sealed
+// with the service secret.
+type SigningKey struct {
+	ID         string    `bson:"_id"`
+	Algorithm  string    `bson:"algorithm"`
+	PrivateKey []byte    `bson:"private_key"`
+	Created    time.Time `bson:"created"`
+	ActiveAt   time.Time `bson:"active_at"`
+	RetiresAt  time.Time `bson:"retires_at"`
+}
@@ -0,0 +1,59 @@
+package core
+
+import "time"
+
+// TwoFactor is the TOTP enrollment of a user, it is pending until the first
+// code confirms it. The secret is sealed with the service secret key and
+// recovery codes are stored as hashes.
+type TwoFactor struct {
+	Secret        []byte   `bson:"secret"`
+	Enabled       bool     `bson:"enabled"`
+	LastStep      int64    `bson:"last_step,omitempty"`
+	RecoveryCodes []string `bson:"recovery_codes,omitempty"`
+}
+
+// TOTPEnrollment is shown once to set up an authenticator app, QR is a PNG
+// data URL of the otpauth URI.
+type TOTPEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+	QR     string `json:"qr"`
+}
+
+// AuthChallenge is a login waiting for its second factor, Enroll marks a
+// login of a user the policy requires to set up TOTP first.
+type AuthChallenge struct {
+	ID        string    `bson:"_id"`
+	UserID    string    `bson:"user_id"`
+	Enroll    bool      `bson:"enroll,omitempty"`
+	Attempts  int       `bson:"attempts"`
+	ExpiresAt time.Time `bson:"expires_at"`
+}
+
+type TwoFactorChallenge struct {
+	Challenge  string          `json:"challenge"`
+	ExpiresIn  int64           `json:"expires_in"`
+	Enrollment *TOTPEnrollment `json:"enrollment,omitempty"`
+}
+
+// AuthResult holds either the tokens of a completed login or the challenge
+// of a login that needs a second factor.
+type AuthResult struct {
+	*TokenPair
+	TwoFactor     *TwoFactorChallenge `json:"two_factor,omitempty"`
+	RecoveryCodes []string            `json:"recovery_codes,omitempty"`
+}
+
+type TwoFactorRequest struct {
+	Token     string `json:"token,omitempty"`
+	Challenge string `json:"challenge,omitempty"`
+	Code      string `json:"code"`
+}
+
+// TwoFactorPolicy makes 2FA mandatory, for every user when Roles is empty.
+type TwoFactorPolicy struct {
+	Token    string    `json:"token,omitempty" bson:"-"`
+	Required bool      `json:"required" bson:"required"`
+	Roles    []string  `json:"roles,omitempty" bson:"roles,omitempty"`
+	Updated  time.Time `json:"updated" bson:"updated"`
+}
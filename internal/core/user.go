@@ -2,16 +2,113 @@ package core
 
 import "time"
 
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+
+	TokenKindAccess  = "access"
+	TokenKindRefresh = "refresh"
+)
+
 type User struct {
 	ID       string `json:"-" bson:"_id"`
 	Login    string `json:"login" bson:"login"`
 	Password string `json:"pswd" bson:"password"`
+	// Email receives password reset tokens, the login is used without it.
+	Email string `json:"email,omitempty" bson:"email,omitempty"`
+	Role  string `json:"-" bson:"role,omitempty"`
+	// ExternalID identifies the user at the identity provider, users signed
+	// in through OIDC only may have no password.
+	ExternalID string `json:"-" bson:"external_id,omitempty"`
+	// Provider is the external authenticator that provisioned the user, only
+	// it may sign the user in besides the stored password.
+	Provider  string     `json:"-" bson:"provider,omitempty"`
+	TwoFactor *TwoFactor `json:"-" bson:"two_factor,omitempty"`
+}
+
+// Principal is the authenticated caller of a request. SessionID is the token
+// family the caller's access token belongs to, a caller authenticated by an
+// API key has the key ID and scopes instead.
+type Principal struct {
+	UserID    string
+	Login     string
+	SessionID string
+	APIKeyID  string
+	Scopes    []string
+}
+
+// Client describes where a login or a token refresh comes from.
+type Client struct {
+	IP        string
+	UserAgent string
 }
 
+// AccessToken is a stored token. Refresh tokens are stored as a hash, tokens
+// issued by one login share the family ID.
 type AccessToken struct {
-	ID       string    `bson:"_id"`
-	Token    string    `bson:"token"`
-	StartTTL time.Time `bson:"start_ttl"`
-	EndTTl   time.Time `bson:"end_ttl"`
-	UserID   string    `bson:"user_id"`
+	ID             string     `bson:"_id"`
+	Token          string     `bson:"token"`
+	Kind           string     `bson:"kind,omitempty"`
+	FamilyID       string     `bson:"family_id,omitempty"`
+	StartTTL       time.Time  `bson:"start_ttl"`
+	EndTTl         time.Time  `bson:"end_ttl"`
+	UserID         string     `bson:"user_id"`
+	UsedAt         *time.Time `bson:"used_at,omitempty"`
+	SessionCreated time.Time  `bson:"session_created,omitempty"`
+	LastUsed       *time.Time `bson:"last_used,omitempty"`
+	IP             string     `bson:"ip,omitempty"`
+	UserAgent      string     `bson:"user_agent,omitempty"`
+}
+
+type PasswordChange struct {
+	Token       string `json:"token,omitempty"`
+	OldPassword string `json:"old_pswd"`
+	NewPassword string `json:"new_pswd"`
+}
+
+type PasswordResetRequest struct {
+	Login    string `json:"login"`
+	Token    string `json:"token"`
+	Password string `json:"pswd"`
+}
+
+// PasswordReset is a pending reset, the token is stored as a hash.
+type PasswordReset struct {
+	ID        string    `bson:"_id"`
+	UserID    string    `bson:"user_id"`
+	ExpiresAt time.Time `bson:"expires_at"`
+}
+
+// Notification is a message to a user, e.g. a password reset token.
+type Notification struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// LoginAttempt counts the recent failed logins of a login or an IP, the
+// record expires after a quiet period.
+type LoginAttempt struct {
+	Key         string    `bson:"_id"`
+	Failures    int       `bson:"failures"`
+	LastFailure time.Time `bson:"last_failure"`
+	ExpiresAt   time.Time `bson:"expires_at"`
+}
+
+type TokenPair struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// Identity is a login confirmed by an authenticator. An empty role leaves the
+// role of the user unchanged.
+type Identity struct {
+	Login    string
+	Role     string
+	Provider string
 }
@@ -0,0 +1,45 @@
+package core
+
+import "time"
+
+const (
+	ChangeAdded   = "added"
+	ChangeRemoved = "removed"
+	ChangeChanged = "changed"
+)
+
+// DocumentVersion is the content of a document before it was replaced. It
+// stays current up to and including Revision.
+type DocumentVersion struct {
+	ID         string    `json:"-" bson:"_id"`
+	DocumentID string    `json:"document_id" bson:"document_id"`
+	Revision   int64     `json:"revision" bson:"revision"`
+	Name       string    `json:"name" bson:"name"`
+	Mime       string    `json:"mime" bson:"mime"`
+	File       bool      `json:"file" bson:"file"`
+	Json       []byte    `json:"-" bson:"json"`
+	Created    time.Time `json:"created" bson:"created"`
+}
+
+type DiffRequest struct {
+	Token string `json:"token"`
+	From  int64  `json:"from"`
+	To    int64  `json:"to"`
+}
+
+// JSONChange is a single difference between two JSON values, Path is a JSON
+// pointer.
+type JSONChange struct {
+	Op   string `json:"op"`
+	Path string `json:"path"`
+	From any    `json:"from,omitempty"`
+	To   any    `json:"to,omitempty"`
+}
+
+type DocumentDiff struct {
+	DocumentID string       `json:"document_id"`
+	From       int64        `json:"from"`
+	To         int64        `json:"to"`
+	Changes    []JSONChange `json:"changes,omitempty"`
+	Unified    string       `json:"unified,omitempty"`
+}
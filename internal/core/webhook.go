@@ -0,0 +1,106 @@
+package core
+
+import "time"
+
+const (
+	EventDocumentCreated  = "document.created"
+	EventDocumentReplaced = "document.replaced"
+	EventDocumentUpdated  = "document.updated"
+	EventDocumentShared   = "document.shared"
+	EventDocumentDeleted  = "document.deleted"
+)
+
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+)
+
+type Webhook struct {
+	ID      string    `json:"id" bson:"_id"`
+	Token   string    `json:"token,omitempty" bson:"-"`
+	UserID  string    `json:"-" bson:"user_id"`
+	Login   string    `json:"-" bson:"login"`
+	URL     string    `json:"url" bson:"url"`
+	Events  []string  `json:"events" bson:"events"`
+	Secret  string    `json:"secret,omitempty" bson:"secret"`
+	Created time.Time `json:"created" bson:"created"`
+}
+
+// WebhookDelivery is an outbox entry holding a signed-once payload which is
+// retried until delivered or out of attempts.
+type WebhookDelivery struct {
+	ID          string    `json:"id" bson:"_id"`
+	WebhookID   string    `json:"webhook_id" bson:"webhook_id"`
+	Event       string    `json:"event" bson:"event"`
+	Payload     []byte    `json:"-" bson:"payload"`
+	Status      string    `json:"status" bson:"status"`
+	Attempts    int       `json:"attempts" bson:"attempts"`
+	NextAttempt time.Time `json:"next_attempt" bson:"next_attempt"`
+	LastError   string    `json:"last_error,omitempty" bson:"last_error,omitempty"`
+	Created     time.Time `json:"created" bson:"created"`
+}
+
+// DeliveryAttempt is a log record of a single webhook delivery attempt.
+type DeliveryAttempt struct {
+	ID         string        `json:"id" bson:"_id"`
+	DeliveryID string        `json:"delivery_id" bson:"delivery_id"`
+	WebhookID  string        `json:"webhook_id" bson:"webhook_id"`
+	Event      string        `json:"event" bson:"event"`
+	Attempt    int           `json:"attempt" bson:"attempt"`
+	StatusCode int           `json:"status_code,omitempty" bson:"status_code,omitempty"`
+	Error      string        `json:"error,omitempty" bson:"error,omitempty"`
+	Duration   time.Duration `json:"duration" bson:"duration"`
+	Time       time.Time     `json:"time" bson:"time"`
+}
+
+type WebhookPayload struct {
+	ID       string    `json:"id"`
+	Event    string    `json:"event"`
+	Actor    string    `json:"actor"`
+	Time     time.Time `json:"time"`
+	Document Document  `json:"document"`
+}
+
+// DocumentEvent is a change of a document pushed to the change feed.
+type DocumentEvent struct {
+	ID       string    `json:"id"`
+	Type     string    `json:"type"`
+	Actor    string    `json:"actor,omitempty"`
+	Time     time.Time `json:"time"`
+	Document Document  `json:"document"`
+}
+
+// FeedEvent is a document event as sent to change feed subscribers.
+type FeedEvent struct {
+	ID       string        `json:"id"`
+	Type     string        `json:"type"`
+	Actor    string        `json:"actor,omitempty"`
+	Time     time.Time     `json:"time"`
+	Document EventDocument `json:"document"`
+}
+
+// EventDocument is the document of a feed event, it has no request fields
+// such as the token.
+type EventDocument struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Mime       string     `json:"mime"`
+	File       bool       `json:"file"`
+	Public     bool       `json:"public"`
+	Created    time.Time  `json:"created"`
+	Grant      []string   `json:"grant"`
+	Attributes Attributes `json:"attributes,omitempty"`
+	Tags       []string   `json:"tags,omitempty"`
+	Folder     string     `json:"folder,omitempty"`
+	LegalHold  bool       `json:"legal_hold"`
+	DeletedAt  *time.Time `json:"deleted_at,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	Lock       *Lock      `json:"lock,omitempty"`
+	Revision   int64      `json:"revision"`
+}
+
+type ShareRequest struct {
+	Token string   `json:"token"`
+	Grant []string `json:"grant"`
+}
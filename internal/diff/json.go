@@ -0,0 +1,116 @@
+// Package diff compares document contents: JSON structurally and text line
+// by line.
+package diff
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/GroVlAn/doc-store/internal/core"
+)
+
+// JSON returns the added, removed and changed paths between two JSON
+// documents. Arrays are compared index by index.
+func JSON(from []byte, to []byte) ([]core.JSONChange, error) {
+	a, err := decode(from)
+	if err != nil {
+		return nil, fmt.Errorf("decoding old json: %w", err)
+	}
+
+	b, err := decode(to)
+	if err != nil {
+		return nil, fmt.Errorf("decoding new json: %w", err)
+	}
+
+	var changes []core.JSONChange
+	compare("", a, b, &changes)
+
+	return changes, nil
+}
+
+func decode(data []byte) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+func compare(path string, a any, b any, changes *[]core.JSONChange) {
+	switch a := a.(type) {
+	case map[string]any:
+		if b, ok := b.(map[string]any); ok {
+			compareObjects(path, a, b, changes)
+
+			return
+		}
+	case []any:
+		if b, ok := b.([]any); ok {
+			compareArrays(path, a, b, changes)
+
+			return
+		}
+	}
+
+	if !reflect.DeepEqual(a, b) {
+		*changes = append(*changes, core.JSONChange{Op: core.ChangeChanged, Path: path, From: a, To: b})
+	}
+}
+
+func compareObjects(path string, a map[string]any, b map[string]any, changes *[]core.JSONChange) {
+	keys := make([]string, 0, len(a)+len(b))
+	for key := range a {
+		keys = append(keys, key)
+	}
+	for key := range b {
+		if _, ok := a[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		keyPath := path + "/" + escape(key)
+
+		valueA, inA := a[key]
+		valueB, inB := b[key]
+
+		switch {
+		case !inB:
+			*changes = append(*changes, core.JSONChange{Op: core.ChangeRemoved, Path: keyPath, From: valueA})
+		case !inA:
+			*changes = append(*changes, core.JSONChange{Op: core.ChangeAdded, Path: keyPath, To: valueB})
+		default:
+			compare(keyPath, valueA, valueB, changes)
+		}
+	}
+}
+
+func compareArrays(path string, a []any, b []any, changes *[]core.JSONChange) {
+	for i := 0; i < max(len(a), len(b)); i++ {
+		indexPath := path + "/" + strconv.Itoa(i)
+
+		switch {
+		case i >= len(b):
+			*changes = append(*changes, core.JSONChange{Op: core.ChangeRemoved, Path: indexPath, From: a[i]})
+		case i >= len(a):
+			*changes = append(*changes, core.JSONChange{Op: core.ChangeAdded, Path: indexPath, To: b[i]})
+		default:
+			compare(indexPath, a[i], b[i], changes)
+		}
+	}
+}
+
+// escape encodes a key as a JSON pointer reference token.
+func escape(key string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+}
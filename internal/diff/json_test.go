@@ -0,0 +1,152 @@
+package diff
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/GroVlAn/doc-store/internal/core"
+)
+
+func TestJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		from string
+		to   string
+		want []core.JSONChange
+	}{
+		{
+			name: "equal",
+			from: `{"a": 1, "b": [1, 2]}`,
+			to:   `{"b": [1, 2], "a": 1}`,
+		},
+		{
+			name: "changed value",
+			from: `{"a": 1}`,
+			to:   `{"a": 2}`,
+			want: []core.JSONChange{{Op: core.ChangeChanged, Path: "/a", From: json.Number("1"), To: json.Number("2")}},
+		},
+		{
+			name: "added and removed keys in key order",
+			from: `{"b": true, "c": null}`,
+			to:   `{"a": "x", "b": true}`,
+			want: []core.JSONChange{
+				{Op: core.ChangeAdded, Path: "/a", To: "x"},
+				{Op: core.ChangeRemoved, Path: "/c"},
+			},
+		},
+		{
+			name: "nested object",
+			from: `{"a": {"b": {"c": 1}}}`,
+			to:   `{"a": {"b": {"c": 1, "d": 2}}}`,
+			want: []core.JSONChange{{Op: core.ChangeAdded, Path: "/a/b/d", To: json.Number("2")}},
+		},
+		{
+			name: "array by index",
+			from: `{"a": [1, 2, 3]}`,
+			to:   `{"a": [1, 5]}`,
+			want: []core.JSONChange{
+				{Op: core.ChangeChanged, Path: "/a/1", From: json.Number("2"), To: json.Number("5")},
+				{Op: core.ChangeRemoved, Path: "/a/2", From: json.Number("3")},
+			},
+		},
+		{
+			name: "array grows",
+			from: `[1]`,
+			to:   `[1, {"a": 1}]`,
+			want: []core.JSONChange{{Op: core.ChangeAdded, Path: "/1", To: map[string]any{"a": json.Number("1")}}},
+		},
+		{
+			name: "type changed",
+			from: `{"a": {"b": 1}}`,
+			to:   `{"a": [1]}`,
+			want: []core.JSONChange{{
+				Op:   core.ChangeChanged,
+				Path: "/a",
+				From: map[string]any{"b": json.Number("1")},
+				To:   []any{json.Number("1")},
+			}},
+		},
+		{
+			name: "root value",
+			from: `1`,
+			to:   `"1"`,
+			want: []core.JSONChange{{Op: core.ChangeChanged, Path: "", From: json.Number("1"), To: "1"}},
+		},
+		{
+			name: "large numbers keep their precision",
+			from: `{"a": 9007199254740993}`,
+			to:   `{"a": 9007199254740992}`,
+			want: []core.JSONChange{{
+				Op:   core.ChangeChanged,
+				Path: "/a",
+				From: json.Number("9007199254740993"),
+				To:   json.Number("9007199254740992"),
+			}},
+		},
+		{
+			name: "escaped path",
+			from: `{"a/b": {"c~d": 1}}`,
+			to:   `{"a/b": {"c~d": 2}}`,
+			want: []core.JSONChange{{Op: core.ChangeChanged, Path: "/a~1b/c~0d", From: json.Number("1"), To: json.Number("2")}},
+		},
+		{
+			name: "escaped tilde before slash",
+			from: `{}`,
+			to:   `{"~1": 1}`,
+			want: []core.JSONChange{{Op: core.ChangeAdded, Path: "/~01", To: json.Number("1")}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := JSON([]byte(tt.from), []byte(tt.to))
+			if err != nil {
+				t.Fatalf("JSON() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("JSON() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		from string
+		to   string
+	}{
+		{name: "invalid old", from: `{`, to: `{}`},
+		{name: "invalid new", from: `{}`, to: `{"a":}`},
+		{name: "empty", from: ``, to: `{}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := JSON([]byte(tt.from), []byte(tt.to)); err == nil {
+				t.Error("JSON() succeeded")
+			}
+		})
+	}
+}
+
+func TestEscape(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{key: "plain", want: "plain"},
+		{key: "a/b", want: "a~1b"},
+		{key: "a~b", want: "a~0b"},
+		{key: "~/", want: "~0~1"},
+		{key: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := escape(tt.key); got != tt.want {
+			t.Errorf("escape(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
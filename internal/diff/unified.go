@@ -0,0 +1,244 @@
+package diff
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+const (
+	contextLines = 3
+
+	// maxEdits bounds the work of the line diff. Texts that differ more are
+	// reported as replaced as a whole.
+	maxEdits = 2000
+)
+
+type editKind int
+
+const (
+	editEqual editKind = iota
+	editDelete
+	editInsert
+)
+
+// edit is a single line operation, a and b are the line positions in the old
+// and the new text.
+type edit struct {
+	kind editKind
+	a    int
+	b    int
+}
+
+// Unified returns the unified diff of two texts, empty when they are equal.
+func Unified(fromName string, toName string, from string, to string) string {
+	a, b := splitLines(from), splitLines(to)
+	edits := lineEdits(a, b)
+
+	if !slices.ContainsFunc(edits, func(e edit) bool { return e.kind != editEqual }) {
+		return ""
+	}
+
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", fromName, toName)
+
+	for i := 0; i < len(edits); {
+		if edits[i].kind == editEqual {
+			i++
+			continue
+		}
+
+		start := max(i-contextLines, 0)
+		end := hunkEnd(edits, i)
+		stop := min(end+contextLines, len(edits))
+
+		writeHunk(&sb, edits[start:stop], a, b)
+
+		i = stop
+	}
+
+	return sb.String()
+}
+
+// hunkEnd returns the end of the changes starting at i, changes separated by
+// less than two contexts of equal lines belong to the same hunk.
+func hunkEnd(edits []edit, i int) int {
+	end := i
+
+	for end < len(edits) {
+		if edits[end].kind != editEqual {
+			end++
+			continue
+		}
+
+		run := end
+		for run < len(edits) && edits[run].kind == editEqual {
+			run++
+		}
+
+		if run == len(edits) || run-end > 2*contextLines {
+			break
+		}
+
+		end = run
+	}
+
+	return end
+}
+
+func writeHunk(sb *strings.Builder, edits []edit, a []string, b []string) {
+	var countA, countB int
+	for _, e := range edits {
+		if e.kind != editInsert {
+			countA++
+		}
+		if e.kind != editDelete {
+			countB++
+		}
+	}
+
+	fmt.Fprintf(sb, "@@ -%s +%s @@\n", hunkRange(edits[0].a, countA), hunkRange(edits[0].b, countB))
+
+	for _, e := range edits {
+		switch e.kind {
+		case editEqual:
+			writeLine(sb, " ", a[e.a])
+		case editDelete:
+			writeLine(sb, "-", a[e.a])
+		case editInsert:
+			writeLine(sb, "+", b[e.b])
+		}
+	}
+}
+
+func hunkRange(start int, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+
+	if count == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+func writeLine(sb *strings.Builder, prefix string, line string) {
+	sb.WriteString(prefix)
+	sb.WriteString(line)
+
+	if !strings.HasSuffix(line, "\n") {
+		sb.WriteString("\n\\ No newline at end of file\n")
+	}
+}
+
+func splitLines(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+
+	return lines
+}
+
+// lineEdits finds the shortest edit script with the Myers algorithm.
+func lineEdits(a []string, b []string) []edit {
+	n, m := len(a), len(b)
+	offset := n + m + 1
+	v := make([]int, 2*offset+1)
+
+	// trace[d] holds v[-d..d] as it was before step d.
+	var trace [][]int
+
+	for d := 0; d <= n+m; d++ {
+		if d > maxEdits {
+			return replaceAll(n, m)
+		}
+
+		trace = append(trace, slices.Clone(v[offset-d:offset+d+1]))
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+
+			v[offset+k] = x
+
+			if x >= n && y >= m {
+				return backtrack(trace, n, m)
+			}
+		}
+	}
+
+	return replaceAll(n, m)
+}
+
+func backtrack(trace [][]int, n int, m int) []edit {
+	var edits []edit
+
+	x, y := n, m
+
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		at := func(k int) int { return v[k+d] }
+
+		k := x - y
+
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+
+		prevX := 0
+		if d > 0 {
+			prevX = at(prevK)
+		}
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			x--
+			y--
+			edits = append(edits, edit{kind: editEqual, a: x, b: y})
+		}
+
+		if d > 0 {
+			if x == prevX {
+				edits = append(edits, edit{kind: editInsert, a: x, b: y - 1})
+			} else {
+				edits = append(edits, edit{kind: editDelete, a: x - 1, b: y})
+			}
+		}
+
+		x, y = prevX, prevY
+	}
+
+	slices.Reverse(edits)
+
+	return edits
+}
+
+func replaceAll(n int, m int) []edit {
+	edits := make([]edit, 0, n+m)
+
+	for i := range n {
+		edits = append(edits, edit{kind: editDelete, a: i, b: 0})
+	}
+
+	for j := range m {
+		edits = append(edits, edit{kind: editInsert, a: n, b: j})
+	}
+
+	return edits
+}
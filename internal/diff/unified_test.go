@@ -0,0 +1,191 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// numbered returns the lines "1\n".."n\n" with the given lines replaced.
+func numbered(n int, replace map[int]string) string {
+	var sb strings.Builder
+
+	for i := 1; i <= n; i++ {
+		if line, ok := replace[i]; ok {
+			sb.WriteString(line + "\n")
+			continue
+		}
+
+		fmt.Fprintf(&sb, "%d\n", i)
+	}
+
+	return sb.String()
+}
+
+func TestUnified(t *testing.T) {
+	tests := []struct {
+		name string
+		from string
+		to   string
+		want string
+	}{
+		{
+			name: "equal",
+			from: numbered(5, nil),
+			to:   numbered(5, nil),
+			want: "",
+		},
+		{
+			name: "both empty",
+			want: "",
+		},
+		{
+			name: "changed line with context",
+			from: numbered(10, nil),
+			to:   numbered(10, map[int]string{5: "five"}),
+			want: "--- a\n+++ b\n" +
+				"@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n",
+		},
+		{
+			name: "context clipped at the start",
+			from: numbered(5, nil),
+			to:   numbered(5, map[int]string{1: "one"}),
+			want: "--- a\n+++ b\n" +
+				"@@ -1,4 +1,4 @@\n-1\n+one\n 2\n 3\n 4\n",
+		},
+		{
+			name: "changes close together share a hunk",
+			from: numbered(20, nil),
+			to:   numbered(20, map[int]string{5: "five", 12: "twelve"}),
+			want: "--- a\n+++ b\n" +
+				"@@ -2,14 +2,14 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n 9\n 10\n 11\n-12\n+twelve\n 13\n 14\n 15\n",
+		},
+		{
+			name: "changes far apart get own hunks",
+			from: numbered(20, nil),
+			to:   numbered(20, map[int]string{5: "five", 13: "thirteen"}),
+			want: "--- a\n+++ b\n" +
+				"@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n" +
+				"@@ -10,7 +10,7 @@\n 10\n 11\n 12\n-13\n+thirteen\n 14\n 15\n 16\n",
+		},
+		{
+			name: "insertion",
+			from: "a\nb\n",
+			to:   "a\nx\nb\n",
+			want: "--- a\n+++ b\n" +
+				"@@ -1,2 +1,3 @@\n a\n+x\n b\n",
+		},
+		{
+			name: "from empty",
+			from: "",
+			to:   "a\n",
+			want: "--- a\n+++ b\n" +
+				"@@ -0,0 +1 @@\n+a\n",
+		},
+		{
+			name: "to empty",
+			from: "a\nb\n",
+			to:   "",
+			want: "--- a\n+++ b\n" +
+				"@@ -1,2 +0,0 @@\n-a\n-b\n",
+		},
+		{
+			name: "missing newline at end",
+			from: "a\nb\n",
+			to:   "a\nb",
+			want: "--- a\n+++ b\n" +
+				"@@ -1,2 +1,2 @@\n a\n-b\n+b\n\\ No newline at end of file\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Unified("a", "b", tt.from, tt.to); got != tt.want {
+				t.Errorf("Unified() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLineEditsMaxEdits(t *testing.T) {
+	// interleaves common lines with changed ones, the shortest script keeps
+	// the common lines and needs 2*changed edits.
+	lines := func(changed int, prefix string) []string {
+		var lines []string
+		for i := range changed {
+			lines = append(lines, fmt.Sprintf("same %d\n", i), fmt.Sprintf("%s %d\n", prefix, i))
+		}
+
+		return lines
+	}
+
+	tests := []struct {
+		name      string
+		changed   int
+		wantEqual bool
+	}{
+		{name: "within the bound", changed: maxEdits / 2, wantEqual: true},
+		{name: "over the bound", changed: maxEdits/2 + 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := lines(tt.changed, "a"), lines(tt.changed, "b")
+			edits := lineEdits(a, b)
+
+			var equal, deleted, inserted int
+			for _, e := range edits {
+				switch e.kind {
+				case editEqual:
+					equal++
+				case editDelete:
+					deleted++
+				case editInsert:
+					inserted++
+				}
+			}
+
+			if tt.wantEqual {
+				if equal != tt.changed || deleted != tt.changed || inserted != tt.changed {
+					t.Errorf("edits = %d equal %d deleted %d inserted, want %d of each", equal, deleted, inserted, tt.changed)
+				}
+
+				return
+			}
+
+			// the texts are replaced as a whole: all old lines deleted
+			// before all new ones are inserted
+			if equal != 0 || deleted != len(a) || inserted != len(b) {
+				t.Fatalf("edits = %d equal %d deleted %d inserted, want %d deleted %d inserted", equal, deleted, inserted, len(a), len(b))
+			}
+			for i, e := range edits {
+				if (i < len(a)) != (e.kind == editDelete) {
+					t.Fatalf("edit %d = %+v, want the deletions first", i, e)
+				}
+			}
+		})
+	}
+}
+
+func TestUnifiedReplacedWhole(t *testing.T) {
+	var from, to strings.Builder
+	for i := range maxEdits/2 + 1 {
+		fmt.Fprintf(&from, "same %d\nold %d\n", i, i)
+		fmt.Fprintf(&to, "same %d\nnew %d\n", i, i)
+	}
+
+	got := Unified("a", "b", from.String(), to.String())
+
+	n := 2 * (maxEdits/2 + 1)
+	header := fmt.Sprintf("--- a\n+++ b\n@@ -1,%d +1,%d @@\n", n, n)
+
+	if !strings.HasPrefix(got, header) {
+		t.Fatalf("Unified() starts with %q, want %q", got[:min(len(got), len(header))], header)
+	}
+	if strings.Count(got, "@@ -") != 1 {
+		t.Errorf("Unified() has %d hunks, want 1", strings.Count(got, "@@ -"))
+	}
+	if strings.Contains(got, "\n same") {
+		t.Error("Unified() kept common lines of a text replaced as a whole")
+	}
+}
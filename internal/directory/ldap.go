@@ -0,0 +1,187 @@
+// Package directory authenticates users against an LDAP directory: the user
+// entry is found with a service account and the password is checked by
+// binding as the user.
+package directory
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/GroVlAn/doc-store/internal/core"
+	"github.com/GroVlAn/doc-store/internal/core/e"
+	"github.com/go-ldap/ldap/v3"
+)
+
+const (
+	ProviderLDAP = "ldap"
+
+	defaultTimeout    = 5 * time.Second
+	defaultUserFilter = "(uid=%s)"
+	defaultGroupAttr  = "memberOf"
+)
+
+type Config struct {
+	URL          string
+	StartTLS     bool
+	BindDN       string
+	BindPassword string
+	BaseDN       string
+	// UserFilter finds the user entry, %s is replaced by the escaped login.
+	UserFilter     string
+	GroupAttribute string
+	// GroupRoles maps group DNs to doc-store roles, admin wins over others.
+	GroupRoles map[string]string
+	Timeout    time.Duration
+}
+
+// Conn is the part of an LDAP connection the authenticator uses, so an
+// in-process stand-in can replace the server.
+type Conn interface {
+	Bind(username string, password string) error
+	Search(request *ldap.SearchRequest) (*ldap.SearchResult, error)
+	Close() error
+}
+
+type Dialer func(ctx context.Context) (Conn, error)
+
+type LDAP struct {
+	cfg  Config
+	dial Dialer
+}
+
+// New creates the authenticator, a nil dialer connects to cfg.URL.
+func New(cfg Config, dial Dialer) *LDAP {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultTimeout
+	}
+	if len(cfg.UserFilter) == 0 {
+		cfg.UserFilter = defaultUserFilter
+	}
+	if len(cfg.GroupAttribute) == 0 {
+		cfg.GroupAttribute = defaultGroupAttr
+	}
+
+	l := &LDAP{cfg: cfg, dial: dial}
+	if l.dial == nil {
+		l.dial = l.dialURL
+	}
+
+	return l
+}
+
+// Authenticate returns e.ErrUserNotFound when the directory has no entry for
+// the login and e.ErrInvalidPassword when the bind as the user fails.
+func (l *LDAP) Authenticate(ctx context.Context, login string, password string) (core.Identity, error) {
+	if len(password) == 0 {
+		// an empty password would be an unauthenticated bind, which succeeds
+		return core.Identity{}, e.ErrInvalidPassword
+	}
+
+	conn, err := l.dial(ctx)
+	if err != nil {
+		return core.Identity{}, fmt.Errorf("connecting to ldap: %w", err)
+	}
+	defer conn.Close()
+
+	if len(l.cfg.BindDN) > 0 {
+		if err := conn.Bind(l.cfg.BindDN, l.cfg.BindPassword); err != nil {
+			return core.Identity{}, fmt.Errorf("binding ldap service account: %w", err)
+		}
+	}
+
+	result, err := conn.Search(ldap.NewSearchRequest(
+		l.cfg.BaseDN,
+		ldap.ScopeWholeSubtree,
+		ldap.NeverDerefAliases,
+		2,
+		int(l.cfg.Timeout.Seconds()),
+		false,
+		fmt.Sprintf(l.cfg.UserFilter, ldap.EscapeFilter(login)),
+		[]string{l.cfg.GroupAttribute},
+		nil,
+	))
+	if err != nil {
+		return core.Identity{}, fmt.Errorf("searching ldap user: %w", err)
+	}
+
+	switch len(result.Entries) {
+	case 0:
+		return core.Identity{}, e.ErrUserNotFound
+	case 1:
+	default:
+		return core.Identity{}, fmt.Errorf("ldap filter matches %d entries", len(result.Entries))
+	}
+
+	entry := result.Entries[0]
+
+	if err := conn.Bind(entry.DN, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return core.Identity{}, e.ErrInvalidPassword
+		}
+
+		return core.Identity{}, fmt.Errorf("binding ldap user: %w", err)
+	}
+
+	return core.Identity{
+		Login:    login,
+		Role:     l.role(entry.GetAttributeValues(l.cfg.GroupAttribute)),
+		Provider: ProviderLDAP,
+	}, nil
+}
+
+// role maps the groups of the user to a role. Users in no mapped group get
+// the user role, so removing someone from a group revokes its role.
+func (l *LDAP) role(groups []string) string {
+	role := core.RoleUser
+
+	for _, group := range groups {
+		for mapped, mappedRole := range l.cfg.GroupRoles {
+			if !strings.EqualFold(group, mapped) {
+				continue
+			}
+
+			if mappedRole == core.RoleAdmin {
+				return core.RoleAdmin
+			}
+
+			role = mappedRole
+		}
+	}
+
+	return role
+}
+
+func (l *LDAP) dialURL(ctx context.Context) (Conn, error) {
+	dialer := &net.Dialer{Timeout: l.cfg.Timeout}
+
+	conn, err := ldap.DialURL(l.cfg.URL, ldap.DialWithDialer(dialer))
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := l.cfg.Timeout
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < timeout {
+		timeout = time.Until(deadline)
+	}
+	conn.SetTimeout(timeout)
+
+	if l.cfg.StartTLS {
+		serverName := ""
+		if u, err := url.Parse(l.cfg.URL); err == nil {
+			serverName = u.Hostname()
+		}
+
+		if err := conn.StartTLS(&tls.Config{ServerName: serverName}); err != nil {
+			conn.Close()
+
+			return nil, fmt.Errorf("starting tls: %w", err)
+		}
+	}
+
+	return conn, nil
+}
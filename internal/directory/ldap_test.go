@@ -0,0 +1,209 @@
+package directory
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/GroVlAn/doc-store/internal/core"
+	"github.com/GroVlAn/doc-store/internal/core/e"
+	"github.com/go-ldap/ldap/v3"
+)
+
+const (
+	serviceDN       = "cn=admin,dc=example,dc=org"
+	servicePassword = "service-secret"
+	adminsGroup     = "cn=doc-store-admins,ou=groups,dc=example,dc=org"
+	editorsGroup    = "cn=doc-store-editors,ou=groups,dc=example,dc=org"
+)
+
+// fakeDirectory is an in-process stand-in for an LDAP server. Entries are
+// keyed by uid, passwords by DN.
+type fakeDirectory struct {
+	entries   map[string]*ldap.Entry
+	passwords map[string]string
+
+	dials   int
+	binds   []string
+	filters []string
+}
+
+func newFakeDirectory() *fakeDirectory {
+	return &fakeDirectory{
+		entries:   make(map[string]*ldap.Entry),
+		passwords: map[string]string{serviceDN: servicePassword},
+	}
+}
+
+func (d *fakeDirectory) addUser(uid string, password string, groups ...string) {
+	dn := "uid=" + uid + ",ou=users,dc=example,dc=org"
+
+	d.entries[uid] = ldap.NewEntry(dn, map[string][]string{defaultGroupAttr: groups})
+	d.passwords[dn] = password
+}
+
+func (d *fakeDirectory) dial(context.Context) (Conn, error) {
+	d.dials++
+
+	return &fakeConn{d: d}, nil
+}
+
+type fakeConn struct {
+	d *fakeDirectory
+}
+
+func (c *fakeConn) Bind(username string, password string) error {
+	c.d.binds = append(c.d.binds, username)
+
+	if expected, ok := c.d.passwords[username]; !ok || expected != password {
+		return ldap.NewError(ldap.LDAPResultInvalidCredentials, errors.New("invalid credentials"))
+	}
+
+	return nil
+}
+
+// Search understands the "(uid=%s)" filter only, an escaped login never
+// matches an entry.
+func (c *fakeConn) Search(request *ldap.SearchRequest) (*ldap.SearchResult, error) {
+	c.d.filters = append(c.d.filters, request.Filter)
+
+	result := &ldap.SearchResult{}
+	for uid, entry := range c.d.entries {
+		if request.Filter == "(uid="+uid+")" {
+			result.Entries = append(result.Entries, entry)
+		}
+	}
+
+	return result, nil
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func newTestLDAP(d *fakeDirectory) *LDAP {
+	return New(Config{
+		BindDN:       serviceDN,
+		BindPassword: servicePassword,
+		BaseDN:       "ou=users,dc=example,dc=org",
+		GroupRoles: map[string]string{
+			adminsGroup:  core.RoleAdmin,
+			editorsGroup: "editor",
+		},
+	}, d.dial)
+}
+
+func TestAuthenticate(t *testing.T) {
+	d := newFakeDirectory()
+	d.addUser("alice", "alice-secret", adminsGroup)
+
+	identity, err := newTestLDAP(d).Authenticate(context.Background(), "alice", "alice-secret")
+	if err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+
+	want := core.Identity{Login: "alice", Role: core.RoleAdmin, Provider: ProviderLDAP}
+	if identity != want {
+		t.Errorf("Authenticate() = %+v, want %+v", identity, want)
+	}
+
+	wantBinds := []string{serviceDN, d.entries["alice"].DN}
+	if len(d.binds) != len(wantBinds) || d.binds[0] != wantBinds[0] || d.binds[1] != wantBinds[1] {
+		t.Errorf("binds = %v, want %v", d.binds, wantBinds)
+	}
+}
+
+func TestAuthenticateErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		login    string
+		password string
+		wantErr  error
+	}{
+		{name: "wrong password", login: "alice", password: "wrong", wantErr: e.ErrInvalidPassword},
+		{name: "unknown user", login: "bob", password: "bob-secret", wantErr: e.ErrUserNotFound},
+		{name: "empty password", login: "alice", password: "", wantErr: e.ErrInvalidPassword},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newFakeDirectory()
+			d.addUser("alice", "alice-secret")
+
+			_, err := newTestLDAP(d).Authenticate(context.Background(), tt.login, tt.password)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Authenticate() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAuthenticateEmptyPasswordSkipsServer(t *testing.T) {
+	d := newFakeDirectory()
+	d.addUser("alice", "alice-secret")
+
+	if _, err := newTestLDAP(d).Authenticate(context.Background(), "alice", ""); err == nil {
+		t.Fatal("Authenticate() with an empty password succeeded")
+	}
+
+	if d.dials != 0 {
+		t.Errorf("dials = %d, want 0", d.dials)
+	}
+}
+
+func TestAuthenticateServiceBindFails(t *testing.T) {
+	d := newFakeDirectory()
+	d.addUser("alice", "alice-secret")
+	d.passwords[serviceDN] = "rotated"
+
+	_, err := newTestLDAP(d).Authenticate(context.Background(), "alice", "alice-secret")
+	if err == nil || errors.Is(err, e.ErrInvalidPassword) {
+		t.Errorf("Authenticate() error = %v, want a service bind error", err)
+	}
+}
+
+func TestAuthenticateEscapesLogin(t *testing.T) {
+	d := newFakeDirectory()
+	d.addUser("alice", "alice-secret")
+
+	_, err := newTestLDAP(d).Authenticate(context.Background(), "*)(uid=alice", "alice-secret")
+	if !errors.Is(err, e.ErrUserNotFound) {
+		t.Errorf("Authenticate() error = %v, want %v", err, e.ErrUserNotFound)
+	}
+
+	want := `(uid=\2a\29\28uid=alice)`
+	if len(d.filters) != 1 || d.filters[0] != want {
+		t.Errorf("filters = %v, want [%s]", d.filters, want)
+	}
+}
+
+func TestAuthenticateRole(t *testing.T) {
+	tests := []struct {
+		name   string
+		groups []string
+		want   string
+	}{
+		{name: "admin group", groups: []string{adminsGroup}, want: core.RoleAdmin},
+		{name: "admin wins", groups: []string{editorsGroup, adminsGroup}, want: core.RoleAdmin},
+		{name: "mapped group", groups: []string{editorsGroup}, want: "editor"},
+		{name: "case insensitive", groups: []string{"CN=Doc-Store-Editors,OU=Groups,DC=Example,DC=Org"}, want: "editor"},
+		{name: "unmapped group", groups: []string{"cn=other,ou=groups,dc=example,dc=org"}, want: core.RoleUser},
+		{name: "no groups", want: core.RoleUser},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newFakeDirectory()
+			d.addUser("alice", "alice-secret", tt.groups...)
+
+			identity, err := newTestLDAP(d).Authenticate(context.Background(), "alice", "alice-secret")
+			if err != nil {
+				t.Fatalf("Authenticate() error = %v", err)
+			}
+
+			if identity.Role != tt.want {
+				t.Errorf("Role = %q, want %q", identity.Role, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package events
+
+import (
+	"context"
+	"sync"
+
+	"github.com/GroVlAn/doc-store/internal/core"
+)
+
+const subscriberBuffer = 64
+
+// Bus is an in-process publish/subscribe hub for document events.
+// Subscribers which do not keep up lose events instead of blocking publishers.
+type Bus struct {
+	mu          sync.RWMutex
+	subscribers map[chan core.DocumentEvent]struct{}
+	attached    bool
+}
+
+func NewBus() *Bus {
+	return &Bus{
+		subscribers: make(map[chan core.DocumentEvent]struct{}),
+	}
+}
+
+// Publish delivers a locally produced event. It is ignored once the bus is
+// attached to an external source, which then reports the same change.
+func (b *Bus) Publish(event core.DocumentEvent) {
+	b.mu.RLock()
+	attached := b.attached
+	b.mu.RUnlock()
+
+	if attached {
+		return
+	}
+
+	b.broadcast(event)
+}
+
+func (b *Bus) Subscribe() (<-chan core.DocumentEvent, func()) {
+	ch := make(chan core.DocumentEvent, subscriberBuffer)
+
+	b.mu.Lock()
+	b.subscribers[ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subscribers, ch)
+			b.mu.Unlock()
+
+			close(ch)
+		})
+	}
+
+	return ch, unsubscribe
+}
+
+// Attach forwards events from source, such as a mongo change stream, to the
+// subscribers until the source is closed or ctx is done.
+func (b *Bus) Attach(ctx context.Context, source <-chan core.DocumentEvent) {
+	b.mu.Lock()
+	b.attached = true
+	b.mu.Unlock()
+
+	go func() {
+		defer func() {
+			b.mu.Lock()
+			b.attached = false
+			b.mu.Unlock()
+		}()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-source:
+				if !ok {
+					return
+				}
+
+				b.broadcast(event)
+			}
+		}
+	}()
+}
+
+func (b *Bus) broadcast(event core.DocumentEvent) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
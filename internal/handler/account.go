@@ -0,0 +1,199 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/GroVlAn/doc-store/internal/archive"
+	"github.com/GroVlAn/doc-store/internal/core"
+	"github.com/GroVlAn/doc-store/internal/core/e"
+)
+
+const (
+	importMaxMemory = 32 << 20
+	importMaxSize   = 1 << 30
+)
+
+// exportAccount streams every document of the caller as an archive starting
+// with a manifest that lists the entries with their checksums.
+func (h *Handler) exportAccount(w http.ResponseWriter, r *http.Request) {
+	body := r.Body
+	defer h.closeRequestBody(body)
+
+	var request core.ExportRequest
+
+	if err := decodeBody(body, &request); err != nil {
+		h.sendErrorResponse(w, e.ErrEmptyBody)
+
+		return
+	}
+
+	principal, err := h.principal(r, request.Token)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	manifest, entries, err := h.accountService.ExportAccount(principal)
+	h.audit(r, principal, core.AuditEvent{Action: core.ActionAccountExport}, err)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	manifestBody, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	aw, err := archive.NewWriter(w, request.Format)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		h.l.Error().Err(err).Msg("failed to reset write deadline")
+	}
+
+	w.Header().Set("Content-Type", archive.ContentType(request.Format))
+	w.Header().Set(
+		"Content-Disposition",
+		fmt.Sprintf(`attachment; filename="%s.%s"`, manifest.Login, archive.Extension(request.Format)),
+	)
+	w.WriteHeader(http.StatusOK)
+
+	manifestEntry := core.ArchiveEntry{
+		Name:     core.ManifestName,
+		Document: core.Document{Created: manifest.Exported},
+		Json:     manifestBody,
+	}
+
+	if err := h.writeArchiveEntry(aw, manifestEntry); err != nil {
+		h.abortArchive(err, manifestEntry)
+	}
+
+	for _, entry := range entries {
+		if err := h.writeArchiveEntry(aw, entry); err != nil {
+			h.abortArchive(err, entry)
+		}
+	}
+
+	if err := aw.Close(); err != nil {
+		h.l.Error().Err(err).Msg("failed to close archive")
+	}
+}
+
+// importAccount recreates the documents of an exported archive for the
+// caller. The "conflict" field chooses what happens to documents whose name
+// is already taken: rename (default), skip or overwrite.
+func (h *Handler) importAccount(w http.ResponseWriter, r *http.Request) {
+	maxSize := h.importMaxSize
+	if maxSize <= 0 {
+		maxSize = importMaxSize
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxSize)
+
+	if err := r.ParseMultipartForm(importMaxMemory); err != nil {
+		var errMaxBytes *http.MaxBytesError
+		if errors.As(err, &errMaxBytes) {
+			h.sendErrorResponse(w, e.ErrFileTooLarge)
+
+			return
+		}
+
+		h.sendErrorResponse(w, e.ErrEmptyBody)
+
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	token := r.FormValue("token")
+
+	principal, err := h.principal(r, token)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	file, header, err := r.FormFile("archive")
+	if err != nil {
+		h.sendErrorResponse(w, e.ErrEmptyBody)
+
+		return
+	}
+	defer file.Close()
+
+	format := r.FormValue("format")
+	if len(format) == 0 && (strings.HasSuffix(header.Filename, ".tar.gz") || strings.HasSuffix(header.Filename, ".tgz")) {
+		format = archive.FormatTarGz
+	}
+
+	ar, err := archive.NewReader(file, header.Size, format)
+	if err != nil {
+		h.sendErrorResponse(w, fmt.Errorf("%w: %w", e.ErrInvalidArchive, err))
+
+		return
+	}
+	defer ar.Close()
+
+	results, err := h.accountService.ImportAccount(principal, ar, r.FormValue("conflict"))
+	h.audit(r, principal, core.AuditEvent{Action: core.ActionAccountImport}, err)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	for _, result := range results {
+		h.audit(r, principal, core.AuditEvent{Action: core.ActionDocumentCreate, DocumentID: result.ID}, result.Err)
+	}
+
+	h.sendBulkResults(w, results, http.StatusCreated)
+}
+
+// deleteAccount deletes the caller, the documents of the caller are
+// transferred to another login or purged.
+func (h *Handler) deleteAccount(w http.ResponseWriter, r *http.Request) {
+	body := r.Body
+	defer h.closeRequestBody(body)
+
+	var deletion core.AccountDeletion
+
+	if err := decodeBody(body, &deletion); err != nil {
+		h.sendErrorResponse(w, e.ErrEmptyBody)
+
+		return
+	}
+
+	principal, err := h.principal(r, deletion.Token)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	err = h.accountService.DeleteAccount(principal, deletion)
+	h.audit(r, principal, core.AuditEvent{Action: core.ActionAccountDelete}, err)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	res := core.Response{}
+	res.Response = map[string]bool{principal.Login: true}
+
+	h.sendResponse(w, res, http.StatusOK)
+}
@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/GroVlAn/doc-store/internal/core"
+	"github.com/GroVlAn/doc-store/internal/core/e"
+	"github.com/go-chi/chi"
+)
+
+// createAPIKey responds with the new key including its secret, the secret
+// can't be read later.
+func (h *Handler) createAPIKey(w http.ResponseWriter, r *http.Request) {
+	body := r.Body
+	defer h.closeRequestBody(body)
+
+	var key core.APIKey
+
+	if err := decodeBody(body, &key); err != nil {
+		h.sendErrorResponse(w, e.ErrEmptyBody)
+
+		return
+	}
+
+	principal, err := h.principal(r, key.Token)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	key, err = h.userService.CreateAPIKey(principal, key)
+	h.audit(r, principal, core.AuditEvent{Action: core.ActionAPIKeyCreate}, err)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	res := core.Response{}
+	res.Data = key
+
+	h.sendResponse(w, res, http.StatusCreated)
+}
+
+func (h *Handler) apiKeys(w http.ResponseWriter, r *http.Request) {
+	token, err := h.tokenFromBody(r)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	principal, err := h.principal(r, token)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	keys, err := h.userService.APIKeys(principal)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	res := core.Response{}
+	res.Data = struct {
+		Keys []core.APIKey `json:"keys"`
+	}{
+		Keys: keys,
+	}
+
+	h.sendResponse(w, res, http.StatusOK)
+}
+
+func (h *Handler) deleteAPIKey(w http.ResponseWriter, r *http.Request) {
+	keyID := chi.URLParam(r, "keyID")
+
+	token, err := h.tokenFromBody(r)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	principal, err := h.principal(r, token)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	err = h.userService.DeleteAPIKey(principal, keyID)
+	h.audit(r, principal, core.AuditEvent{Action: core.ActionAPIKeyDelete}, err)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	res := core.Response{}
+	res.Response = map[string]bool{keyID: true}
+
+	h.sendResponse(w, res, http.StatusOK)
+}
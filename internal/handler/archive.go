@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/GroVlAn/doc-store/internal/archive"
+	"github.com/GroVlAn/doc-store/internal/core"
+	"github.com/GroVlAn/doc-store/internal/core/e"
+)
+
+// archiveDocuments streams the requested documents as a zip or tar.gz
+// archive built on the fly.
+func (h *Handler) archiveDocuments(w http.ResponseWriter, r *http.Request) {
+	body := r.Body
+	defer h.closeRequestBody(body)
+
+	var request core.ArchiveRequest
+
+	if err := decodeBody(body, &request); err != nil {
+		h.sendErrorResponse(w, e.ErrEmptyBody)
+
+		return
+	}
+
+	principal, err := h.principal(r, request.Token)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	entries, err := h.documentService.ArchiveDocuments(principal, request.IDs, request.Filter)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	aw, err := archive.NewWriter(w, request.Format)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		h.l.Error().Err(err).Msg("failed to reset write deadline")
+	}
+
+	w.Header().Set("Content-Type", archive.ContentType(request.Format))
+	w.Header().Set(
+		"Content-Disposition",
+		fmt.Sprintf(`attachment; filename="documents.%s"`, archive.Extension(request.Format)),
+	)
+	w.WriteHeader(http.StatusOK)
+
+	for _, entry := range entries {
+		err := h.writeArchiveEntry(aw, entry)
+		h.audit(r, principal, core.AuditEvent{Action: core.ActionDocumentRead, DocumentID: entry.Document.ID}, err)
+		if err != nil {
+			h.abortArchive(err, entry)
+		}
+	}
+
+	if err := aw.Close(); err != nil {
+		h.l.Error().Err(err).Msg("failed to close archive")
+	}
+}
+
+func (h *Handler) writeArchiveEntry(aw archive.Writer, entry core.ArchiveEntry) error {
+	if entry.Open == nil {
+		return aw.Add(entry.Name, int64(len(entry.Json)), entry.Document.Created, bytes.NewReader(entry.Json))
+	}
+
+	file, info, err := entry.Open()
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return aw.Add(entry.Name, info.Size(), info.ModTime(), file)
+}
+
+// abortArchive drops the connection after a failed entry. The status is sent
+// already, closing the archive would hand the client a valid but incomplete
+// one.
+func (h *Handler) abortArchive(err error, entry core.ArchiveEntry) {
+	h.l.Error().Err(err).Str("document", entry.Document.ID).Msg("failed to write archive entry")
+
+	panic(http.ErrAbortHandler)
+}
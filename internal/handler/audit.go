@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/GroVlAn/doc-store/internal/core"
+	"github.com/GroVlAn/doc-store/internal/core/e"
+	"github.com/go-chi/chi"
+)
+
+// audit records the outcome of an operation. Failures to write the audit
+// event are logged and never fail the request itself.
+func (h *Handler) audit(r *http.Request, principal core.Principal, event core.AuditEvent, err error) {
+	event.IP = clientIP(r)
+	event.UserAgent = r.UserAgent()
+	event.Result = core.AuditResultSuccess
+
+	if err != nil {
+		event.Result = core.AuditResultFailure
+		event.Error = err.Error()
+	}
+
+	if err := h.auditService.RecordAudit(principal, event); err != nil {
+		h.l.Error().Err(err).Str("action", event.Action).Msg("failed to record audit event")
+	}
+}
+
+// auditDenied records a request rejected before reaching its operation: an
+// invalid or revoked token, or an API key without the scope of the route.
+func (h *Handler) auditDenied(r *http.Request, principal core.Principal, err error) {
+	h.audit(r, principal, core.AuditEvent{Action: core.ActionAccessDenied, Path: r.URL.Path}, err)
+}
+
+func (h *Handler) auditLog(w http.ResponseWriter, r *http.Request) {
+	body := r.Body
+	defer h.closeRequestBody(body)
+
+	var filter core.AuditFilter
+
+	if err := decodeBody(body, &filter); err != nil {
+		h.sendErrorResponse(w, e.ErrEmptyBody)
+
+		return
+	}
+
+	principal, err := h.principal(r, filter.Token)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	events, err := h.auditService.AuditLog(principal, filter)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	h.sendAuditEvents(w, events)
+}
+
+func (h *Handler) documentAudit(w http.ResponseWriter, r *http.Request) {
+	docID := chi.URLParam(r, "docID")
+
+	body := r.Body
+	defer h.closeRequestBody(body)
+
+	var filter core.AuditFilter
+
+	if err := decodeBody(body, &filter); err != nil {
+		h.sendErrorResponse(w, e.ErrEmptyBody)
+
+		return
+	}
+
+	principal, err := h.principal(r, filter.Token)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	events, err := h.auditService.DocumentAudit(principal, docID, filter)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	h.sendAuditEvents(w, events)
+}
+
+func (h *Handler) sendAuditEvents(w http.ResponseWriter, events []core.AuditEvent) {
+	res := core.Response{}
+	res.Data = struct {
+		Events []core.AuditEvent `json:"events,omitempty"`
+	}{
+		Events: events,
+	}
+
+	h.sendResponse(w, res, http.StatusOK)
+}
+
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+func client(r *http.Request) core.Client {
+	return core.Client{IP: clientIP(r), UserAgent: r.UserAgent()}
+}
@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+
+	"github.com/GroVlAn/doc-store/internal/core"
+	"github.com/GroVlAn/doc-store/internal/core/e"
+)
+
+const (
+	bulkMaxMemory = 32 << 20
+)
+
+// createDocuments uploads every "file" part of the form, the n-th "meta"
+// field describes the n-th file. The token is verified once for all files.
+func (h *Handler) createDocuments(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseMultipartForm(bulkMaxMemory); err != nil {
+		h.sendErrorResponse(w, e.ErrEmptyBody)
+
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	token := r.FormValue("token")
+
+	principal, err := h.principal(r, token)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	files := r.MultipartForm.File["file"]
+	metas := r.MultipartForm.Value["meta"]
+
+	if len(files) == 0 {
+		h.sendErrorResponse(w, e.ErrEmptyBody)
+
+		return
+	}
+
+	results := make([]core.BulkResult, len(files))
+	documents := make([]core.BulkDocument, 0, len(files))
+	indexes := make([]int, 0, len(files))
+
+	for i, header := range files {
+		var meta string
+		if i < len(metas) {
+			meta = metas[i]
+		}
+
+		document, err := h.bulkDocument(header, meta)
+		if err != nil {
+			results[i] = core.BulkResult{Index: i, Name: header.Filename, Err: err}
+			continue
+		}
+
+		documents = append(documents, document)
+		indexes = append(indexes, i)
+	}
+
+	created, err := h.documentService.CreateDocuments(principal, documents)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	for i, result := range created {
+		result.Index = indexes[i]
+		results[indexes[i]] = result
+	}
+
+	for _, result := range results {
+		h.audit(r, principal, core.AuditEvent{Action: core.ActionDocumentCreate, DocumentID: result.ID}, result.Err)
+	}
+
+	h.sendBulkResults(w, results, http.StatusCreated)
+}
+
+func (h *Handler) deleteDocuments(w http.ResponseWriter, r *http.Request) {
+	body := r.Body
+	defer h.closeRequestBody(body)
+
+	var request core.BulkDeleteRequest
+
+	if err := decodeBody(body, &request); err != nil {
+		h.sendErrorResponse(w, e.ErrEmptyBody)
+
+		return
+	}
+
+	principal, err := h.principal(r, request.Token)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	results, err := h.documentService.DeleteDocuments(principal, request.IDs, request.Filter)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	for _, result := range results {
+		h.audit(r, principal, core.AuditEvent{Action: core.ActionDocumentDelete, DocumentID: result.ID}, result.Err)
+	}
+
+	h.sendBulkResults(w, results, http.StatusOK)
+}
+
+func (h *Handler) bulkDocument(header *multipart.FileHeader, meta string) (core.BulkDocument, error) {
+	document := core.BulkDocument{}
+
+	if len(meta) > 0 {
+		if err := json.Unmarshal([]byte(meta), &document.Meta); err != nil {
+			return core.BulkDocument{}, fmt.Errorf("%w: %w", e.ErrInvalidMeta, err)
+		}
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		return core.BulkDocument{}, fmt.Errorf("opening file: %w", err)
+	}
+	defer file.Close()
+
+	document.File, err = io.ReadAll(file)
+	if err != nil {
+		return core.BulkDocument{}, fmt.Errorf("reading file: %w", err)
+	}
+
+	document.Meta.Name = header.Filename
+	document.Meta.Mime = http.DetectContentType(document.File)
+
+	return document, nil
+}
+
+// sendBulkResults responds with status when every item succeeded and with
+// 207 Multi-Status when some of them failed.
+func (h *Handler) sendBulkResults(w http.ResponseWriter, results []core.BulkResult, status int) {
+	for i := range results {
+		if results[i].Err == nil {
+			results[i].Status = status
+			continue
+		}
+
+		results[i].Status, results[i].Error = h.handleError(results[i].Err)
+		status = http.StatusMultiStatus
+	}
+
+	res := core.Response{}
+	res.Data = struct {
+		Items []core.BulkResult `json:"items"`
+	}{
+		Items: results,
+	}
+
+	h.sendResponse(w, res, status)
+}
@@ -0,0 +1,186 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/GroVlAn/doc-store/internal/core"
+	"github.com/GroVlAn/doc-store/internal/core/e"
+	"github.com/go-chi/chi"
+)
+
+func (h *Handler) createComment(w http.ResponseWriter, r *http.Request) {
+	docID := chi.URLParam(r, "docID")
+
+	body := r.Body
+	defer h.closeRequestBody(body)
+
+	var comment core.Comment
+
+	if err := decodeBody(body, &comment); err != nil {
+		h.sendErrorResponse(w, e.ErrEmptyBody)
+
+		return
+	}
+
+	principal, err := h.principal(r, comment.Token)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	comment, err = h.commentService.CreateComment(principal, docID, comment)
+	h.audit(r, principal, core.AuditEvent{Action: core.ActionCommentCreate, DocumentID: docID}, err)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	res := core.Response{}
+	res.Data = comment
+
+	h.sendResponse(w, res, http.StatusCreated)
+}
+
+func (h *Handler) comments(w http.ResponseWriter, r *http.Request) {
+	docID := chi.URLParam(r, "docID")
+
+	token, err := h.tokenFromBody(r)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	principal, err := h.principal(r, token)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	comments, err := h.commentService.Comments(principal, docID)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	res := core.Response{}
+	res.Data = struct {
+		Comments []core.Comment `json:"comments"`
+	}{
+		Comments: comments,
+	}
+
+	h.sendResponse(w, res, http.StatusOK)
+}
+
+func (h *Handler) updateComment(w http.ResponseWriter, r *http.Request) {
+	docID := chi.URLParam(r, "docID")
+	commentID := chi.URLParam(r, "commentID")
+
+	body := r.Body
+	defer h.closeRequestBody(body)
+
+	var update core.Comment
+
+	if err := decodeBody(body, &update); err != nil {
+		h.sendErrorResponse(w, e.ErrEmptyBody)
+
+		return
+	}
+
+	principal, err := h.principal(r, update.Token)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	comment, err := h.commentService.UpdateComment(principal, docID, commentID, update.Body)
+	h.audit(r, principal, core.AuditEvent{Action: core.ActionCommentUpdate, DocumentID: docID}, err)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	res := core.Response{}
+	res.Data = comment
+
+	h.sendResponse(w, res, http.StatusOK)
+}
+
+func (h *Handler) resolveComment(w http.ResponseWriter, r *http.Request) {
+	h.setCommentResolved(w, r, true)
+}
+
+func (h *Handler) reopenComment(w http.ResponseWriter, r *http.Request) {
+	h.setCommentResolved(w, r, false)
+}
+
+func (h *Handler) setCommentResolved(w http.ResponseWriter, r *http.Request, resolved bool) {
+	docID := chi.URLParam(r, "docID")
+	commentID := chi.URLParam(r, "commentID")
+
+	token, err := h.tokenFromBody(r)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	principal, err := h.principal(r, token)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	comment, err := h.commentService.ResolveComment(principal, docID, commentID, resolved)
+	h.audit(r, principal, core.AuditEvent{Action: core.ActionCommentResolve, DocumentID: docID}, err)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	res := core.Response{}
+	res.Data = comment
+
+	h.sendResponse(w, res, http.StatusOK)
+}
+
+func (h *Handler) deleteComment(w http.ResponseWriter, r *http.Request) {
+	docID := chi.URLParam(r, "docID")
+	commentID := chi.URLParam(r, "commentID")
+
+	token, err := h.tokenFromBody(r)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	principal, err := h.principal(r, token)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	err = h.commentService.DeleteComment(principal, docID, commentID)
+	h.audit(r, principal, core.AuditEvent{Action: core.ActionCommentDelete, DocumentID: docID}, err)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	res := core.Response{}
+	res.Response = map[string]bool{commentID: true}
+
+	h.sendResponse(w, res, http.StatusOK)
+}
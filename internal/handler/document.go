@@ -2,7 +2,6 @@ package handler
 
 import (
 	"encoding/json"
-	"fmt"
 	"io"
 	"net/http"
 
@@ -23,11 +22,21 @@ func (h *Handler) createDocument(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.userService.VerifyAccessToken(documentRequest.Meta.Token); err != nil {
+	principal, err := h.principal(r, documentRequest.Meta.Token)
+	if err != nil {
 		h.sendErrorResponse(w, err)
+
 		return
 	}
 
+	revision, err := ifMatch(r)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+	documentRequest.Meta.IfMatch = revision
+
 	jsonValue := r.FormValue("json")
 
 	var jsonData *interface{}
@@ -56,7 +65,9 @@ func (h *Handler) createDocument(w http.ResponseWriter, r *http.Request) {
 		documentRequest.Meta.Mime = http.DetectContentType(b)
 	}
 
-	if err := h.documentService.CreateDocument(documentRequest.Meta, b); err != nil {
+	document, err := h.documentService.CreateDocument(principal, documentRequest.Meta, b)
+	h.audit(r, principal, core.AuditEvent{Action: core.ActionDocumentCreate, DocumentID: document.ID}, err)
+	if err != nil {
 		h.sendErrorResponse(w, err)
 
 		return
@@ -65,54 +76,63 @@ func (h *Handler) createDocument(w http.ResponseWriter, r *http.Request) {
 	res := core.Response{}
 	if header != nil {
 		res.Response = struct {
-			Json *interface{} `json:"json,omitempty"`
-			File string       `json:"file"`
+			ID         string          `json:"id"`
+			Revision   int64           `json:"revision"`
+			Json       *interface{}    `json:"json,omitempty"`
+			File       string          `json:"file"`
+			Attributes core.Attributes `json:"attributes,omitempty"`
 		}{
-			Json: jsonData,
-			File: header.Filename,
+			ID:         document.ID,
+			Revision:   document.Revision,
+			Json:       jsonData,
+			File:       header.Filename,
+			Attributes: document.Attributes,
 		}
 	} else {
 		res.Response = struct {
-			Json *interface{} `json:"json,omitempty"`
+			ID         string          `json:"id"`
+			Revision   int64           `json:"revision"`
+			Json       *interface{}    `json:"json,omitempty"`
+			Attributes core.Attributes `json:"attributes,omitempty"`
 		}{
-			Json: jsonData,
+			ID:         document.ID,
+			Revision:   document.Revision,
+			Json:       jsonData,
+			Attributes: document.Attributes,
 		}
 	}
 
+	setETag(w, document.Revision)
 	h.sendResponse(w, res, http.StatusCreated)
 }
 
 func (h *Handler) document(w http.ResponseWriter, r *http.Request) {
 	docID := chi.URLParam(r, "docID")
 
-	body := r.Body
-	defer h.closeRequestBody(body)
-
-	var tokenBody struct {
-		Token string `json:"token"`
-	}
-
-	if err := json.NewDecoder(body).Decode(&tokenBody); err != nil {
-		h.sendErrorResponse(w, &e.ErrInvalidToken{
-			Msg: "invalid token",
-			Err: fmt.Errorf("decoding token body: %w", err),
-		})
+	token, err := h.tokenFromBody(r)
+	if err != nil {
+		h.sendErrorResponse(w, err)
 
 		return
 	}
 
-	if err := h.userService.VerifyAccessToken(tokenBody.Token); err != nil {
+	principal, err := h.principal(r, token)
+	if err != nil {
 		h.sendErrorResponse(w, err)
+
 		return
 	}
 
-	document, file, err := h.documentService.Document(tokenBody.Token, docID)
+	document, file, err := h.documentService.Document(principal, docID)
+	h.audit(r, principal, core.AuditEvent{Action: core.ActionDocumentRead, DocumentID: docID}, err)
 	if err != nil {
 		h.sendErrorResponse(w, err)
 
 		return
 	}
 
+	h.setDocumentHeaders(w, document)
+
 	if document.Json != nil {
 		h.serveJson(w, document.Json)
 	} else {
@@ -126,18 +146,21 @@ func (h *Handler) documentsList(w http.ResponseWriter, r *http.Request) {
 
 	var docFilter core.DocumentFilter
 
-	if err := json.NewDecoder(body).Decode(&docFilter); err != nil {
+	if err := decodeBody(body, &docFilter); err != nil {
 		h.sendErrorResponse(w, e.ErrEmptyBody)
 
 		return
 	}
 
-	if err := h.userService.VerifyAccessToken(docFilter.Token); err != nil {
+	principal, err := h.principal(r, docFilter.Token)
+	if err != nil {
 		h.sendErrorResponse(w, err)
+
 		return
 	}
 
-	docList, err := h.documentService.DocumentsList(docFilter.Token, docFilter)
+	docList, err := h.documentService.DocumentsList(principal, docFilter)
+	h.audit(r, principal, core.AuditEvent{Action: core.ActionDocumentList}, err)
 	if err != nil {
 		h.sendErrorResponse(w, err)
 
@@ -154,28 +177,112 @@ func (h *Handler) documentsList(w http.ResponseWriter, r *http.Request) {
 	h.sendResponse(w, res, http.StatusCreated)
 }
 
-func (h *Handler) deleteDocument(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) updateDocument(w http.ResponseWriter, r *http.Request) {
 	docID := chi.URLParam(r, "docID")
 
 	body := r.Body
 	defer h.closeRequestBody(body)
 
-	var tokenBody struct {
-		Token string `json:"token"`
+	var update core.DocumentUpdate
+
+	if err := decodeBody(body, &update); err != nil {
+		h.sendErrorResponse(w, e.ErrEmptyBody)
+
+		return
 	}
 
-	if err := json.NewDecoder(body).Decode(&tokenBody); err != nil {
-		h.sendErrorResponse(w, &e.ErrInvalidToken{Msg: "invalid token", Err: err})
+	principal, err := h.principal(r, update.Token)
+	if err != nil {
+		h.sendErrorResponse(w, err)
 
 		return
 	}
 
-	if err := h.userService.VerifyAccessToken(tokenBody.Token); err != nil {
+	revision, err := ifMatch(r)
+	if err != nil {
 		h.sendErrorResponse(w, err)
+
 		return
 	}
+	update.IfMatch = revision
 
-	err := h.documentService.DeleteDocument(tokenBody.Token, docID)
+	document, err := h.documentService.UpdateDocument(principal, docID, update)
+	h.audit(r, principal, core.AuditEvent{Action: core.ActionDocumentUpdate, DocumentID: docID}, err)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	res := core.Response{}
+	res.Data = document
+
+	setETag(w, document.Revision)
+	h.sendResponse(w, res, http.StatusOK)
+}
+
+func (h *Handler) shareDocument(w http.ResponseWriter, r *http.Request) {
+	docID := chi.URLParam(r, "docID")
+
+	body := r.Body
+	defer h.closeRequestBody(body)
+
+	var share core.ShareRequest
+
+	if err := decodeBody(body, &share); err != nil {
+		h.sendErrorResponse(w, e.ErrEmptyBody)
+
+		return
+	}
+
+	principal, err := h.principal(r, share.Token)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	document, err := h.documentService.ShareDocument(principal, docID, share.Grant)
+	h.audit(r, principal, core.AuditEvent{Action: core.ActionDocumentShare, DocumentID: docID}, err)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	res := core.Response{}
+	res.Data = document
+
+	setETag(w, document.Revision)
+	h.sendResponse(w, res, http.StatusOK)
+}
+
+func (h *Handler) deleteDocument(w http.ResponseWriter, r *http.Request) {
+	docID := chi.URLParam(r, "docID")
+
+	token, err := h.tokenFromBody(r)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	principal, err := h.principal(r, token)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	revision, err := ifMatch(r)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	err = h.documentService.DeleteDocument(principal, docID, revision)
+	h.audit(r, principal, core.AuditEvent{Action: core.ActionDocumentDelete, DocumentID: docID}, err)
 	if err != nil {
 		h.sendErrorResponse(w, err)
 
@@ -188,6 +295,33 @@ func (h *Handler) deleteDocument(w http.ResponseWriter, r *http.Request) {
 	h.sendResponse(w, res, http.StatusCreated)
 }
 
+func (h *Handler) setDocumentHeaders(w http.ResponseWriter, document core.Document) {
+	w.Header().Set(documentIDHeader, document.ID)
+	setETag(w, document.Revision)
+
+	if document.Lock != nil {
+		lock, err := json.Marshal(document.Lock)
+		if err != nil {
+			h.l.Error().Err(err).Msg("failed marshal document lock")
+		} else {
+			w.Header().Set(documentLockHeader, string(lock))
+		}
+	}
+
+	if len(document.Attributes) == 0 {
+		return
+	}
+
+	attributes, err := json.Marshal(document.Attributes)
+	if err != nil {
+		h.l.Error().Err(err).Msg("failed marshal document attributes")
+
+		return
+	}
+
+	w.Header().Set(documentAttributesHeader, string(attributes))
+}
+
 func (h *Handler) serveJson(w http.ResponseWriter, jsonData []byte) {
 	w.Header().Set("Content-Type", "application/json")
 	w.Write(jsonData)
@@ -0,0 +1,182 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/GroVlAn/doc-store/internal/core"
+	"github.com/GroVlAn/doc-store/internal/core/e"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	streamHeartbeat    = 30 * time.Second
+	socketWriteTimeout = 10 * time.Second
+)
+
+// eventStream pushes document events as Server-Sent Events. The token is
+// passed as a query parameter since EventSource can't send a body.
+func (h *Handler) eventStream(w http.ResponseWriter, r *http.Request) {
+	events, stop, ok := h.subscribeEvents(w, r)
+	if !ok {
+		return
+	}
+	defer stop()
+
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		h.l.Error().Err(err).Msg("failed to reset write deadline")
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	if err := rc.Flush(); err != nil {
+		h.l.Error().Err(err).Msg("failed to flush event stream")
+
+		return
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+
+			data, err := json.Marshal(event)
+			if err != nil {
+				h.l.Error().Err(err).Msg("failed marshal document event")
+				continue
+			}
+
+			if _, err := fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data); err != nil {
+				return
+			}
+		}
+
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// eventSocket pushes document events as WebSocket text messages.
+func (h *Handler) eventSocket(w http.ResponseWriter, r *http.Request) {
+	events, stop, ok := h.subscribeEvents(w, r)
+	if !ok {
+		return
+	}
+	defer stop()
+
+	upgrader := websocket.Upgrader{CheckOrigin: h.checkOrigin}
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		h.l.Error().Err(err).Msg("failed to upgrade connection")
+
+		return
+	}
+	defer conn.Close()
+
+	closed := make(chan struct{})
+
+	go func() {
+		defer close(closed)
+
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case <-heartbeat.C:
+			deadline := time.Now().Add(socketWriteTimeout)
+			if err := conn.WriteControl(websocket.PingMessage, nil, deadline); err != nil {
+				return
+			}
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+
+			if err := conn.SetWriteDeadline(time.Now().Add(socketWriteTimeout)); err != nil {
+				return
+			}
+
+			if err := conn.WriteJSON(event); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (h *Handler) subscribeEvents(w http.ResponseWriter, r *http.Request) (<-chan core.FeedEvent, func(), bool) {
+	if !h.checkOrigin(r) {
+		h.sendErrorResponse(w, e.ErrForbidden)
+
+		return nil, nil, false
+	}
+
+	token := r.URL.Query().Get("token")
+
+	principal, err := h.principal(r, token)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return nil, nil, false
+	}
+
+	events, stop, err := h.eventService.SubscribeEvents(principal)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return nil, nil, false
+	}
+
+	return events, stop, true
+}
+
+// checkOrigin accepts requests from the server's own origin and the allowed
+// ones. Requests without an Origin header don't come from a browser page.
+func (h *Handler) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if len(origin) == 0 {
+		return true
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+
+	if strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+
+	return slices.Contains(h.allowedOrigins, origin)
+}
@@ -3,47 +3,193 @@ package handler
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
+	"math"
 	"net/http"
+	"strconv"
 
+	"github.com/GroVlAn/doc-store/internal/archive"
 	"github.com/GroVlAn/doc-store/internal/core"
 	"github.com/GroVlAn/doc-store/internal/core/e"
+	"github.com/GroVlAn/doc-store/internal/keyring"
 	"github.com/go-chi/chi"
 	"github.com/rs/zerolog"
 )
 
 const (
-	addrPath     = "/api"
-	registerPath = "/register"
-	authPath     = "/auth"
-	documentPath = "/docs"
+	addrPath      = "/api"
+	jwksPath      = "/.well-known/jwks.json"
+	registerPath  = "/register"
+	authPath      = "/auth"
+	refreshPath   = "/refresh"
+	oidcPath      = "/oidc"
+	twoFactorPath = "/2fa"
+	passwordPath  = "/password"
+	resetPath     = "/reset"
+	sessionsPath  = "/sessions"
+	apiKeysPath   = "/keys"
+	documentPath  = "/docs"
+	trashPath     = "/trash"
+	adminPath     = "/admin"
+	holdPath      = "/holds"
+	policyPath    = "/retention"
+	auditPath     = "/audit"
+	sharePath     = "/share"
+	webhookPath   = "/webhooks"
+	eventsPath    = "/events"
+	bulkPath      = "/bulk"
+	archivePath   = "/archive"
+	accountPath   = "/account"
+	lockPath      = "/lock"
+	versionsPath  = "/versions"
+	diffPath      = "/diff"
+	commentsPath  = "/comments"
+	relationPath  = "/relations"
+
+	documentIDHeader         = "X-Document-ID"
+	documentAttributesHeader = "X-Document-Attributes"
+	documentLockHeader       = "X-Document-Lock"
 )
 
 type userService interface {
 	Register(user core.User) error
-	Auth(user core.User) (string, error)
-	VerifyAccessToken(token string) error
+	Auth(user core.User, client core.Client) (core.AuthResult, error)
+	CompleteTwoFactor(challenge string, code string, client core.Client) (core.AuthResult, error)
+	Refresh(refreshToken string, client core.Client) (core.TokenPair, error)
+	Authenticate(token string, client core.Client) (core.Principal, error)
 	Logout(token string) error
+	Sessions(principal core.Principal) ([]core.Session, error)
+	RevokeSession(principal core.Principal, sessionID string) error
+	RevokeSessions(principal core.Principal) error
+	CreateAPIKey(principal core.Principal, key core.APIKey) (core.APIKey, error)
+	APIKeys(principal core.Principal) ([]core.APIKey, error)
+	DeleteAPIKey(principal core.Principal, keyID string) error
+	JWKS() keyring.JWKS
+	OIDCLogin() (string, string, error)
+	OIDCCallback(code string, state string, browserState string, client core.Client) (core.AuthResult, error)
+	EnrollTOTP(principal core.Principal) (core.TOTPEnrollment, error)
+	ConfirmTOTP(principal core.Principal, code string) ([]string, error)
+	DisableTOTP(principal core.Principal, code string) error
+	RegenerateRecoveryCodes(principal core.Principal, code string) ([]string, error)
+	TwoFactorPolicy(principal core.Principal) (core.TwoFactorPolicy, error)
+	SetTwoFactorPolicy(principal core.Principal, policy core.TwoFactorPolicy) (core.TwoFactorPolicy, error)
+	ChangePassword(principal core.Principal, change core.PasswordChange) error
+	RequestPasswordReset(login string) error
+	ResetPassword(token string, password string) error
 }
 
 type documentService interface {
-	CreateDocument(document core.Document, file []byte) error
-	Document(token string, documentID string) (core.Document, string, error)
-	DocumentsList(token string, filter core.DocumentFilter) ([]core.Document, error)
-	DeleteDocument(token string, documentID string) error
+	CreateDocument(principal core.Principal, document core.Document, file []byte) (core.Document, error)
+	Document(principal core.Principal, documentID string) (core.Document, string, error)
+	DocumentsList(principal core.Principal, filter core.DocumentFilter) ([]core.Document, error)
+	UpdateDocument(principal core.Principal, documentID string, update core.DocumentUpdate) (core.Document, error)
+	ShareDocument(principal core.Principal, documentID string, grant []string) (core.Document, error)
+	DeleteDocument(principal core.Principal, documentID string, ifMatch *int64) error
+	CreateDocuments(principal core.Principal, documents []core.BulkDocument) ([]core.BulkResult, error)
+	DeleteDocuments(principal core.Principal, documentIDs []string, filter *core.DocumentFilter) ([]core.BulkResult, error)
+	ArchiveDocuments(principal core.Principal, documentIDs []string, filter *core.DocumentFilter) ([]core.ArchiveEntry, error)
+	TrashList(principal core.Principal) ([]core.Document, error)
+	RestoreDocument(principal core.Principal, documentID string) (core.Document, error)
+	PurgeDocument(principal core.Principal, documentID string) error
+	LockDocument(principal core.Principal, documentID string, request core.LockRequest) (core.Document, error)
+	UnlockDocument(principal core.Principal, documentID string) error
+	ForceUnlockDocument(principal core.Principal, documentID string) error
+	DocumentVersions(principal core.Principal, documentID string) ([]core.DocumentVersion, error)
+	DiffDocument(principal core.Principal, documentID string, from int64, to int64) (core.DocumentDiff, error)
+}
+
+type retentionService interface {
+	PlaceLegalHold(principal core.Principal, documentID string) error
+	ReleaseLegalHold(principal core.Principal, documentID string) error
+	CreateRetentionPolicy(principal core.Principal, policy core.RetentionPolicy) (core.RetentionPolicy, error)
+	RetentionPolicies(principal core.Principal) ([]core.RetentionPolicy, error)
+	DeleteRetentionPolicy(principal core.Principal, policyID string) error
+}
+
+type auditService interface {
+	RecordAudit(principal core.Principal, event core.AuditEvent) error
+	AuditLog(principal core.Principal, filter core.AuditFilter) ([]core.AuditEvent, error)
+	DocumentAudit(principal core.Principal, documentID string, filter core.AuditFilter) ([]core.AuditEvent, error)
+}
+
+type webhookService interface {
+	CreateWebhook(principal core.Principal, webhook core.Webhook) (core.Webhook, error)
+	Webhooks(principal core.Principal) ([]core.Webhook, error)
+	DeleteWebhook(principal core.Principal, webhookID string) error
+	WebhookDeliveries(principal core.Principal, webhookID string) ([]core.DeliveryAttempt, error)
+}
+
+type eventService interface {
+	SubscribeEvents(principal core.Principal) (<-chan core.FeedEvent, func(), error)
+}
+
+type accountService interface {
+	ExportAccount(principal core.Principal) (core.ExportManifest, []core.ArchiveEntry, error)
+	ImportAccount(principal core.Principal, ar archive.Reader, conflict string) ([]core.BulkResult, error)
+	DeleteAccount(principal core.Principal, deletion core.AccountDeletion) error
+}
+
+type commentService interface {
+	CreateComment(principal core.Principal, documentID string, comment core.Comment) (core.Comment, error)
+	Comments(principal core.Principal, documentID string) ([]core.Comment, error)
+	UpdateComment(principal core.Principal, documentID string, commentID string, body string) (core.Comment, error)
+	ResolveComment(principal core.Principal, documentID string, commentID string, resolved bool) (core.Comment, error)
+	DeleteComment(principal core.Principal, documentID string, commentID string) error
+}
+
+type relationService interface {
+	CreateRelation(principal core.Principal, sourceID string, relation core.Relation) (core.Relation, error)
+	Relations(principal core.Principal, documentID string) (core.DocumentRelations, error)
+	DeleteRelation(principal core.Principal, documentID string, relationID string) error
+}
+
+type Deps struct {
+	UserService      userService
+	DocumentService  documentService
+	RetentionService retentionService
+	AuditService     auditService
+	WebhookService   webhookService
+	EventService     eventService
+	AccountService   accountService
+	CommentService   commentService
+	RelationService  relationService
+
+	// AllowedOrigins may open the change feed besides the server's own origin.
+	AllowedOrigins []string
+	// ImportMaxSize bounds the body of an account import, 0 uses the default.
+	ImportMaxSize int64
 }
 
 type Handler struct {
-	l               zerolog.Logger
-	userService     userService
-	documentService documentService
+	l                zerolog.Logger
+	userService      userService
+	documentService  documentService
+	retentionService retentionService
+	auditService     auditService
+	webhookService   webhookService
+	eventService     eventService
+	accountService   accountService
+	commentService   commentService
+	relationService  relationService
+	allowedOrigins   []string
+	importMaxSize    int64
 }
 
-func New(l zerolog.Logger, userService userService, documentService documentService) *Handler {
+func New(l zerolog.Logger, deps Deps) *Handler {
 	return &Handler{
-		l:               l,
-		userService:     userService,
-		documentService: documentService,
+		l:                l,
+		userService:      deps.UserService,
+		documentService:  deps.DocumentService,
+		retentionService: deps.RetentionService,
+		auditService:     deps.AuditService,
+		webhookService:   deps.WebhookService,
+		eventService:     deps.EventService,
+		accountService:   deps.AccountService,
+		commentService:   deps.CommentService,
+		relationService:  deps.RelationService,
+		allowedOrigins:   deps.AllowedOrigins,
+		importMaxSize:    deps.ImportMaxSize,
 	}
 }
 
@@ -52,17 +198,107 @@ func (h *Handler) Handler() *chi.Mux {
 
 	h.useMiddleware(r)
 
+	r.Get(jwksPath, h.jwks)
+
 	r.Route(addrPath, func(r chi.Router) {
 		r.Post(registerPath, h.register)
 		r.Get(authPath, h.auth)
+		r.Post(authPath+refreshPath, h.refresh)
+		r.Get(authPath+oidcPath+"/login", h.oidcLogin)
+		r.Get(authPath+oidcPath+"/callback", h.oidcCallback)
+		r.Post(authPath+twoFactorPath, h.completeTwoFactor)
+		r.Post(authPath+passwordPath+resetPath, h.requestPasswordReset)
+		r.Post(authPath+passwordPath+resetPath+"/confirm", h.resetPassword)
 		r.Delete(authPath+"/{token}", h.logout)
 
-		r.Post(documentPath, h.createDocument)
-		r.Get(documentPath, h.documentsList)
-
-		r.Route(documentPath+"/{docID}", func(r chi.Router) {
-			r.Get("/", h.document)
-			r.Delete("/", h.deleteDocument)
+		r.Group(func(r chi.Router) {
+			r.Use(h.authenticate)
+
+			// routes without a scope are not available to API keys
+			read := h.requireScope(core.ScopeDocsRead)
+			write := h.requireScope(core.ScopeDocsWrite)
+			del := h.requireScope(core.ScopeDocsDelete)
+			share := h.requireScope(core.ScopeShare)
+
+			r.Post(apiKeysPath, h.createAPIKey)
+			r.Get(apiKeysPath, h.apiKeys)
+			r.Delete(apiKeysPath+"/{keyID}", h.deleteAPIKey)
+
+			r.Post(twoFactorPath+"/totp", h.enrollTOTP)
+			r.Post(twoFactorPath+"/totp/confirm", h.confirmTOTP)
+			r.Delete(twoFactorPath+"/totp", h.disableTOTP)
+			r.Post(twoFactorPath+"/recovery-codes", h.regenerateRecoveryCodes)
+
+			r.Get(sessionsPath, h.sessions)
+			r.Delete(sessionsPath, h.revokeSessions)
+			r.Delete(sessionsPath+"/{sessionID}", h.revokeSession)
+
+			r.With(write).Post(documentPath, h.createDocument)
+			r.With(read).Get(documentPath, h.documentsList)
+			r.With(del).Delete(documentPath, h.deleteDocuments)
+			r.With(write).Post(documentPath+bulkPath, h.createDocuments)
+			r.With(read).Post(documentPath+archivePath, h.archiveDocuments)
+
+			r.Route(documentPath+"/{docID}", func(r chi.Router) {
+				r.With(read).Get("/", h.document)
+				r.With(write).Patch("/", h.updateDocument)
+				r.With(del).Delete("/", h.deleteDocument)
+				r.With(read).Get(auditPath, h.documentAudit)
+				r.With(share).Post(sharePath, h.shareDocument)
+				r.With(write).Post(lockPath, h.lockDocument)
+				r.With(write).Delete(lockPath, h.unlockDocument)
+				r.With(write).Delete(lockPath+"/force", h.forceUnlockDocument)
+				r.With(read).Get(versionsPath, h.documentVersions)
+				r.With(read).Get(diffPath, h.diffDocument)
+
+				r.With(write).Post(commentsPath, h.createComment)
+				r.With(read).Get(commentsPath, h.comments)
+				r.Route(commentsPath+"/{commentID}", func(r chi.Router) {
+					r.With(write).Patch("/", h.updateComment)
+					r.With(write).Delete("/", h.deleteComment)
+					r.With(write).Post("/resolve", h.resolveComment)
+					r.With(write).Delete("/resolve", h.reopenComment)
+				})
+
+				r.With(write).Post(relationPath, h.createRelation)
+				r.With(read).Get(relationPath, h.relations)
+				r.With(write).Delete(relationPath+"/{relationID}", h.deleteRelation)
+			})
+
+			r.With(read).Get(trashPath, h.trashList)
+			r.Route(trashPath+"/{docID}", func(r chi.Router) {
+				r.With(write).Post("/restore", h.restoreDocument)
+				r.With(del).Delete("/", h.purgeDocument)
+			})
+
+			r.Get(accountPath+"/export", h.exportAccount)
+			r.Post(accountPath+"/import", h.importAccount)
+			r.Post(accountPath+passwordPath, h.changePassword)
+			r.Delete(accountPath, h.deleteAccount)
+
+			r.With(read).Get(eventsPath, h.eventStream)
+			r.With(read).Get(eventsPath+"/ws", h.eventSocket)
+
+			r.Post(webhookPath, h.createWebhook)
+			r.Get(webhookPath, h.webhooks)
+			r.Route(webhookPath+"/{webhookID}", func(r chi.Router) {
+				r.Delete("/", h.deleteWebhook)
+				r.Get("/deliveries", h.webhookDeliveries)
+			})
+
+			r.Route(adminPath, func(r chi.Router) {
+				r.Post(holdPath+"/{docID}", h.placeLegalHold)
+				r.Delete(holdPath+"/{docID}", h.releaseLegalHold)
+
+				r.Get(policyPath, h.retentionPolicies)
+				r.Post(policyPath, h.createRetentionPolicy)
+				r.Delete(policyPath+"/{policyID}", h.deleteRetentionPolicy)
+
+				r.Get(auditPath, h.auditLog)
+
+				r.Get(twoFactorPath, h.twoFactorPolicy)
+				r.Put(twoFactorPath, h.setTwoFactorPolicy)
+			})
 		})
 	})
 
@@ -74,6 +310,12 @@ func (h *Handler) sendErrorResponse(w http.ResponseWriter, err error) {
 
 	status, msg := h.handleError(err)
 
+	var errTooManyAttempts *e.ErrTooManyAttempts
+	if errors.As(err, &errTooManyAttempts) {
+		retryAfter := int64(math.Ceil(errTooManyAttempts.RetryAfter.Seconds()))
+		w.Header().Set("Retry-After", strconv.FormatInt(retryAfter, 10))
+	}
+
 	res.Error = &core.ErrorResponse{}
 	res.Error.Code = status
 	res.Error.Text = msg
@@ -86,8 +328,17 @@ func (h *Handler) handleError(err error) (int, string) {
 	var errInsert *e.ErrInsert
 	var errDelete *e.ErrDelete
 	var errInvalidToken *e.ErrInvalidToken
+	var errTooManyAttempts *e.ErrTooManyAttempts
 
 	switch {
+	case errors.As(err, &errTooManyAttempts):
+		h.l.Warn().Err(err).Dur("retry_after", errTooManyAttempts.RetryAfter).Msg("attempt throttled")
+
+		return http.StatusTooManyRequests, err.Error()
+	case errors.Is(err, e.ErrBusy):
+		h.l.Error().Err(err).Msg("password hashing is saturated")
+
+		return http.StatusServiceUnavailable, e.ErrBusy.Error()
 	case errors.As(err, &errInvalidToken):
 		h.l.Error().Err(errInvalidToken.Unwrap()).Msg("failed verify token")
 
@@ -124,6 +375,170 @@ func (h *Handler) handleError(err error) (int, string) {
 		h.l.Error().Err(err).Msg("document not found")
 
 		return http.StatusNotFound, err.Error()
+	case errors.Is(err, e.ErrDocumentRetained):
+		h.l.Error().Err(err).Msg("document is retained")
+
+		return http.StatusLocked, err.Error()
+	case errors.Is(err, e.ErrDocumentLocked):
+		h.l.Error().Err(err).Msg("document is locked")
+
+		return http.StatusLocked, err.Error()
+	case errors.Is(err, e.ErrPreconditionFailed):
+		h.l.Error().Err(err).Msg("document revision does not match")
+
+		return http.StatusPreconditionFailed, err.Error()
+	case errors.Is(err, e.ErrPreconditionRequired):
+		h.l.Error().Err(err).Msg("missing If-Match header")
+
+		return http.StatusPreconditionRequired, err.Error()
+	case errors.Is(err, e.ErrVersionNotFound):
+		h.l.Error().Err(err).Msg("document version not found")
+
+		return http.StatusNotFound, err.Error()
+	case errors.Is(err, e.ErrNotDiffable):
+		h.l.Error().Err(err).Msg("failed to compare document versions")
+
+		return http.StatusUnprocessableEntity, err.Error()
+	case errors.Is(err, e.ErrCommentNotFound):
+		h.l.Error().Err(err).Msg("comment not found")
+
+		return http.StatusNotFound, err.Error()
+	case errors.Is(err, e.ErrAPIKeyNotFound):
+		h.l.Error().Err(err).Msg("api key not found")
+
+		return http.StatusNotFound, err.Error()
+	case errors.Is(err, e.ErrInvalidAPIKey):
+		h.l.Error().Err(err).Msg("failed to verify api key")
+
+		return http.StatusBadRequest, err.Error()
+	case errors.Is(err, e.ErrOIDCDisabled):
+		h.l.Error().Err(err).Msg("oidc login is disabled")
+
+		return http.StatusNotFound, err.Error()
+	case errors.Is(err, e.ErrOIDCFailed):
+		h.l.Error().Err(err).Msg("failed oidc login")
+
+		return http.StatusUnauthorized, e.ErrOIDCFailed.Error()
+	case errors.Is(err, e.ErrAccountLinked):
+		h.l.Error().Err(err).Msg("failed to link account")
+
+		return http.StatusConflict, err.Error()
+	case errors.Is(err, e.ErrInvalidTOTP), errors.Is(err, e.ErrInvalidChallenge):
+		h.l.Error().Err(err).Msg("failed to verify second factor")
+
+		return http.StatusUnauthorized, err.Error()
+	case errors.Is(err, e.ErrTwoFactorEnabled):
+		h.l.Error().Err(err).Msg("failed to enroll totp")
+
+		return http.StatusConflict, err.Error()
+	case errors.Is(err, e.ErrTwoFactorNotEnabled):
+		h.l.Error().Err(err).Msg("two-factor authentication is not enabled")
+
+		return http.StatusConflict, err.Error()
+	case errors.Is(err, e.ErrTwoFactorRequired):
+		h.l.Error().Err(err).Msg("two-factor authentication is required")
+
+		return http.StatusForbidden, err.Error()
+	case errors.Is(err, e.ErrInvalidTwoFactorRule):
+		h.l.Error().Err(err).Msg("failed to verify two-factor policy")
+
+		return http.StatusBadRequest, err.Error()
+	case errors.Is(err, e.ErrInvalidEmail):
+		h.l.Error().Err(err).Msg("failed to verify email")
+
+		return http.StatusBadRequest, err.Error()
+	case errors.Is(err, e.ErrInvalidResetToken):
+		h.l.Error().Err(err).Msg("failed to verify password reset token")
+
+		return http.StatusBadRequest, err.Error()
+	case errors.Is(err, e.ErrInvalidTransfer):
+		h.l.Error().Err(err).Msg("failed to transfer account documents")
+
+		return http.StatusBadRequest, err.Error()
+	case errors.Is(err, e.ErrSessionNotFound):
+		h.l.Error().Err(err).Msg("session not found")
+
+		return http.StatusNotFound, err.Error()
+	case errors.Is(err, e.ErrRelationNotFound):
+		h.l.Error().Err(err).Msg("relation not found")
+
+		return http.StatusNotFound, err.Error()
+	case errors.Is(err, e.ErrRelationExist):
+		h.l.Error().Err(err).Msg("relation already exist")
+
+		return http.StatusConflict, err.Error()
+	case errors.Is(err, e.ErrForbidden):
+		h.l.Error().Err(err).Msg("access denied")
+
+		return http.StatusForbidden, err.Error()
+	case errors.Is(err, e.ErrInvalidWebhook), errors.Is(err, e.ErrWebhookAddress):
+		h.l.Error().Err(err).Msg("failed to verify webhook")
+
+		return http.StatusBadRequest, err.Error()
+	case errors.Is(err, e.ErrWebhookNotFound):
+		h.l.Error().Err(err).Msg("webhook not found")
+
+		return http.StatusNotFound, err.Error()
+	case errors.Is(err, archive.ErrUnsupportedFormat):
+		h.l.Error().Err(err).Msg("failed to verify archive format")
+
+		return http.StatusBadRequest, err.Error()
+	case errors.Is(err, e.ErrInvalidArchive), errors.Is(err, e.ErrInvalidConflict):
+		h.l.Error().Err(err).Msg("failed to import account")
+
+		return http.StatusBadRequest, err.Error()
+	case errors.Is(err, e.ErrFileTooLarge):
+		h.l.Error().Err(err).Msg("failed to read request body")
+
+		return http.StatusRequestEntityTooLarge, err.Error()
+	case errors.Is(err, e.ErrInvalidLock):
+		h.l.Error().Err(err).Msg("failed to verify lock duration")
+
+		return http.StatusBadRequest, err.Error()
+	case errors.Is(err, e.ErrInvalidComment):
+		h.l.Error().Err(err).Msg("failed to verify comment")
+
+		return http.StatusBadRequest, err.Error()
+	case errors.Is(err, e.ErrInvalidRelation):
+		h.l.Error().Err(err).Msg("failed to verify relation")
+
+		return http.StatusBadRequest, err.Error()
+	case errors.Is(err, e.ErrInvalidMeta):
+		h.l.Error().Err(err).Msg("failed to decode document meta")
+
+		return http.StatusBadRequest, err.Error()
+	case errors.Is(err, e.ErrInvalidName):
+		h.l.Error().Err(err).Msg("failed to verify document name")
+
+		return http.StatusBadRequest, err.Error()
+	case errors.Is(err, e.ErrEmptyBody):
+		h.l.Error().Err(err).Msg("empty request data")
+
+		return http.StatusBadRequest, err.Error()
+	case errors.Is(err, e.ErrInvalidPolicy):
+		h.l.Error().Err(err).Msg("failed to verify retention policy")
+
+		return http.StatusBadRequest, err.Error()
+	case errors.Is(err, e.ErrDocumentExist):
+		h.l.Error().Err(err).Msg("document already exist")
+
+		return http.StatusConflict, err.Error()
+	case errors.Is(err, e.ErrInvalidAttribute):
+		h.l.Error().Err(err).Msg("failed to verify document attributes")
+
+		return http.StatusBadRequest, err.Error()
+	case errors.Is(err, e.ErrInvalidTTL):
+		h.l.Error().Err(err).Msg("failed to verify document expiration")
+
+		return http.StatusBadRequest, err.Error()
+	case errors.Is(err, e.ErrInvalidSort):
+		h.l.Error().Err(err).Msg("failed to verify documents sort")
+
+		return http.StatusBadRequest, err.Error()
+	case errors.Is(err, e.ErrInvalidFilter):
+		h.l.Error().Err(err).Msg("failed to verify documents filter")
+
+		return http.StatusBadRequest, err.Error()
 	default:
 		h.l.Error().Err(err).Msg("internal error")
 
@@ -145,6 +560,32 @@ func (h *Handler) sendResponse(w http.ResponseWriter, res core.Response, status
 	}
 }
 
+// tokenFromBody decodes the deprecated access token from a JSON request body.
+func (h *Handler) tokenFromBody(r *http.Request) (string, error) {
+	body := r.Body
+	defer h.closeRequestBody(body)
+
+	var tokenBody struct {
+		Token string `json:"token"`
+	}
+
+	if err := decodeBody(body, &tokenBody); err != nil {
+		return "", &e.ErrInvalidToken{Msg: "invalid token", Err: fmt.Errorf("decoding token body: %w", err)}
+	}
+
+	return tokenBody.Token, nil
+}
+
+// decodeBody decodes a JSON request body. An empty body is not an error since
+// the token may come from the Authorization header.
+func decodeBody(body io.Reader, v any) error {
+	if err := json.NewDecoder(body).Decode(v); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+
+	return nil
+}
+
 func (h *Handler) closeRequestBody(body io.ReadCloser) {
 	if err := body.Close(); err != nil {
 		h.l.Error().Err(err).Msg("failed to close request body")
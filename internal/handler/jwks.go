@@ -0,0 +1,27 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+const jwksMaxAge = "public, max-age=300"
+
+// jwks publishes the public keys access tokens are signed with, so other
+// services can verify them without the signing secret.
+func (h *Handler) jwks(w http.ResponseWriter, r *http.Request) {
+	b, err := json.Marshal(h.userService.JWKS())
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/jwk-set+json")
+	w.Header().Set("Cache-Control", jwksMaxAge)
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := w.Write(b); err != nil {
+		h.l.Error().Err(err).Msg("failed write response")
+	}
+}
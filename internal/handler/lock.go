@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/GroVlAn/doc-store/internal/core"
+	"github.com/GroVlAn/doc-store/internal/core/e"
+	"github.com/go-chi/chi"
+)
+
+func (h *Handler) lockDocument(w http.ResponseWriter, r *http.Request) {
+	docID := chi.URLParam(r, "docID")
+
+	body := r.Body
+	defer h.closeRequestBody(body)
+
+	var request core.LockRequest
+
+	if err := decodeBody(body, &request); err != nil {
+		h.sendErrorResponse(w, e.ErrEmptyBody)
+
+		return
+	}
+
+	principal, err := h.principal(r, request.Token)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	document, err := h.documentService.LockDocument(principal, docID, request)
+	h.audit(r, principal, core.AuditEvent{Action: core.ActionDocumentLock, DocumentID: docID}, err)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	res := core.Response{}
+	res.Data = document.Lock
+
+	h.sendResponse(w, res, http.StatusOK)
+}
+
+func (h *Handler) unlockDocument(w http.ResponseWriter, r *http.Request) {
+	h.removeLock(w, r, core.ActionDocumentUnlock, h.documentService.UnlockDocument)
+}
+
+func (h *Handler) forceUnlockDocument(w http.ResponseWriter, r *http.Request) {
+	h.removeLock(w, r, core.ActionDocumentForceUnlock, h.documentService.ForceUnlockDocument)
+}
+
+func (h *Handler) removeLock(
+	w http.ResponseWriter,
+	r *http.Request,
+	action string,
+	unlock func(principal core.Principal, documentID string) error,
+) {
+	docID := chi.URLParam(r, "docID")
+
+	token, err := h.tokenFromBody(r)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	principal, err := h.principal(r, token)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	err = unlock(principal, docID)
+	h.audit(r, principal, core.AuditEvent{Action: action, DocumentID: docID}, err)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	res := core.Response{}
+	res.Response = map[string]bool{docID: true}
+
+	h.sendResponse(w, res, http.StatusOK)
+}
@@ -1,11 +1,23 @@
 package handler
 
 import (
+	"context"
+	"fmt"
 	"net/http"
+	"strings"
 
+	"github.com/GroVlAn/doc-store/internal/core"
+	"github.com/GroVlAn/doc-store/internal/core/e"
 	"github.com/go-chi/chi"
 )
 
+const bearerScheme = "Bearer "
+
+type (
+	principalKey struct{}
+	scopeKey     struct{}
+)
+
 func (h *Handler) useMiddleware(r *chi.Mux) {
 	r.Use(h.cors)
 }
@@ -13,7 +25,84 @@ func (h *Handler) useMiddleware(r *chi.Mux) {
 func (h *Handler) cors(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type, If-Match")
 
 		next.ServeHTTP(w, r)
 	})
 }
+
+// authenticate verifies the "Authorization: Bearer" token once and puts the
+// principal into the request context. Requests without the header are passed
+// on to the deprecated body token fallback.
+func (h *Handler) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		if len(header) == 0 {
+			next.ServeHTTP(w, r)
+
+			return
+		}
+
+		if len(header) <= len(bearerScheme) || !strings.EqualFold(header[:len(bearerScheme)], bearerScheme) {
+			err := &e.ErrInvalidToken{Msg: "invalid authorization header"}
+			h.auditDenied(r, core.Principal{}, err)
+			h.sendErrorResponse(w, err)
+
+			return
+		}
+
+		principal, err := h.userService.Authenticate(strings.TrimSpace(header[len(bearerScheme):]), client(r))
+		if err != nil {
+			h.auditDenied(r, core.Principal{}, err)
+			h.sendErrorResponse(w, err)
+
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), principalKey{}, principal)))
+	})
+}
+
+// requireScope sets the scope an API key needs for the route.
+func (h *Handler) requireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), scopeKey{}, scope)))
+		})
+	}
+}
+
+// principal returns the caller authenticated by the middleware. Without the
+// Authorization header the token passed in the request body or query is
+// verified instead, that way of passing the token is deprecated. API keys are
+// checked against the scope of the route. Rejected callers are audited.
+func (h *Handler) principal(r *http.Request, token string) (core.Principal, error) {
+	principal, ok := r.Context().Value(principalKey{}).(core.Principal)
+	if !ok {
+		if len(token) == 0 {
+			err := &e.ErrInvalidToken{Msg: "missing access token"}
+			h.auditDenied(r, core.Principal{}, err)
+
+			return core.Principal{}, err
+		}
+
+		h.l.Warn().Str("path", r.URL.Path).Msg("access token outside of the Authorization header is deprecated")
+
+		var err error
+		principal, err = h.userService.Authenticate(token, client(r))
+		if err != nil {
+			h.auditDenied(r, core.Principal{}, err)
+
+			return core.Principal{}, err
+		}
+	}
+
+	scope, _ := r.Context().Value(scopeKey{}).(string)
+	if !principal.HasScope(scope) {
+		h.auditDenied(r, principal, fmt.Errorf("%w: missing scope %s", e.ErrForbidden, scope))
+
+		return core.Principal{}, e.ErrForbidden
+	}
+
+	return principal, nil
+}
@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/GroVlAn/doc-store/internal/core"
+)
+
+const oidcStateCookie = "oidc_state"
+
+// oidcLogin redirects the browser to the identity provider. The state is set
+// as a cookie too, the callback only accepts it from the same browser.
+func (h *Handler) oidcLogin(w http.ResponseWriter, r *http.Request) {
+	authURL, state, err := h.userService.OIDCLogin()
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    state,
+		Path:     addrPath + authPath + oidcPath,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// oidcCallback is the redirect URL registered at the identity provider, it
+// responds with doc-store tokens or a 2FA challenge.
+func (h *Handler) oidcCallback(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	if providerErr := query.Get("error"); len(providerErr) > 0 {
+		h.l.Error().Str("error", providerErr).Str("description", query.Get("error_description")).Msg("oidc provider error")
+	}
+
+	var browserState string
+	if cookie, err := r.Cookie(oidcStateCookie); err == nil {
+		browserState = cookie.Value
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Path:     addrPath + authPath + oidcPath,
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	result, err := h.userService.OIDCCallback(query.Get("code"), query.Get("state"), browserState, client(r))
+	if err != nil {
+		h.audit(r, core.Principal{}, core.AuditEvent{Action: core.ActionOIDC}, err)
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	var principal core.Principal
+	if result.TokenPair != nil {
+		principal, _ = h.userService.Authenticate(result.AccessToken, client(r))
+	}
+	h.audit(r, principal, core.AuditEvent{Action: core.ActionOIDC}, nil)
+
+	res := core.Response{}
+	res.Response = result
+
+	h.sendResponse(w, res, http.StatusOK)
+}
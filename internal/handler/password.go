@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/GroVlAn/doc-store/internal/core"
+	"github.com/GroVlAn/doc-store/internal/core/e"
+)
+
+// changePassword keeps the session of the request signed in and revokes the
+// other sessions of the user.
+func (h *Handler) changePassword(w http.ResponseWriter, r *http.Request) {
+	body := r.Body
+	defer h.closeRequestBody(body)
+
+	var change core.PasswordChange
+
+	if err := decodeBody(body, &change); err != nil {
+		h.sendErrorResponse(w, e.ErrEmptyBody)
+
+		return
+	}
+
+	principal, err := h.principal(r, change.Token)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	err = h.userService.ChangePassword(principal, change)
+	h.audit(r, principal, core.AuditEvent{Action: core.ActionPasswordChange}, err)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	res := core.Response{}
+	res.Response = map[string]bool{"password": true}
+
+	h.sendResponse(w, res, http.StatusOK)
+}
+
+// requestPasswordReset responds the same way whether the login exists or not.
+func (h *Handler) requestPasswordReset(w http.ResponseWriter, r *http.Request) {
+	body := r.Body
+	defer h.closeRequestBody(body)
+
+	var request core.PasswordResetRequest
+
+	err := json.NewDecoder(body).Decode(&request)
+	if err != nil {
+		h.sendErrorResponse(w, e.ErrEmptyBody)
+
+		return
+	}
+
+	err = h.userService.RequestPasswordReset(request.Login)
+	h.audit(r, core.Principal{}, core.AuditEvent{Actor: request.Login, Action: core.ActionPasswordResetRequest}, err)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	res := core.Response{}
+	res.Response = map[string]bool{"requested": true}
+
+	h.sendResponse(w, res, http.StatusAccepted)
+}
+
+func (h *Handler) resetPassword(w http.ResponseWriter, r *http.Request) {
+	body := r.Body
+	defer h.closeRequestBody(body)
+
+	var request core.PasswordResetRequest
+
+	err := json.NewDecoder(body).Decode(&request)
+	if err != nil {
+		h.sendErrorResponse(w, e.ErrEmptyBody)
+
+		return
+	}
+
+	err = h.userService.ResetPassword(request.Token, request.Password)
+	h.audit(r, core.Principal{}, core.AuditEvent{Action: core.ActionPasswordReset}, err)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	res := core.Response{}
+	res.Response = map[string]bool{"password": true}
+
+	h.sendResponse(w, res, http.StatusOK)
+}
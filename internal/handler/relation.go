@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/GroVlAn/doc-store/internal/core"
+	"github.com/GroVlAn/doc-store/internal/core/e"
+	"github.com/go-chi/chi"
+)
+
+func (h *Handler) createRelation(w http.ResponseWriter, r *http.Request) {
+	docID := chi.URLParam(r, "docID")
+
+	body := r.Body
+	defer h.closeRequestBody(body)
+
+	var relation core.Relation
+
+	if err := decodeBody(body, &relation); err != nil {
+		h.sendErrorResponse(w, e.ErrEmptyBody)
+
+		return
+	}
+
+	principal, err := h.principal(r, relation.Token)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	relation, err = h.relationService.CreateRelation(principal, docID, relation)
+	h.audit(r, principal, core.AuditEvent{Action: core.ActionRelationCreate, DocumentID: docID}, err)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	res := core.Response{}
+	res.Data = relation
+
+	h.sendResponse(w, res, http.StatusCreated)
+}
+
+func (h *Handler) relations(w http.ResponseWriter, r *http.Request) {
+	docID := chi.URLParam(r, "docID")
+
+	token, err := h.tokenFromBody(r)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	principal, err := h.principal(r, token)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	relations, err := h.relationService.Relations(principal, docID)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	res := core.Response{}
+	res.Data = relations
+
+	h.sendResponse(w, res, http.StatusOK)
+}
+
+func (h *Handler) deleteRelation(w http.ResponseWriter, r *http.Request) {
+	docID := chi.URLParam(r, "docID")
+	relationID := chi.URLParam(r, "relationID")
+
+	token, err := h.tokenFromBody(r)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	principal, err := h.principal(r, token)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	err = h.relationService.DeleteRelation(principal, docID, relationID)
+	h.audit(r, principal, core.AuditEvent{Action: core.ActionRelationDelete, DocumentID: docID}, err)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	res := core.Response{}
+	res.Response = map[string]bool{relationID: true}
+
+	h.sendResponse(w, res, http.StatusOK)
+}
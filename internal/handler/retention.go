@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/GroVlAn/doc-store/internal/core"
+	"github.com/GroVlAn/doc-store/internal/core/e"
+	"github.com/go-chi/chi"
+)
+
+func (h *Handler) placeLegalHold(w http.ResponseWriter, r *http.Request) {
+	h.setLegalHold(w, r, core.ActionLegalHoldPlace, h.retentionService.PlaceLegalHold)
+}
+
+func (h *Handler) releaseLegalHold(w http.ResponseWriter, r *http.Request) {
+	h.setLegalHold(w, r, core.ActionLegalHoldRelease, h.retentionService.ReleaseLegalHold)
+}
+
+func (h *Handler) setLegalHold(
+	w http.ResponseWriter,
+	r *http.Request,
+	action string,
+	set func(principal core.Principal, documentID string) error,
+) {
+	docID := chi.URLParam(r, "docID")
+
+	token, err := h.tokenFromBody(r)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	principal, err := h.principal(r, token)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	err = set(principal, docID)
+	h.audit(r, principal, core.AuditEvent{Action: action, DocumentID: docID}, err)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	res := core.Response{}
+	res.Response = map[string]bool{docID: true}
+
+	h.sendResponse(w, res, http.StatusOK)
+}
+
+func (h *Handler) createRetentionPolicy(w http.ResponseWriter, r *http.Request) {
+	body := r.Body
+	defer h.closeRequestBody(body)
+
+	var policy core.RetentionPolicy
+
+	if err := decodeBody(body, &policy); err != nil {
+		h.sendErrorResponse(w, e.ErrEmptyBody)
+
+		return
+	}
+
+	principal, err := h.principal(r, policy.Token)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	policy, err = h.retentionService.CreateRetentionPolicy(principal, policy)
+	h.audit(r, principal, core.AuditEvent{Action: core.ActionPolicyCreate}, err)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	res := core.Response{}
+	res.Data = policy
+
+	h.sendResponse(w, res, http.StatusCreated)
+}
+
+func (h *Handler) retentionPolicies(w http.ResponseWriter, r *http.Request) {
+	token, err := h.tokenFromBody(r)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	principal, err := h.principal(r, token)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	policies, err := h.retentionService.RetentionPolicies(principal)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	res := core.Response{}
+	res.Data = struct {
+		Policies []core.RetentionPolicy `json:"policies,omitempty"`
+	}{
+		Policies: policies,
+	}
+
+	h.sendResponse(w, res, http.StatusOK)
+}
+
+func (h *Handler) deleteRetentionPolicy(w http.ResponseWriter, r *http.Request) {
+	policyID := chi.URLParam(r, "policyID")
+
+	token, err := h.tokenFromBody(r)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	principal, err := h.principal(r, token)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	err = h.retentionService.DeleteRetentionPolicy(principal, policyID)
+	h.audit(r, principal, core.AuditEvent{Action: core.ActionPolicyDelete}, err)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	res := core.Response{}
+	res.Response = map[string]bool{policyID: true}
+
+	h.sendResponse(w, res, http.StatusOK)
+}
@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/GroVlAn/doc-store/internal/core"
+	"github.com/GroVlAn/doc-store/internal/core/e"
+)
+
+const (
+	etagHeader    = "ETag"
+	ifMatchHeader = "If-Match"
+)
+
+// ifMatch parses the If-Match header into the expected document revision,
+// nil when the header is absent. Only a single strong ETag or "*" is
+// accepted.
+func ifMatch(r *http.Request) (*int64, error) {
+	value := strings.TrimSpace(r.Header.Get(ifMatchHeader))
+	if len(value) == 0 {
+		return nil, nil
+	}
+
+	if value == "*" {
+		revision := core.AnyRevision
+
+		return &revision, nil
+	}
+
+	unquoted, err := strconv.Unquote(value)
+	if err != nil {
+		return nil, e.ErrPreconditionFailed
+	}
+
+	revision, err := strconv.ParseInt(unquoted, 10, 64)
+	if err != nil || revision < 0 {
+		return nil, e.ErrPreconditionFailed
+	}
+
+	return &revision, nil
+}
+
+func setETag(w http.ResponseWriter, revision int64) {
+	w.Header().Set(etagHeader, strconv.Quote(strconv.FormatInt(revision, 10)))
+}
@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/GroVlAn/doc-store/internal/core"
+	"github.com/go-chi/chi"
+)
+
+func (h *Handler) sessions(w http.ResponseWriter, r *http.Request) {
+	token, err := h.tokenFromBody(r)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	principal, err := h.principal(r, token)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	sessions, err := h.userService.Sessions(principal)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	res := core.Response{}
+	res.Data = struct {
+		Sessions []core.Session `json:"sessions"`
+	}{
+		Sessions: sessions,
+	}
+
+	h.sendResponse(w, res, http.StatusOK)
+}
+
+func (h *Handler) revokeSession(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "sessionID")
+
+	token, err := h.tokenFromBody(r)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	principal, err := h.principal(r, token)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	err = h.userService.RevokeSession(principal, sessionID)
+	h.audit(r, principal, core.AuditEvent{Action: core.ActionSessionRevoke}, err)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	res := core.Response{}
+	res.Response = map[string]bool{sessionID: true}
+
+	h.sendResponse(w, res, http.StatusOK)
+}
+
+// revokeSessions logs the caller out of every session.
+func (h *Handler) revokeSessions(w http.ResponseWriter, r *http.Request) {
+	token, err := h.tokenFromBody(r)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	principal, err := h.principal(r, token)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	err = h.userService.RevokeSessions(principal)
+	h.audit(r, principal, core.AuditEvent{Action: core.ActionSessionRevokeAll}, err)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	res := core.Response{}
+	res.Response = map[string]bool{principal.UserID: true}
+
+	h.sendResponse(w, res, http.StatusOK)
+}
@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/GroVlAn/doc-store/internal/core"
+	"github.com/go-chi/chi"
+)
+
+func (h *Handler) trashList(w http.ResponseWriter, r *http.Request) {
+	token, err := h.tokenFromBody(r)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	principal, err := h.principal(r, token)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	trashList, err := h.documentService.TrashList(principal)
+	h.audit(r, principal, core.AuditEvent{Action: core.ActionTrashList}, err)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	res := core.Response{}
+	res.Data = struct {
+		Docs []core.Document `json:"docs,omitempty"`
+	}{
+		Docs: trashList,
+	}
+
+	h.sendResponse(w, res, http.StatusOK)
+}
+
+func (h *Handler) restoreDocument(w http.ResponseWriter, r *http.Request) {
+	docID := chi.URLParam(r, "docID")
+
+	token, err := h.tokenFromBody(r)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	principal, err := h.principal(r, token)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	document, err := h.documentService.RestoreDocument(principal, docID)
+	h.audit(r, principal, core.AuditEvent{Action: core.ActionDocumentRestore, DocumentID: docID}, err)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	res := core.Response{}
+	res.Data = document
+
+	h.sendResponse(w, res, http.StatusOK)
+}
+
+func (h *Handler) purgeDocument(w http.ResponseWriter, r *http.Request) {
+	docID := chi.URLParam(r, "docID")
+
+	token, err := h.tokenFromBody(r)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	principal, err := h.principal(r, token)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	err = h.documentService.PurgeDocument(principal, docID)
+	h.audit(r, principal, core.AuditEvent{Action: core.ActionDocumentPurge, DocumentID: docID}, err)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	res := core.Response{}
+	res.Response = map[string]bool{docID: true}
+
+	h.sendResponse(w, res, http.StatusOK)
+}
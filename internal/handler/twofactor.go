@@ -0,0 +1,217 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/GroVlAn/doc-store/internal/core"
+	"github.com/GroVlAn/doc-store/internal/core/e"
+)
+
+// completeTwoFactor exchanges the challenge of a login and a TOTP or
+// recovery code for a token pair.
+func (h *Handler) completeTwoFactor(w http.ResponseWriter, r *http.Request) {
+	body := r.Body
+	defer h.closeRequestBody(body)
+
+	var request core.TwoFactorRequest
+
+	err := json.NewDecoder(body).Decode(&request)
+	if err != nil {
+		h.sendErrorResponse(w, e.ErrEmptyBody)
+
+		return
+	}
+
+	result, err := h.userService.CompleteTwoFactor(request.Challenge, request.Code, client(r))
+	if err != nil {
+		h.audit(r, core.Principal{}, core.AuditEvent{Action: core.ActionTwoFactor}, err)
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	principal, _ := h.userService.Authenticate(result.AccessToken, client(r))
+	h.audit(r, principal, core.AuditEvent{Action: core.ActionTwoFactor}, nil)
+
+	res := core.Response{}
+	res.Response = result
+
+	h.sendResponse(w, res, http.StatusOK)
+}
+
+// enrollTOTP responds with the secret to add to an authenticator app, the
+// enrollment is pending until confirmTOTP.
+func (h *Handler) enrollTOTP(w http.ResponseWriter, r *http.Request) {
+	token, err := h.tokenFromBody(r)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	principal, err := h.principal(r, token)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	enrollment, err := h.userService.EnrollTOTP(principal)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	res := core.Response{}
+	res.Data = enrollment
+
+	h.sendResponse(w, res, http.StatusOK)
+}
+
+func (h *Handler) confirmTOTP(w http.ResponseWriter, r *http.Request) {
+	h.recoveryCodesResponse(w, r, core.ActionTOTPEnable, h.userService.ConfirmTOTP)
+}
+
+func (h *Handler) regenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	h.recoveryCodesResponse(w, r, core.ActionRecoveryCodes, h.userService.RegenerateRecoveryCodes)
+}
+
+// recoveryCodesResponse responds with new recovery codes, they can't be read
+// later.
+func (h *Handler) recoveryCodesResponse(
+	w http.ResponseWriter,
+	r *http.Request,
+	action string,
+	codes func(principal core.Principal, code string) ([]string, error),
+) {
+	body := r.Body
+	defer h.closeRequestBody(body)
+
+	var request core.TwoFactorRequest
+
+	if err := decodeBody(body, &request); err != nil {
+		h.sendErrorResponse(w, e.ErrEmptyBody)
+
+		return
+	}
+
+	principal, err := h.principal(r, request.Token)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	recoveryCodes, err := codes(principal, request.Code)
+	h.audit(r, principal, core.AuditEvent{Action: action}, err)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	res := core.Response{}
+	res.Data = struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}{
+		RecoveryCodes: recoveryCodes,
+	}
+
+	h.sendResponse(w, res, http.StatusOK)
+}
+
+func (h *Handler) disableTOTP(w http.ResponseWriter, r *http.Request) {
+	body := r.Body
+	defer h.closeRequestBody(body)
+
+	var request core.TwoFactorRequest
+
+	if err := decodeBody(body, &request); err != nil {
+		h.sendErrorResponse(w, e.ErrEmptyBody)
+
+		return
+	}
+
+	principal, err := h.principal(r, request.Token)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	err = h.userService.DisableTOTP(principal, request.Code)
+	h.audit(r, principal, core.AuditEvent{Action: core.ActionTOTPDisable}, err)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	res := core.Response{}
+	res.Response = map[string]bool{"totp": false}
+
+	h.sendResponse(w, res, http.StatusOK)
+}
+
+func (h *Handler) twoFactorPolicy(w http.ResponseWriter, r *http.Request) {
+	token, err := h.tokenFromBody(r)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	principal, err := h.principal(r, token)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	policy, err := h.userService.TwoFactorPolicy(principal)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	res := core.Response{}
+	res.Data = policy
+
+	h.sendResponse(w, res, http.StatusOK)
+}
+
+func (h *Handler) setTwoFactorPolicy(w http.ResponseWriter, r *http.Request) {
+	body := r.Body
+	defer h.closeRequestBody(body)
+
+	var policy core.TwoFactorPolicy
+
+	if err := decodeBody(body, &policy); err != nil {
+		h.sendErrorResponse(w, e.ErrEmptyBody)
+
+		return
+	}
+
+	principal, err := h.principal(r, policy.Token)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	policy, err = h.userService.SetTwoFactorPolicy(principal, policy)
+	h.audit(r, principal, core.AuditEvent{Action: core.ActionTwoFactorRules}, err)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	res := core.Response{}
+	res.Data = policy
+
+	h.sendResponse(w, res, http.StatusOK)
+}
@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	"github.com/GroVlAn/doc-store/internal/core"
+	"github.com/GroVlAn/doc-store/internal/core/e"
 	"github.com/go-chi/chi"
 )
 
@@ -24,6 +25,7 @@ func (h *Handler) register(w http.ResponseWriter, r *http.Request) {
 	}
 
 	err = h.userService.Register(user)
+	h.audit(r, core.Principal{}, core.AuditEvent{Actor: user.Login, Action: core.ActionRegister}, err)
 	if err != nil {
 		h.sendErrorResponse(w, err)
 
@@ -53,7 +55,8 @@ func (h *Handler) auth(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token, err := h.userService.Auth(user)
+	result, err := h.userService.Auth(user, client(r))
+	h.audit(r, core.Principal{}, core.AuditEvent{Actor: user.Login, Action: core.ActionAuth}, err)
 	if err != nil {
 		h.sendErrorResponse(w, err)
 
@@ -61,20 +64,52 @@ func (h *Handler) auth(w http.ResponseWriter, r *http.Request) {
 	}
 
 	res := core.Response{}
+	res.Response = result
 
-	res.Response = struct {
-		Token string `json:"token"`
-	}{
-		Token: token,
+	h.sendResponse(w, res, http.StatusOK)
+}
+
+// refresh exchanges a refresh token for a new token pair. Every refresh token
+// can be used once.
+func (h *Handler) refresh(w http.ResponseWriter, r *http.Request) {
+	body := r.Body
+	defer h.closeRequestBody(body)
+
+	var request core.RefreshRequest
+
+	err := json.NewDecoder(body).Decode(&request)
+	if err != nil {
+		h.sendErrorResponse(w, e.ErrEmptyBody)
+
+		return
 	}
 
+	tokens, err := h.userService.Refresh(request.RefreshToken, client(r))
+	if err != nil {
+		h.audit(r, core.Principal{}, core.AuditEvent{Action: core.ActionRefresh}, err)
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	principal, _ := h.userService.Authenticate(tokens.AccessToken, client(r))
+	h.audit(r, principal, core.AuditEvent{Action: core.ActionRefresh}, nil)
+
+	res := core.Response{}
+	res.Response = tokens
+
 	h.sendResponse(w, res, http.StatusOK)
 }
 
 func (h *Handler) logout(w http.ResponseWriter, r *http.Request) {
 	token := chi.URLParam(r, "token")
 
+	// the principal is resolved before the token is deleted, so the event
+	// keeps its actor
+	principal, _ := h.userService.Authenticate(token, client(r))
+
 	err := h.userService.Logout(token)
+	h.audit(r, principal, core.AuditEvent{Action: core.ActionLogout}, err)
 	if err != nil {
 		h.sendErrorResponse(w, err)
 
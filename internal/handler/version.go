@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/GroVlAn/doc-store/internal/core"
+	"github.com/GroVlAn/doc-store/internal/core/e"
+	"github.com/go-chi/chi"
+)
+
+func (h *Handler) documentVersions(w http.ResponseWriter, r *http.Request) {
+	docID := chi.URLParam(r, "docID")
+
+	token, err := h.tokenFromBody(r)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	principal, err := h.principal(r, token)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	versions, err := h.documentService.DocumentVersions(principal, docID)
+	h.audit(r, principal, core.AuditEvent{Action: core.ActionDocumentRead, DocumentID: docID}, err)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	res := core.Response{}
+	res.Data = struct {
+		Versions []core.DocumentVersion `json:"versions"`
+	}{
+		Versions: versions,
+	}
+
+	h.sendResponse(w, res, http.StatusOK)
+}
+
+// diffDocument compares two revisions of a document, "from" and "to" default
+// to the previous and the current revision.
+func (h *Handler) diffDocument(w http.ResponseWriter, r *http.Request) {
+	docID := chi.URLParam(r, "docID")
+
+	body := r.Body
+	defer h.closeRequestBody(body)
+
+	var request core.DiffRequest
+
+	if err := decodeBody(body, &request); err != nil {
+		h.sendErrorResponse(w, e.ErrEmptyBody)
+
+		return
+	}
+
+	principal, err := h.principal(r, request.Token)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	documentDiff, err := h.documentService.DiffDocument(principal, docID, request.From, request.To)
+	h.audit(r, principal, core.AuditEvent{Action: core.ActionDocumentRead, DocumentID: docID}, err)
+	if err != nil {
+		h.sendErrorResponse(w, err)
+
+		return
+	}
+
+	res := core.Response{}
+	res.Data = documentDiff
+
+	h.sendResponse(w, res, http.StatusOK)
+}
@@ -0,0 +1,165 @@
+// Package keyring holds the keys access tokens are signed with. The newest
+// key signs new tokens, older keys are kept to verify tokens until they
+// retire.
+package keyring
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+const (
+	AlgorithmHS256 = "HS256"
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+
+	rsaKeySize = 2048
+	pemType    = "PRIVATE KEY"
+)
+
+var ErrUnsupportedAlgorithm = errors.New("unsupported signing algorithm")
+
+type Key struct {
+	ID        string
+	Algorithm string
+	Private   crypto.Signer
+	Created   time.Time
+	ActiveAt  time.Time
+	RetiresAt time.Time
+}
+
+func (k Key) Public() crypto.PublicKey {
+	return k.Private.Public()
+}
+
+func (k Key) Method() jwt.SigningMethod {
+	return Method(k.Algorithm)
+}
+
+// Method returns the JWT signing method of the algorithm or nil.
+func Method(algorithm string) jwt.SigningMethod {
+	switch algorithm {
+	case AlgorithmHS256:
+		return jwt.SigningMethodHS256
+	case AlgorithmRS256:
+		return jwt.SigningMethodRS256
+	case AlgorithmEdDSA:
+		return jwt.SigningMethodEdDSA
+	default:
+		return nil
+	}
+}
+
+// Ring is safe for concurrent use.
+type Ring struct {
+	mu   sync.RWMutex
+	keys []Key
+}
+
+func New() *Ring {
+	return &Ring{}
+}
+
+// Set replaces the keys of the ring.
+func (r *Ring) Set(keys []Key) {
+	keys = append([]Key(nil), keys...)
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].Created.After(keys[j].Created)
+	})
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.keys = keys
+}
+
+// Signing returns the newest active key. A new key is published for
+// verification before it becomes active, so every instance knows it by then.
+func (r *Ring) Signing() (Key, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := time.Now()
+
+	for _, key := range r.keys {
+		if !key.ActiveAt.After(now) {
+			return key, true
+		}
+	}
+
+	return Key{}, false
+}
+
+func (r *Ring) Key(id string) (Key, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, key := range r.keys {
+		if key.ID == id {
+			return key, true
+		}
+	}
+
+	return Key{}, false
+}
+
+func (r *Ring) Keys() []Key {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append([]Key(nil), r.keys...)
+}
+
+// Generate creates a private key for the algorithm.
+func Generate(algorithm string) (crypto.Signer, error) {
+	switch algorithm {
+	case AlgorithmRS256:
+		return rsa.GenerateKey(rand.Reader, rsaKeySize)
+	case AlgorithmEdDSA:
+		_, private, err := ed25519.GenerateKey(rand.Reader)
+
+		return private, err
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedAlgorithm, algorithm)
+	}
+}
+
+// MarshalPrivate encodes the private key as a PKCS #8 PEM block.
+func MarshalPrivate(private crypto.Signer) ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling private key: %w", err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: pemType, Bytes: der}), nil
+}
+
+func ParsePrivate(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != pemType {
+		return nil, errors.New("invalid private key block")
+	}
+
+	private, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parsing private key: %w", err)
+	}
+
+	signer, ok := private.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("private key can not sign")
+	}
+
+	return signer, nil
+}
@@ -0,0 +1,28 @@
+// Package notify delivers notifications to users.
+package notify
+
+import (
+	"context"
+
+	"github.com/GroVlAn/doc-store/internal/core"
+	"github.com/rs/zerolog"
+)
+
+// Log writes notifications to the log instead of sending them, it is meant
+// for development only since reset tokens end up in the log.
+type Log struct {
+	l zerolog.Logger
+}
+
+func NewLog(l zerolog.Logger) *Log {
+	return &Log{l: l}
+}
+
+func (n *Log) Notify(_ context.Context, notification core.Notification) error {
+	n.l.Info().
+		Str("to", notification.To).
+		Str("subject", notification.Subject).
+		Msg(notification.Body)
+
+	return nil
+}
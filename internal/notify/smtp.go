@@ -0,0 +1,129 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/GroVlAn/doc-store/internal/core"
+)
+
+const defaultSMTPTimeout = 10 * time.Second
+
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	Timeout  time.Duration
+}
+
+// SMTP sends notifications as plain text mail. STARTTLS is used when the
+// server offers it, credentials are only sent over TLS or to localhost.
+type SMTP struct {
+	cfg SMTPConfig
+}
+
+func NewSMTP(cfg SMTPConfig) *SMTP {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultSMTPTimeout
+	}
+
+	return &SMTP{cfg: cfg}
+}
+
+func (n *SMTP) Notify(ctx context.Context, notification core.Notification) error {
+	to, err := mail.ParseAddress(notification.To)
+	if err != nil {
+		return fmt.Errorf("parsing recipient: %w", err)
+	}
+
+	from, err := mail.ParseAddress(n.cfg.From)
+	if err != nil {
+		return fmt.Errorf("parsing sender: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, n.cfg.Timeout)
+	defer cancel()
+
+	dialer := &net.Dialer{}
+
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(n.cfg.Host, fmt.Sprint(n.cfg.Port)))
+	if err != nil {
+		return fmt.Errorf("connecting to smtp server: %w", err)
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, n.cfg.Host)
+	if err != nil {
+		conn.Close()
+
+		return fmt.Errorf("creating smtp client: %w", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: n.cfg.Host}); err != nil {
+			return fmt.Errorf("starting tls: %w", err)
+		}
+	}
+
+	if len(n.cfg.Username) > 0 {
+		auth := smtp.PlainAuth("", n.cfg.Username, n.cfg.Password, n.cfg.Host)
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("authenticating: %w", err)
+		}
+	}
+
+	if err := client.Mail(from.Address); err != nil {
+		return fmt.Errorf("setting sender: %w", err)
+	}
+
+	if err := client.Rcpt(to.Address); err != nil {
+		return fmt.Errorf("setting recipient: %w", err)
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("starting message: %w", err)
+	}
+
+	if _, err := w.Write(message(from, to, notification)); err != nil {
+		w.Close()
+
+		return fmt.Errorf("writing message: %w", err)
+	}
+
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("sending message: %w", err)
+	}
+
+	return client.Quit()
+}
+
+func message(from *mail.Address, to *mail.Address, notification core.Notification) []byte {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "From: %s\r\n", from.String())
+	fmt.Fprintf(&buf, "To: %s\r\n", to.String())
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", notification.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("\r\n")
+	buf.WriteString(strings.ReplaceAll(notification.Body, "\n", "\r\n"))
+	buf.WriteString("\r\n")
+
+	return buf.Bytes()
+}
@@ -0,0 +1,111 @@
+// Package oidc is an OpenID Connect relying party using the authorization
+// code flow with PKCE.
+package oidc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+var ErrNoIDToken = errors.New("token response has no id_token")
+
+type Config struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+// Client discovers the provider on first use, so the service starts even
+// when the identity provider is not reachable yet.
+type Client struct {
+	cfg Config
+
+	mu       sync.Mutex
+	oauth    *oauth2.Config
+	verifier *oidc.IDTokenVerifier
+}
+
+func New(cfg Config) *Client {
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{oidc.ScopeOpenID, "profile", "email"}
+	}
+
+	return &Client{cfg: cfg}
+}
+
+// AuthURL returns the URL of the provider the user is redirected to.
+func (c *Client) AuthURL(ctx context.Context, state string, nonce string, verifier string) (string, error) {
+	oauth, _, err := c.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return oauth.AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(verifier)), nil
+}
+
+// Exchange redeems the authorization code and returns the claims of the
+// verified ID token.
+func (c *Client) Exchange(ctx context.Context, code string, verifier string, nonce string) (map[string]any, error) {
+	oauth, idVerifier, err := c.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := oauth.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return nil, fmt.Errorf("exchanging code: %w", err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, ErrNoIDToken
+	}
+
+	idToken, err := idVerifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("verifying id token: %w", err)
+	}
+
+	if idToken.Nonce != nonce {
+		return nil, errors.New("id token nonce does not match")
+	}
+
+	claims := map[string]any{}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("decoding id token claims: %w", err)
+	}
+
+	return claims, nil
+}
+
+func (c *Client) discover(ctx context.Context) (*oauth2.Config, *oidc.IDTokenVerifier, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.oauth != nil {
+		return c.oauth, c.verifier, nil
+	}
+
+	provider, err := oidc.NewProvider(ctx, c.cfg.Issuer)
+	if err != nil {
+		return nil, nil, fmt.Errorf("discovering provider %s: %w", c.cfg.Issuer, err)
+	}
+
+	c.oauth = &oauth2.Config{
+		ClientID:     c.cfg.ClientID,
+		ClientSecret: c.cfg.ClientSecret,
+		RedirectURL:  c.cfg.RedirectURL,
+		Endpoint:     provider.Endpoint(),
+		Scopes:       c.cfg.Scopes,
+	}
+	c.verifier = provider.Verifier(&oidc.Config{ClientID: c.cfg.ClientID})
+
+	return c.oauth, c.verifier, nil
+}
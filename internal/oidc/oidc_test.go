@@ -0,0 +1,343 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"golang.org/x/oauth2"
+)
+
+const (
+	testClientID     = "doc-store"
+	testClientSecret = "client-secret"
+	testKeyID        = "test-key"
+)
+
+// mockProvider is a local OpenID provider serving discovery, its JWKS and a
+// token endpoint which checks the PKCE verifier of the code.
+type mockProvider struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mu     sync.Mutex
+	grants map[string]grant
+}
+
+type grant struct {
+	challenge string
+	claims    jwt.MapClaims
+	// signer overrides the key of the provider, e.g. with one not in the JWKS
+	signer *rsa.PrivateKey
+}
+
+func newMockProvider(t *testing.T) *mockProvider {
+	t.Helper()
+
+	p := &mockProvider{key: newKey(t), grants: make(map[string]grant)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", p.discovery)
+	mux.HandleFunc("GET /keys", p.keys)
+	mux.HandleFunc("POST /token", p.token)
+
+	p.server = httptest.NewServer(mux)
+	t.Cleanup(p.server.Close)
+
+	return p
+}
+
+func newKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+
+	return key
+}
+
+func (p *mockProvider) discovery(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"issuer":                                p.server.URL,
+		"authorization_endpoint":                p.server.URL + "/authorize",
+		"token_endpoint":                        p.server.URL + "/token",
+		"jwks_uri":                              p.server.URL + "/keys",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+	})
+}
+
+func (p *mockProvider) keys(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"kid": testKeyID,
+			"n":   base64.RawURLEncoding.EncodeToString(p.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(p.key.E)).Bytes()),
+		}},
+	})
+}
+
+func (p *mockProvider) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+
+		return
+	}
+
+	clientID, clientSecret, ok := r.BasicAuth()
+	if !ok {
+		clientID, clientSecret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if clientID != testClientID || clientSecret != testClientSecret {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+
+		return
+	}
+
+	p.mu.Lock()
+	g, ok := p.grants[r.PostForm.Get("code")]
+	delete(p.grants, r.PostForm.Get("code"))
+	p.mu.Unlock()
+
+	if !ok || s256(r.PostForm.Get("code_verifier")) != g.challenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+
+		return
+	}
+
+	signer := p.key
+	if g.signer != nil {
+		signer = g.signer
+	}
+
+	idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, g.claims)
+	idToken.Header["kid"] = testKeyID
+
+	rawIDToken, err := idToken.SignedString(signer)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token": "access-token",
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     rawIDToken,
+	})
+}
+
+// authorize plays the user signing in at the provider: it reads the
+// authorization request and returns the code the browser is redirected with.
+func (p *mockProvider) authorize(t *testing.T, authURL string, edit func(claims jwt.MapClaims, g *grant)) string {
+	t.Helper()
+
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatalf("parsing auth url: %v", err)
+	}
+
+	query := u.Query()
+	if method := query.Get("code_challenge_method"); method != "S256" {
+		t.Fatalf("code_challenge_method = %q, want S256", method)
+	}
+	if clientID := query.Get("client_id"); clientID != testClientID {
+		t.Fatalf("client_id = %q, want %q", clientID, testClientID)
+	}
+
+	now := time.Now()
+	g := grant{
+		challenge: query.Get("code_challenge"),
+		claims: jwt.MapClaims{
+			"iss":                p.server.URL,
+			"sub":                "subject-1",
+			"aud":                testClientID,
+			"iat":                now.Unix(),
+			"exp":                now.Add(time.Hour).Unix(),
+			"nonce":              query.Get("nonce"),
+			"preferred_username": "alice",
+			"email":              "alice@example.org",
+			"email_verified":     true,
+		},
+	}
+	if edit != nil {
+		edit(g.claims, &g)
+	}
+
+	code := query.Get("state") + "-code"
+
+	p.mu.Lock()
+	p.grants[code] = g
+	p.mu.Unlock()
+
+	return code
+}
+
+func (p *mockProvider) client() *Client {
+	return New(Config{
+		Issuer:       p.server.URL,
+		ClientID:     testClientID,
+		ClientSecret: testClientSecret,
+		RedirectURL:  "http://localhost:8080/api/auth/oidc/callback",
+	})
+}
+
+func s256(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func TestAuthURL(t *testing.T) {
+	p := newMockProvider(t)
+	verifier := oauth2.GenerateVerifier()
+
+	authURL, err := p.client().AuthURL(context.Background(), "state-1", "nonce-1", verifier)
+	if err != nil {
+		t.Fatalf("AuthURL() error = %v", err)
+	}
+
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatalf("parsing auth url: %v", err)
+	}
+
+	query := u.Query()
+	want := map[string]string{
+		"state":                 "state-1",
+		"nonce":                 "nonce-1",
+		"code_challenge":        s256(verifier),
+		"code_challenge_method": "S256",
+		"response_type":         "code",
+	}
+	for key, value := range want {
+		if got := query.Get(key); got != value {
+			t.Errorf("%s = %q, want %q", key, got, value)
+		}
+	}
+
+	if u.Path != "/authorize" {
+		t.Errorf("path = %q, want the discovered authorization endpoint", u.Path)
+	}
+}
+
+func TestExchange(t *testing.T) {
+	p := newMockProvider(t)
+	c := p.client()
+	verifier := oauth2.GenerateVerifier()
+
+	authURL, err := c.AuthURL(context.Background(), "state-1", "nonce-1", verifier)
+	if err != nil {
+		t.Fatalf("AuthURL() error = %v", err)
+	}
+
+	code := p.authorize(t, authURL, nil)
+
+	claims, err := c.Exchange(context.Background(), code, verifier, "nonce-1")
+	if err != nil {
+		t.Fatalf("Exchange() error = %v", err)
+	}
+
+	if claims["sub"] != "subject-1" || claims["preferred_username"] != "alice" || claims["email_verified"] != true {
+		t.Errorf("claims = %v", claims)
+	}
+}
+
+func TestExchangeRejects(t *testing.T) {
+	otherKey := newKey(t)
+
+	tests := []struct {
+		name     string
+		edit     func(claims jwt.MapClaims, g *grant)
+		verifier func(verifier string) string
+		nonce    string
+	}{
+		{
+			name:     "wrong pkce verifier",
+			verifier: func(string) string { return oauth2.GenerateVerifier() },
+		},
+		{
+			name:  "other nonce",
+			nonce: "nonce-2",
+		},
+		{
+			name: "other audience",
+			edit: func(claims jwt.MapClaims, _ *grant) { claims["aud"] = "other-client" },
+		},
+		{
+			name: "other issuer",
+			edit: func(claims jwt.MapClaims, _ *grant) { claims["iss"] = "https://issuer.example.org" },
+		},
+		{
+			name: "expired",
+			edit: func(claims jwt.MapClaims, _ *grant) { claims["exp"] = time.Now().Add(-time.Hour).Unix() },
+		},
+		{
+			name: "unknown key",
+			edit: func(_ jwt.MapClaims, g *grant) { g.signer = otherKey },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newMockProvider(t)
+			c := p.client()
+			verifier := oauth2.GenerateVerifier()
+
+			authURL, err := c.AuthURL(context.Background(), "state-1", "nonce-1", verifier)
+			if err != nil {
+				t.Fatalf("AuthURL() error = %v", err)
+			}
+
+			code := p.authorize(t, authURL, tt.edit)
+
+			if tt.verifier != nil {
+				verifier = tt.verifier(verifier)
+			}
+
+			nonce := "nonce-1"
+			if len(tt.nonce) > 0 {
+				nonce = tt.nonce
+			}
+
+			if _, err := c.Exchange(context.Background(), code, verifier, nonce); err == nil {
+				t.Error("Exchange() succeeded")
+			}
+		})
+	}
+}
+
+func TestDiscoveryFailure(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	c := New(Config{Issuer: server.URL, ClientID: testClientID})
+
+	if _, err := c.AuthURL(context.Background(), "state-1", "nonce-1", oauth2.GenerateVerifier()); err == nil {
+		t.Error("AuthURL() succeeded without a discovery document")
+	}
+}
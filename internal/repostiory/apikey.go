@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/GroVlAn/doc-store/internal/core"
+	"github.com/GroVlAn/doc-store/internal/core/e"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+func (r *Repository) CreateAPIKey(ctx context.Context, key core.APIKey) error {
+	_, err := r.apiKeyCollection.InsertOne(ctx, key)
+	if err != nil {
+		return &e.ErrInsert{Msg: "failed create api key", Err: err}
+	}
+
+	return nil
+}
+
+func (r *Repository) APIKeyByHash(ctx context.Context, hash string) (core.APIKey, error) {
+	filter := bson.M{"hash": hash}
+
+	var key core.APIKey
+
+	err := r.apiKeyCollection.FindOne(ctx, filter).Decode(&key)
+	switch {
+	case err != nil && errors.Is(err, mongo.ErrNoDocuments):
+		return core.APIKey{}, e.ErrAPIKeyNotFound
+	case err != nil:
+		return core.APIKey{}, &e.ErrFind{Msg: "failed to find api key", Err: err}
+	default:
+		return key, nil
+	}
+}
+
+func (r *Repository) APIKeys(ctx context.Context, userID string) ([]core.APIKey, error) {
+	filter := bson.M{"user_id": userID}
+
+	findOptions := options.Find()
+	findOptions.SetSort(bson.D{{Key: "created", Value: 1}})
+
+	cursor, err := r.apiKeyCollection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, &e.ErrFind{Msg: "failed find api keys", Err: err}
+	}
+	defer cursor.Close(ctx)
+
+	var keys []core.APIKey
+
+	if err := cursor.All(ctx, &keys); err != nil {
+		return nil, &e.ErrFind{Msg: "failed find api keys", Err: err}
+	}
+
+	return keys, nil
+}
+
+func (r *Repository) DeleteAPIKey(ctx context.Context, userID string, keyID string) error {
+	filter := bson.M{
+		"_id":     keyID,
+		"user_id": userID,
+	}
+
+	res, err := r.apiKeyCollection.DeleteOne(ctx, filter)
+	if err != nil {
+		return &e.ErrDelete{Msg: "failed delete api key", Err: err}
+	}
+	if res.DeletedCount == 0 {
+		return e.ErrAPIKeyNotFound
+	}
+
+	return nil
+}
+
+func (r *Repository) TouchAPIKey(ctx context.Context, keyID string, lastUsed time.Time) error {
+	filter := bson.M{"_id": keyID}
+
+	update := bson.M{
+		"$set": bson.M{"last_used": lastUsed},
+	}
+
+	_, err := r.apiKeyCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return &e.ErrInsert{Msg: "failed update api key", Err: err}
+	}
+
+	return nil
+}
+
+func (r *Repository) DeleteUserAPIKeys(ctx context.Context, userID string) error {
+	_, err := r.apiKeyCollection.DeleteMany(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return &e.ErrDelete{Msg: "failed delete api keys", Err: err}
+	}
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/GroVlAn/doc-store/internal/core"
+	"github.com/GroVlAn/doc-store/internal/core/e"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// LoginAttempts returns the unexpired records of the keys, keys without
+// failures are left out.
+func (r *Repository) LoginAttempts(ctx context.Context, keys []string, now time.Time) ([]core.LoginAttempt, error) {
+	filter := bson.M{
+		"_id":        bson.M{"$in": keys},
+		"expires_at": bson.M{"$gt": now},
+	}
+
+	cursor, err := r.attemptsCollection.Find(ctx, filter)
+	if err != nil {
+		return nil, &e.ErrFind{Msg: "failed to find login attempts", Err: err}
+	}
+
+	var attempts []core.LoginAttempt
+
+	if err := cursor.All(ctx, &attempts); err != nil {
+		return nil, &e.ErrFind{Msg: "failed to decode login attempts", Err: err}
+	}
+
+	return attempts, nil
+}
+
+// FailLogin counts a failed login of the key and keeps the record until
+// expiresAt, an expired record starts counting again.
+func (r *Repository) FailLogin(ctx context.Context, key string, now time.Time, expiresAt time.Time) (core.LoginAttempt, error) {
+	filter := bson.M{"_id": key}
+
+	update := bson.A{
+		bson.M{"$set": bson.M{
+			"failures": bson.M{"$cond": bson.A{
+				bson.M{"$gt": bson.A{"$expires_at", now}},
+				bson.M{"$add": bson.A{"$failures", 1}},
+				1,
+			}},
+			"last_failure": now,
+			"expires_at":   expiresAt,
+		}},
+	}
+
+	var attempt core.LoginAttempt
+
+	err := r.attemptsCollection.FindOneAndUpdate(
+		ctx,
+		filter,
+		update,
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&attempt)
+	if err != nil {
+		return core.LoginAttempt{}, &e.ErrInsert{Msg: "failed save login attempt", Err: err}
+	}
+
+	return attempt, nil
+}
+
+// ReleaseLoginAttempt takes back one failure of the key, a reserved attempt
+// that turned out not to be a failed login.
+func (r *Repository) ReleaseLoginAttempt(ctx context.Context, key string) error {
+	filter := bson.M{"_id": key, "failures": bson.M{"$gt": 0}}
+
+	_, err := r.attemptsCollection.UpdateOne(ctx, filter, bson.M{"$inc": bson.M{"failures": -1}})
+	if err != nil {
+		return &e.ErrInsert{Msg: "failed release login attempt", Err: err}
+	}
+
+	return nil
+}
+
+func (r *Repository) ClearLoginAttempts(ctx context.Context, key string) error {
+	_, err := r.attemptsCollection.DeleteOne(ctx, bson.M{"_id": key})
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return &e.ErrDelete{Msg: "failed delete login attempts", Err: err}
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/GroVlAn/doc-store/internal/core"
+	"github.com/GroVlAn/doc-store/internal/core/e"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+func (r *Repository) CreateAuditEvent(ctx context.Context, event core.AuditEvent) error {
+	_, err := r.auditCollection.InsertOne(ctx, event)
+	if err != nil {
+		return &e.ErrInsert{Msg: "failed create audit event", Err: err}
+	}
+
+	return nil
+}
+
+func (r *Repository) AuditEvents(ctx context.Context, af core.AuditFilter) ([]core.AuditEvent, error) {
+	filter := bson.M{}
+
+	if len(af.Actor) > 0 {
+		filter["actor"] = af.Actor
+	}
+
+	if len(af.Action) > 0 {
+		filter["action"] = af.Action
+	}
+
+	if len(af.DocumentID) > 0 {
+		filter["document_id"] = af.DocumentID
+	}
+
+	if af.From != nil || af.To != nil {
+		period := bson.M{}
+		if af.From != nil {
+			period["$gte"] = *af.From
+		}
+		if af.To != nil {
+			period["$lte"] = *af.To
+		}
+		filter["time"] = period
+	}
+
+	findOptions := options.Find()
+	findOptions.SetSort(bson.D{{Key: "time", Value: -1}})
+	findOptions.SetLimit(af.Limit)
+
+	cursor, err := r.auditCollection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, &e.ErrFind{Msg: "failed find audit events", Err: err}
+	}
+	defer cursor.Close(ctx)
+
+	var events []core.AuditEvent
+
+	if err := cursor.All(ctx, &events); err != nil {
+		return nil, &e.ErrFind{Msg: "failed find audit events", Err: err}
+	}
+
+	return events, nil
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/GroVlAn/doc-store/internal/core"
+	"github.com/GroVlAn/doc-store/internal/core/e"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type documentChange struct {
+	OperationType     string         `bson:"operationType"`
+	ClusterTime       bson.Timestamp `bson:"clusterTime"`
+	FullDocument      *core.Document `bson:"fullDocument"`
+	UpdateDescription struct {
+		UpdatedFields bson.M   `bson:"updatedFields"`
+		RemovedFields []string `bson:"removedFields"`
+	} `bson:"updateDescription"`
+}
+
+// WatchDocuments opens a change stream on the document collection. It fails
+// right away when the deployment does not support change streams, e.g. a
+// standalone server without a replica set.
+func (r *Repository) WatchDocuments(ctx context.Context) (<-chan core.DocumentEvent, error) {
+	streamOptions := options.ChangeStream().SetFullDocument(options.UpdateLookup)
+
+	stream, err := r.documentCollection.Watch(ctx, mongo.Pipeline{}, streamOptions)
+	if err != nil {
+		return nil, &e.ErrFind{Msg: "failed watch documents", Err: err}
+	}
+
+	events := make(chan core.DocumentEvent)
+
+	go func() {
+		defer close(events)
+		defer stream.Close(context.Background())
+
+		for stream.Next(ctx) {
+			var change documentChange
+			if err := stream.Decode(&change); err != nil {
+				continue
+			}
+
+			event, ok := change.event()
+			if !ok {
+				continue
+			}
+
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+// event maps the change to a document event. Hard deletes carry no document
+// and so can't be checked against grants, they are skipped.
+func (dc documentChange) event() (core.DocumentEvent, bool) {
+	if dc.FullDocument == nil {
+		return core.DocumentEvent{}, false
+	}
+
+	event := core.DocumentEvent{
+		ID:       uuid.NewString(),
+		Time:     time.Unix(int64(dc.ClusterTime.T), 0),
+		Document: *dc.FullDocument,
+	}
+
+	switch dc.OperationType {
+	case "insert":
+		event.Type = core.EventDocumentCreated
+	case "replace":
+		event.Type = core.EventDocumentReplaced
+	case "update":
+		event.Type = dc.updateEventType()
+	default:
+		return core.DocumentEvent{}, false
+	}
+
+	return event, true
+}
+
+func (dc documentChange) updateEventType() string {
+	if _, ok := dc.UpdateDescription.UpdatedFields["deleted_at"]; ok {
+		return core.EventDocumentDeleted
+	}
+
+	if slices.Contains(dc.UpdateDescription.RemovedFields, "deleted_at") {
+		return core.EventDocumentCreated
+	}
+
+	if _, ok := dc.UpdateDescription.UpdatedFields["grant"]; ok {
+		return core.EventDocumentShared
+	}
+
+	return core.EventDocumentUpdated
+}
@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/GroVlAn/doc-store/internal/core"
+	"github.com/GroVlAn/doc-store/internal/core/e"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+func (r *Repository) CreateComment(ctx context.Context, comment core.Comment) error {
+	_, err := r.commentCollection.InsertOne(ctx, comment)
+	if err != nil {
+		return &e.ErrInsert{Msg: "failed create comment", Err: err}
+	}
+
+	return nil
+}
+
+func (r *Repository) Comment(ctx context.Context, documentID string, commentID string) (core.Comment, error) {
+	filter := bson.M{
+		"_id":         commentID,
+		"document_id": documentID,
+	}
+
+	var comment core.Comment
+
+	err := r.commentCollection.FindOne(ctx, filter).Decode(&comment)
+	switch {
+	case err != nil && errors.Is(err, mongo.ErrNoDocuments):
+		return core.Comment{}, e.ErrCommentNotFound
+	case err != nil:
+		return core.Comment{}, &e.ErrFind{Msg: "failed to find comment", Err: err}
+	default:
+		return comment, nil
+	}
+}
+
+func (r *Repository) Comments(ctx context.Context, documentID string) ([]core.Comment, error) {
+	filter := bson.M{"document_id": documentID}
+
+	findOptions := options.Find()
+	findOptions.SetSort(bson.D{{Key: "created", Value: 1}})
+
+	cursor, err := r.commentCollection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, &e.ErrFind{Msg: "failed find comments", Err: err}
+	}
+	defer cursor.Close(ctx)
+
+	var comments []core.Comment
+
+	if err := cursor.All(ctx, &comments); err != nil {
+		return nil, &e.ErrFind{Msg: "failed find comments", Err: err}
+	}
+
+	return comments, nil
+}
+
+func (r *Repository) UpdateComment(ctx context.Context, commentID string, body string, updated time.Time) error {
+	filter := bson.M{"_id": commentID}
+
+	update := bson.M{
+		"$set": bson.M{
+			"body":    body,
+			"updated": updated,
+		},
+	}
+
+	res, err := r.commentCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return &e.ErrInsert{Msg: "failed update comment", Err: err}
+	}
+	if res.MatchedCount == 0 {
+		return e.ErrCommentNotFound
+	}
+
+	return nil
+}
+
+// ResolveComment marks the comment resolved by the login, an empty login
+// reopens it.
+func (r *Repository) ResolveComment(ctx context.Context, commentID string, login string) error {
+	filter := bson.M{"_id": commentID}
+
+	update := bson.M{
+		"$set": bson.M{"resolved": true, "resolved_by": login},
+	}
+	if len(login) == 0 {
+		update = bson.M{
+			"$set":   bson.M{"resolved": false},
+			"$unset": bson.M{"resolved_by": ""},
+		}
+	}
+
+	res, err := r.commentCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return &e.ErrInsert{Msg: "failed resolve comment", Err: err}
+	}
+	if res.MatchedCount == 0 {
+		return e.ErrCommentNotFound
+	}
+
+	return nil
+}
+
+// DeleteComment deletes the comment together with its replies.
+func (r *Repository) DeleteComment(ctx context.Context, commentID string) error {
+	filter := bson.M{
+		"$or": []bson.M{
+			{"_id": commentID},
+			{"parent_id": commentID},
+		},
+	}
+
+	if _, err := r.commentCollection.DeleteMany(ctx, filter); err != nil {
+		return &e.ErrDelete{Msg: "failed delete comment", Err: err}
+	}
+
+	return nil
+}
+
+func (r *Repository) DeleteComments(ctx context.Context, documentID string) error {
+	filter := bson.M{"document_id": documentID}
+
+	if _, err := r.commentCollection.DeleteMany(ctx, filter); err != nil {
+		return &e.ErrDelete{Msg: "failed delete comments", Err: err}
+	}
+
+	return nil
+}
@@ -1,23 +1,38 @@
 package repository
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
+	"strings"
+
+	"github.com/GroVlAn/doc-store/internal/core/e"
 )
 
 const (
-	filesDirectory = "/files"
+	filesDirectory   = "/files"
+	trashDirectory   = filesDirectory + "/.trash"
+	versionDirectory = filesDirectory + "/.versions"
 )
 
 type FileRepository struct{}
 
 func NewFileRepository() *FileRepository {
 	createFilesDirectory(filesDirectory)
+	createFilesDirectory(trashDirectory)
+	createFilesDirectory(versionDirectory)
 
 	return &FileRepository{}
 }
 
 func (fr *FileRepository) SaveFile(userID string, fileName string, file []byte) error {
+	if !validPathElement(userID) || !validPathElement(fileName) {
+		return e.ErrInvalidName
+	}
+
 	createFilesDirectory(fmt.Sprintf("%s/%s", filesDirectory, userID))
 
 	filePath := fmt.Sprintf("%s/%s/%s", filesDirectory, userID, fileName)
@@ -35,6 +50,25 @@ func (fr *FileRepository) File(userID string, fileName string) (string, error) {
 	return filePath, nil
 }
 
+// OpenFile opens a stored file for reading, the caller closes it.
+func (fr *FileRepository) OpenFile(userID string, fileName string) (io.ReadCloser, fs.FileInfo, error) {
+	filePath := fmt.Sprintf("%s/%s/%s", filesDirectory, userID, fileName)
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("opening file: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+
+		return nil, nil, fmt.Errorf("getting file info: %w", err)
+	}
+
+	return file, info, nil
+}
+
 func (fr *FileRepository) DeleteFile(userID string, fileName string) error {
 	filePath := fmt.Sprintf("%s/%s/%s", filesDirectory, userID, fileName)
 
@@ -51,6 +85,184 @@ func (fr *FileRepository) FileExist(userID string, fileName string) bool {
 	return pathExist(filePath)
 }
 
+// Checksum returns the hex encoded SHA-256 and the size of a stored file.
+func (fr *FileRepository) Checksum(userID string, fileName string) (string, int64, error) {
+	filePath := fmt.Sprintf("%s/%s/%s", filesDirectory, userID, fileName)
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", 0, fmt.Errorf("opening file: %w", err)
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return "", 0, fmt.Errorf("reading file: %w", err)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), size, nil
+}
+
+// TrashFile moves a file out of the user's directory so that a new file with
+// the same name can be uploaded while the document stays in the trash.
+func (fr *FileRepository) TrashFile(userID string, fileName string, documentID string) error {
+	createFilesDirectory(fmt.Sprintf("%s/%s", trashDirectory, userID))
+
+	filePath := fmt.Sprintf("%s/%s/%s", filesDirectory, userID, fileName)
+	trashPath := fmt.Sprintf("%s/%s/%s", trashDirectory, userID, documentID)
+
+	if err := os.Rename(filePath, trashPath); err != nil {
+		return fmt.Errorf("moving file to trash: %w", err)
+	}
+
+	return nil
+}
+
+func (fr *FileRepository) RestoreFile(userID string, documentID string, fileName string) error {
+	createFilesDirectory(fmt.Sprintf("%s/%s", filesDirectory, userID))
+
+	trashPath := fmt.Sprintf("%s/%s/%s", trashDirectory, userID, documentID)
+	filePath := fmt.Sprintf("%s/%s/%s", filesDirectory, userID, fileName)
+
+	if err := os.Rename(trashPath, filePath); err != nil {
+		return fmt.Errorf("restoring file from trash: %w", err)
+	}
+
+	return nil
+}
+
+func (fr *FileRepository) DeleteTrashFile(userID string, documentID string) error {
+	trashPath := fmt.Sprintf("%s/%s/%s", trashDirectory, userID, documentID)
+
+	if err := os.Remove(trashPath); err != nil {
+		return fmt.Errorf("removing file from trash: %w", err)
+	}
+
+	return nil
+}
+
+func (fr *FileRepository) TrashFileExist(userID string, documentID string) bool {
+	trashPath := fmt.Sprintf("%s/%s/%s", trashDirectory, userID, documentID)
+
+	return pathExist(trashPath)
+}
+
+// MoveFile moves a file to the directory of another user.
+func (fr *FileRepository) MoveFile(fromUserID string, toUserID string, fileName string, newName string) error {
+	createFilesDirectory(fmt.Sprintf("%s/%s", filesDirectory, toUserID))
+
+	filePath := fmt.Sprintf("%s/%s/%s", filesDirectory, fromUserID, fileName)
+	newPath := fmt.Sprintf("%s/%s/%s", filesDirectory, toUserID, newName)
+
+	if err := os.Rename(filePath, newPath); err != nil {
+		return fmt.Errorf("moving file: %w", err)
+	}
+
+	return nil
+}
+
+func (fr *FileRepository) MoveTrashFile(fromUserID string, toUserID string, documentID string) error {
+	createFilesDirectory(fmt.Sprintf("%s/%s", trashDirectory, toUserID))
+
+	trashPath := fmt.Sprintf("%s/%s/%s", trashDirectory, fromUserID, documentID)
+	newPath := fmt.Sprintf("%s/%s/%s", trashDirectory, toUserID, documentID)
+
+	if err := os.Rename(trashPath, newPath); err != nil {
+		return fmt.Errorf("moving file in trash: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteUserFiles removes the file and trash directories of the user.
+func (fr *FileRepository) DeleteUserFiles(userID string) error {
+	if len(userID) == 0 {
+		return nil
+	}
+
+	for _, dirPath := range []string{
+		fmt.Sprintf("%s/%s", filesDirectory, userID),
+		fmt.Sprintf("%s/%s", trashDirectory, userID),
+	} {
+		if err := os.RemoveAll(dirPath); err != nil {
+			return fmt.Errorf("removing user files: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// SaveVersionFile copies the current file of a document before it is
+// replaced by a new revision.
+func (fr *FileRepository) SaveVersionFile(userID string, fileName string, documentID string, revision int64) error {
+	createFilesDirectory(fmt.Sprintf("%s/%s", versionDirectory, documentID))
+
+	filePath := fmt.Sprintf("%s/%s/%s", filesDirectory, userID, fileName)
+	versionPath := fmt.Sprintf("%s/%s/%d", versionDirectory, documentID, revision)
+
+	src, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("opening file: %w", err)
+	}
+	defer src.Close()
+
+	dst, err := os.Create(versionPath)
+	if err != nil {
+		return fmt.Errorf("creating version file: %w", err)
+	}
+
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+
+		return fmt.Errorf("copying file: %w", err)
+	}
+
+	if err := dst.Close(); err != nil {
+		return fmt.Errorf("closing version file: %w", err)
+	}
+
+	return nil
+}
+
+func (fr *FileRepository) VersionFile(documentID string, revision int64) (string, error) {
+	versionPath := fmt.Sprintf("%s/%s/%d", versionDirectory, documentID, revision)
+
+	return versionPath, nil
+}
+
+func (fr *FileRepository) DeleteVersionFiles(documentID string) error {
+	versionPath := fmt.Sprintf("%s/%s", versionDirectory, documentID)
+
+	if err := os.RemoveAll(versionPath); err != nil {
+		return fmt.Errorf("removing version files: %w", err)
+	}
+
+	return nil
+}
+
+// ReadFile reads a stored file, e.ErrFileTooLarge is returned when it is
+// larger than limit bytes.
+func (fr *FileRepository) ReadFile(filePath string, limit int64) ([]byte, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("opening file: %w", err)
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("reading file: %w", err)
+	}
+
+	if int64(len(data)) > limit {
+		return nil, e.ErrFileTooLarge
+	}
+
+	return data, nil
+}
+
 func createFilesDirectory(dirPath string) {
 	if pathExist(dirPath) {
 		return
@@ -66,3 +278,9 @@ func pathExist(path string) bool {
 
 	return false
 }
+
+// validPathElement reports whether name is a single path element, so the
+// joined path can't leave the directory it is joined to.
+func validPathElement(name string) bool {
+	return len(name) > 0 && name != "." && name != ".." && !strings.ContainsAny(name, "/\\\x00")
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/GroVlAn/doc-store/internal/core"
+	"github.com/GroVlAn/doc-store/internal/core/e"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// LockDocument sets the lock unless another user holds an active one.
+func (r *Repository) LockDocument(ctx context.Context, login string, documentID string, lock core.Lock) error {
+	filter := activeDocument(bson.M{
+		"_id": documentID,
+		"grant": bson.M{
+			"$in": []string{login},
+		},
+	})
+
+	lockFilter := unlockedDocument(filter, login, lock.Created)
+
+	update := bson.M{
+		"$set": bson.M{"lock": lock},
+	}
+
+	res, err := r.documentCollection.UpdateOne(ctx, lockFilter, update)
+	if err != nil {
+		return &e.ErrInsert{Msg: "failed lock document", Err: err}
+	}
+	if res.MatchedCount > 0 {
+		return nil
+	}
+
+	count, err := r.documentCollection.CountDocuments(ctx, filter)
+	if err != nil {
+		return &e.ErrFind{Msg: "failed to find document", Err: err}
+	}
+	if count == 0 {
+		return e.ErrNoDocuments
+	}
+
+	return e.ErrDocumentLocked
+}
+
+// UnlockDocument removes the lock of the document. An empty owner removes
+// any lock, otherwise only a lock held by the owner.
+func (r *Repository) UnlockDocument(ctx context.Context, documentID string, owner string) error {
+	filter := bson.M{"_id": documentID}
+	if len(owner) > 0 {
+		filter["lock.owner"] = owner
+	}
+
+	update := bson.M{
+		"$unset": bson.M{"lock": ""},
+	}
+
+	res, err := r.documentCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return &e.ErrInsert{Msg: "failed unlock document", Err: err}
+	}
+	if res.MatchedCount == 0 {
+		return e.ErrNoDocuments
+	}
+
+	return nil
+}
+
+// unlockedDocument restricts the filter to documents which have no lock, are
+// locked by login or whose lock has expired.
+func unlockedDocument(filter bson.M, login string, now time.Time) bson.M {
+	return bson.M{
+		"$and": []bson.M{filter, {
+			"$or": []bson.M{
+				{"lock": bson.M{"$exists": false}},
+				{"lock.expires_at": bson.M{"$lte": now}},
+				{"lock.owner": login},
+			},
+		}},
+	}
+}
+
+// lockedNotMatched is the error of a write restricted by unlockedDocument
+// which matched no document, filter is the one without the lock condition.
+func (r *Repository) lockedNotMatched(ctx context.Context, filter bson.M, revision *int64) error {
+	count, err := r.documentCollection.CountDocuments(ctx, filter)
+	if err != nil {
+		return &e.ErrFind{Msg: "failed to find document", Err: err}
+	}
+	if count > 0 {
+		return e.ErrDocumentLocked
+	}
+
+	return notMatched(revision)
+}
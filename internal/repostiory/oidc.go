@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/GroVlAn/doc-store/internal/core"
+	"github.com/GroVlAn/doc-store/internal/core/e"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+func (r *Repository) CreateOIDCState(ctx context.Context, state core.OIDCState) error {
+	_, err := r.oidcStateCollection.InsertOne(ctx, state)
+	if err != nil {
+		return &e.ErrInsert{Msg: "failed create oidc state", Err: err}
+	}
+
+	return nil
+}
+
+// TakeOIDCState removes and returns an unexpired state, so every state can
+// complete one login.
+func (r *Repository) TakeOIDCState(ctx context.Context, state string, now time.Time) (core.OIDCState, error) {
+	filter := bson.M{
+		"_id":        state,
+		"expires_at": bson.M{"$gt": now},
+	}
+
+	var oidcState core.OIDCState
+
+	err := r.oidcStateCollection.FindOneAndDelete(ctx, filter).Decode(&oidcState)
+	switch {
+	case err != nil && errors.Is(err, mongo.ErrNoDocuments):
+		return core.OIDCState{}, e.ErrOIDCFailed
+	case err != nil:
+		return core.OIDCState{}, &e.ErrFind{Msg: "failed to find oidc state", Err: err}
+	default:
+		return oidcState, nil
+	}
+}
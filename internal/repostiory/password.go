@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/GroVlAn/doc-store/internal/core"
+	"github.com/GroVlAn/doc-store/internal/core/e"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+func (r *Repository) CreatePasswordReset(ctx context.Context, reset core.PasswordReset) error {
+	_, err := r.resetCollection.InsertOne(ctx, reset)
+	if err != nil {
+		return &e.ErrInsert{Msg: "failed create password reset", Err: err}
+	}
+
+	return nil
+}
+
+// TakePasswordReset removes and returns an unexpired reset, so every reset
+// token works once.
+func (r *Repository) TakePasswordReset(ctx context.Context, resetID string, now time.Time) (core.PasswordReset, error) {
+	filter := bson.M{
+		"_id":        resetID,
+		"expires_at": bson.M{"$gt": now},
+	}
+
+	var reset core.PasswordReset
+
+	err := r.resetCollection.FindOneAndDelete(ctx, filter).Decode(&reset)
+	switch {
+	case err != nil && errors.Is(err, mongo.ErrNoDocuments):
+		return core.PasswordReset{}, e.ErrInvalidResetToken
+	case err != nil:
+		return core.PasswordReset{}, &e.ErrFind{Msg: "failed to find password reset", Err: err}
+	default:
+		return reset, nil
+	}
+}
+
+func (r *Repository) DeletePasswordResets(ctx context.Context, userID string) error {
+	_, err := r.resetCollection.DeleteMany(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return &e.ErrDelete{Msg: "failed delete password resets", Err: err}
+	}
+
+	return nil
+}
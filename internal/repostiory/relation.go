@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/GroVlAn/doc-store/internal/core"
+	"github.com/GroVlAn/doc-store/internal/core/e"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+func (r *Repository) CreateRelation(ctx context.Context, relation core.Relation) error {
+	_, err := r.relationCollection.InsertOne(ctx, relation)
+	if mongo.IsDuplicateKeyError(err) {
+		return e.ErrRelationExist
+	}
+	if err != nil {
+		return &e.ErrInsert{Msg: "failed create relation", Err: err}
+	}
+
+	return nil
+}
+
+func (r *Repository) Relation(ctx context.Context, relationID string) (core.Relation, error) {
+	filter := bson.M{"_id": relationID}
+
+	var relation core.Relation
+
+	err := r.relationCollection.FindOne(ctx, filter).Decode(&relation)
+	switch {
+	case err != nil && errors.Is(err, mongo.ErrNoDocuments):
+		return core.Relation{}, e.ErrRelationNotFound
+	case err != nil:
+		return core.Relation{}, &e.ErrFind{Msg: "failed to find relation", Err: err}
+	default:
+		return relation, nil
+	}
+}
+
+// Relations finds the relations in which the document is the source or the
+// target.
+func (r *Repository) Relations(ctx context.Context, documentID string) ([]core.Relation, error) {
+	filter := documentRelations(documentID)
+
+	findOptions := options.Find()
+	findOptions.SetSort(bson.D{{Key: "created", Value: 1}})
+
+	cursor, err := r.relationCollection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, &e.ErrFind{Msg: "failed find relations", Err: err}
+	}
+	defer cursor.Close(ctx)
+
+	var relations []core.Relation
+
+	if err := cursor.All(ctx, &relations); err != nil {
+		return nil, &e.ErrFind{Msg: "failed find relations", Err: err}
+	}
+
+	return relations, nil
+}
+
+func (r *Repository) DeleteRelation(ctx context.Context, relationID string) error {
+	filter := bson.M{"_id": relationID}
+
+	res, err := r.relationCollection.DeleteOne(ctx, filter)
+	if err != nil {
+		return &e.ErrDelete{Msg: "failed delete relation", Err: err}
+	}
+	if res.DeletedCount == 0 {
+		return e.ErrRelationNotFound
+	}
+
+	return nil
+}
+
+// DeleteRelations deletes the relations from and to the document.
+func (r *Repository) DeleteRelations(ctx context.Context, documentID string) error {
+	if _, err := r.relationCollection.DeleteMany(ctx, documentRelations(documentID)); err != nil {
+		return &e.ErrDelete{Msg: "failed delete relations", Err: err}
+	}
+
+	return nil
+}
+
+func documentRelations(documentID string) bson.M {
+	return bson.M{
+		"$or": []bson.M{
+			{"source_id": documentID},
+			{"target_id": documentID},
+		},
+	}
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/GroVlAn/doc-store/internal/core"
 	"github.com/GroVlAn/doc-store/internal/core/e"
@@ -12,28 +13,133 @@ import (
 )
 
 const (
-	databaseName       = "documents_db"
-	userCollection     = "user"
-	tokenCollection    = "token"
-	documentCollection = "document"
+	databaseName        = "documents_db"
+	userCollection      = "user"
+	tokenCollection     = "token"
+	documentCollection  = "document"
+	retentionCollection = "retention_policy"
+	auditCollection     = "audit"
+	webhookCollection   = "webhook"
+	outboxCollection    = "webhook_outbox"
+	attemptCollection   = "webhook_delivery"
+	versionCollection   = "document_version"
+	commentCollection   = "comment"
+	relationCollection  = "relation"
+	apiKeyCollection    = "api_key"
+	signingCollection   = "signing_key"
+	oidcStateCollection = "oidc_state"
+	challengeCollection = "auth_challenge"
+	settingsCollection  = "settings"
+	resetCollection     = "password_reset"
+	attemptsCollection  = "login_attempt"
 )
 
 type Repository struct {
-	userCollection     *mongo.Collection
-	tokenCollection    *mongo.Collection
-	documentCollection *mongo.Collection
+	client              *mongo.Client
+	userCollection      *mongo.Collection
+	tokenCollection     *mongo.Collection
+	documentCollection  *mongo.Collection
+	retentionCollection *mongo.Collection
+	auditCollection     *mongo.Collection
+	webhookCollection   *mongo.Collection
+	outboxCollection    *mongo.Collection
+	attemptCollection   *mongo.Collection
+	versionCollection   *mongo.Collection
+	commentCollection   *mongo.Collection
+	relationCollection  *mongo.Collection
+	apiKeyCollection    *mongo.Collection
+	signingCollection   *mongo.Collection
+	oidcStateCollection *mongo.Collection
+	challengeCollection *mongo.Collection
+	settingsCollection  *mongo.Collection
+	resetCollection     *mongo.Collection
+	attemptsCollection  *mongo.Collection
 }
 
 func New(client *mongo.Client) *Repository {
 	database := client.Database(databaseName)
 
 	return &Repository{
-		userCollection:     database.Collection(userCollection),
-		tokenCollection:    database.Collection(tokenCollection),
-		documentCollection: database.Collection(documentCollection),
+		client:              client,
+		userCollection:      database.Collection(userCollection),
+		tokenCollection:     database.Collection(tokenCollection),
+		documentCollection:  database.Collection(documentCollection),
+		retentionCollection: database.Collection(retentionCollection),
+		auditCollection:     database.Collection(auditCollection),
+		webhookCollection:   database.Collection(webhookCollection),
+		outboxCollection:    database.Collection(outboxCollection),
+		attemptCollection:   database.Collection(attemptCollection),
+		versionCollection:   database.Collection(versionCollection),
+		commentCollection:   database.Collection(commentCollection),
+		relationCollection:  database.Collection(relationCollection),
+		apiKeyCollection:    database.Collection(apiKeyCollection),
+		signingCollection:   database.Collection(signingCollection),
+		oidcStateCollection: database.Collection(oidcStateCollection),
+		challengeCollection: database.Collection(challengeCollection),
+		settingsCollection:  database.Collection(settingsCollection),
+		resetCollection:     database.Collection(resetCollection),
+		attemptsCollection:  database.Collection(attemptsCollection),
 	}
 }
 
+// CreateIndexes creates the indexes the repository relies on. Tokens are
+// removed by a TTL index once they expire.
+func (r *Repository) CreateIndexes(ctx context.Context) error {
+	_, err := r.tokenCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "end_ttl", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+		{Keys: bson.D{{Key: "token", Value: 1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "family_id", Value: 1}}},
+	})
+	if err != nil {
+		return &e.ErrInsert{Msg: "failed create token indexes", Err: err}
+	}
+
+	_, err = r.apiKeyCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "hash", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return &e.ErrInsert{Msg: "failed create api key indexes", Err: err}
+	}
+
+	_, err = r.oidcStateCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		return &e.ErrInsert{Msg: "failed create oidc state indexes", Err: err}
+	}
+
+	_, err = r.challengeCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		return &e.ErrInsert{Msg: "failed create auth challenge indexes", Err: err}
+	}
+
+	_, err = r.resetCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		return &e.ErrInsert{Msg: "failed create password reset indexes", Err: err}
+	}
+
+	_, err = r.attemptsCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		return &e.ErrInsert{Msg: "failed create login attempt indexes", Err: err}
+	}
+
+	return nil
+}
+
 func (r *Repository) CreateUser(ctx context.Context, user core.User) error {
 	_, err := r.userCollection.InsertOne(ctx, user)
 	if err != nil {
@@ -72,6 +178,89 @@ func (r *Repository) UserByID(ctx context.Context, id string) (core.User, error)
 	return user, nil
 }
 
+func (r *Repository) UserByExternalID(ctx context.Context, externalID string) (core.User, error) {
+	filter := bson.M{"external_id": externalID}
+
+	var user core.User
+
+	err := r.userCollection.FindOne(ctx, filter).Decode(&user)
+	switch {
+	case err != nil && errors.Is(err, mongo.ErrNoDocuments):
+		return core.User{}, e.ErrUserNotFound
+	case err != nil:
+		return core.User{}, &e.ErrFind{Msg: "user not found", Err: err}
+	default:
+		return user, nil
+	}
+}
+
+// LinkExternalID links a user without an external identity to one.
+func (r *Repository) LinkExternalID(ctx context.Context, userID string, externalID string) error {
+	filter := bson.M{
+		"_id":         userID,
+		"external_id": bson.M{"$exists": false},
+	}
+
+	update := bson.M{
+		"$set": bson.M{"external_id": externalID},
+	}
+
+	res, err := r.userCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return &e.ErrInsert{Msg: "failed link user", Err: err}
+	}
+	if res.MatchedCount == 0 {
+		return e.ErrAccountLinked
+	}
+
+	return nil
+}
+
+func (r *Repository) UpdateUserRole(ctx context.Context, userID string, role string) error {
+	filter := bson.M{"_id": userID}
+
+	update := bson.M{
+		"$set": bson.M{"role": role},
+	}
+
+	res, err := r.userCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return &e.ErrInsert{Msg: "failed update user role", Err: err}
+	}
+	if res.MatchedCount == 0 {
+		return e.ErrUserNotFound
+	}
+
+	return nil
+}
+
+func (r *Repository) UpdatePassword(ctx context.Context, userID string, password string) error {
+	filter := bson.M{"_id": userID}
+
+	update := bson.M{
+		"$set": bson.M{"password": password},
+	}
+
+	res, err := r.userCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return &e.ErrInsert{Msg: "failed update password", Err: err}
+	}
+	if res.MatchedCount == 0 {
+		return e.ErrUserNotFound
+	}
+
+	return nil
+}
+
+func (r *Repository) DeleteUser(ctx context.Context, userID string) error {
+	_, err := r.userCollection.DeleteOne(ctx, bson.M{"_id": userID})
+	if err != nil {
+		return &e.ErrDelete{Msg: "failed delete user", Err: err}
+	}
+
+	return nil
+}
+
 func (r *Repository) CreateToken(ctx context.Context, token core.AccessToken) error {
 	_, err := r.tokenCollection.InsertOne(ctx, token)
 	if err != nil {
@@ -105,6 +294,41 @@ func (r *Repository) DeleteToken(ctx context.Context, token string) error {
 	return nil
 }
 
+// UseRefreshToken marks a refresh token as used. A token used before is
+// reported as reused.
+func (r *Repository) UseRefreshToken(ctx context.Context, token string, usedAt time.Time) error {
+	filter := bson.M{
+		"token":   token,
+		"kind":    core.TokenKindRefresh,
+		"used_at": bson.M{"$exists": false},
+	}
+
+	update := bson.M{
+		"$set": bson.M{"used_at": usedAt},
+	}
+
+	res, err := r.tokenCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return &e.ErrInsert{Msg: "failed use refresh token", Err: err}
+	}
+	if res.MatchedCount == 0 {
+		return e.ErrRefreshTokenReused
+	}
+
+	return nil
+}
+
+func (r *Repository) DeleteTokenFamily(ctx context.Context, familyID string) error {
+	filter := bson.M{"family_id": familyID}
+
+	_, err := r.tokenCollection.DeleteMany(ctx, filter)
+	if err != nil {
+		return &e.ErrDelete{Msg: "failed delete token family", Err: err}
+	}
+
+	return nil
+}
+
 func (r *Repository) CreateDocument(ctx context.Context, document core.Document) error {
 	_, err := r.documentCollection.InsertOne(ctx, document)
 	if err != nil {
@@ -115,12 +339,12 @@ func (r *Repository) CreateDocument(ctx context.Context, document core.Document)
 }
 
 func (r *Repository) Document(ctx context.Context, login string, documentID string) (core.Document, error) {
-	filter := bson.M{
+	filter := unexpiredDocument(activeDocument(bson.M{
 		"_id": documentID,
 		"grant": bson.M{
 			"$in": []string{login},
 		},
-	}
+	}), time.Now())
 
 	var document core.Document
 
@@ -132,43 +356,101 @@ func (r *Repository) Document(ctx context.Context, login string, documentID stri
 	return document, nil
 }
 
+// DocumentsList returns the active documents granted to df.Login matching the
+// filter. The grant and the active and unexpired conditions are set last, the
+// key of the filter can't replace them.
 func (r *Repository) DocumentsList(ctx context.Context, df core.DocumentFilter) ([]core.Document, error) {
-	filter := bson.M{
-		"grant": bson.M{
-			"$in": []string{df.Login},
-		},
-	}
+	filter := bson.M{}
 
 	if len(df.Key) > 0 && len(df.Value) > 0 {
 		filter[df.Key] = df.Value
 	}
 
+	for key, value := range df.Attributes {
+		filter[attributeField(key)] = value
+	}
+
+	if len(df.Tag) > 0 {
+		filter["tags"] = df.Tag
+	}
+
+	if len(df.Folder) > 0 {
+		filter["folder"] = df.Folder
+	}
+
+	if len(df.OwnerID) > 0 {
+		filter["owner_id"] = df.OwnerID
+	}
+
+	filter["grant"] = bson.M{
+		"$in": []string{df.Login},
+	}
+	filter = unexpiredDocument(activeDocument(filter), time.Now())
+
 	findOptions := options.Find()
-	findOptions.SetSort(bson.D{{Key: "Name", Value: -1}, {Key: "created", Value: -1}})
+	findOptions.SetSort(documentsSort(df))
 	findOptions.SetLimit(df.Limit)
 
-	cursor, err := r.documentCollection.Find(ctx, filter, findOptions)
-	defer cursor.Close(ctx)
-	if err != nil {
-		return nil, &e.ErrFind{Msg: "failed find documents", Err: err}
+	return r.findDocuments(ctx, filter, findOptions)
+}
+
+func (r *Repository) UpdateDocumentAttributes(
+	ctx context.Context,
+	login string,
+	documentID string,
+	attributes core.Attributes,
+	unset []string,
+	revision *int64,
+) error {
+	filter := revisionDocument(activeDocument(bson.M{
+		"_id": documentID,
+		"grant": bson.M{
+			"$in": []string{login},
+		},
+	}), revision)
+
+	update := bson.M{
+		"$inc": bson.M{"revision": 1},
 	}
 
-	var documentsList []core.Document
+	if len(attributes) > 0 {
+		set := bson.M{}
+		for key, value := range attributes {
+			set[attributeField(key)] = value
+		}
+		update["$set"] = set
+	}
 
-	if err := cursor.All(ctx, &documentsList); err != nil {
-		return nil, &e.ErrFind{Msg: "failed find documents", Err: err}
+	if len(unset) > 0 {
+		remove := bson.M{}
+		for _, key := range unset {
+			remove[attributeField(key)] = ""
+		}
+		update["$unset"] = remove
 	}
 
-	return documentsList, nil
+	if len(update) == 1 {
+		return nil
+	}
+
+	res, err := r.documentCollection.UpdateOne(ctx, unlockedDocument(filter, login, time.Now()), update)
+	if err != nil {
+		return &e.ErrInsert{Msg: "failed update document attributes", Err: err}
+	}
+	if res.MatchedCount == 0 {
+		return r.lockedNotMatched(ctx, filter, revision)
+	}
+
+	return nil
 }
 
 func (r *Repository) DocumentByName(ctx context.Context, login string, name string) (core.Document, error) {
-	filter := bson.M{
+	filter := activeDocument(bson.M{
 		"grant": bson.M{
 			"$in": []string{login},
 		},
 		"name": name,
-	}
+	})
 
 	var document core.Document
 	err := r.documentCollection.FindOne(ctx, filter).Decode(&document)
@@ -182,17 +464,400 @@ func (r *Repository) DocumentByName(ctx context.Context, login string, name stri
 	}
 }
 
-func (r *Repository) DeleteDocument(ctx context.Context, login string, documentID string) error {
+// ReplaceDocument replaces an active document with the same ID if it is
+// still at the given revision.
+// ReplaceDocument writes the document over the stored revision unless another
+// user holds an active lock on it.
+func (r *Repository) ReplaceDocument(ctx context.Context, login string, document core.Document, revision int64) error {
+	filter := revisionDocument(activeDocument(bson.M{"_id": document.ID}), &revision)
+
+	res, err := r.documentCollection.ReplaceOne(ctx, unlockedDocument(filter, login, time.Now()), document)
+	if err != nil {
+		return &e.ErrInsert{Msg: "failed replace document", Err: err}
+	}
+	if res.MatchedCount == 0 {
+		return r.lockedNotMatched(ctx, filter, &revision)
+	}
+
+	return nil
+}
+
+func (r *Repository) TrashDocument(
+	ctx context.Context,
+	login string,
+	documentID string,
+	deletedAt time.Time,
+	revision *int64,
+) error {
+	filter := revisionDocument(activeDocument(bson.M{
+		"_id": documentID,
+		"grant": bson.M{
+			"$in": []string{login},
+		},
+	}), revision)
+
+	update := bson.M{
+		"$set": bson.M{"deleted_at": deletedAt},
+	}
+
+	res, err := r.documentCollection.UpdateOne(ctx, unlockedDocument(filter, login, deletedAt), update)
+	if err != nil {
+		return &e.ErrDelete{Msg: "failed move document to trash", Err: err}
+	}
+	if res.MatchedCount == 0 {
+		return r.lockedNotMatched(ctx, filter, revision)
+	}
+
+	return nil
+}
+
+func (r *Repository) TrashedDocument(ctx context.Context, login string, documentID string) (core.Document, error) {
+	filter := trashedDocument(bson.M{
+		"_id": documentID,
+		"grant": bson.M{
+			"$in": []string{login},
+		},
+	})
+
+	var document core.Document
+
+	err := r.documentCollection.FindOne(ctx, filter).Decode(&document)
+	switch {
+	case err != nil && errors.Is(err, mongo.ErrNoDocuments):
+		return core.Document{}, e.ErrNoDocuments
+	case err != nil:
+		return core.Document{}, &e.ErrFind{Msg: "failed to find document in trash", Err: err}
+	default:
+		return document, nil
+	}
+}
+
+func (r *Repository) TrashList(ctx context.Context, login string) ([]core.Document, error) {
+	filter := trashedDocument(bson.M{
+		"grant": bson.M{
+			"$in": []string{login},
+		},
+	})
+
+	findOptions := options.Find()
+	findOptions.SetSort(bson.D{{Key: "deleted_at", Value: -1}})
+
+	return r.findDocuments(ctx, filter, findOptions)
+}
+
+func (r *Repository) TrashedBefore(ctx context.Context, before time.Time) ([]core.Document, error) {
 	filter := bson.M{
+		"deleted_at": bson.M{"$lte": before},
+	}
+
+	return r.findDocuments(ctx, filter, options.Find())
+}
+
+func (r *Repository) RestoreDocument(ctx context.Context, login string, documentID string) error {
+	filter := trashedDocument(bson.M{
+		"_id": documentID,
+		"grant": bson.M{
+			"$in": []string{login},
+		},
+	})
+
+	update := bson.M{
+		"$unset": bson.M{"deleted_at": ""},
+	}
+
+	res, err := r.documentCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return &e.ErrInsert{Msg: "failed restore document", Err: err}
+	}
+	if res.MatchedCount == 0 {
+		return e.ErrNoDocuments
+	}
+
+	return nil
+}
+
+func (r *Repository) DocumentsByIDs(ctx context.Context, login string, documentIDs []string) ([]core.Document, error) {
+	filter := unexpiredDocument(activeDocument(bson.M{
+		"_id": bson.M{"$in": documentIDs},
+		"grant": bson.M{
+			"$in": []string{login},
+		},
+	}), time.Now())
+
+	return r.findDocuments(ctx, filter, options.Find())
+}
+
+func (r *Repository) DocumentsByOwner(ctx context.Context, ownerID string) ([]core.Document, error) {
+	filter := unexpiredDocument(activeDocument(bson.M{
+		"owner_id": ownerID,
+	}), time.Now())
+
+	findOptions := options.Find()
+	findOptions.SetSort(bson.D{{Key: "created", Value: 1}})
+
+	return r.findDocuments(ctx, filter, findOptions)
+}
+
+// OwnedDocuments returns every document of the owner, trashed and expired
+// ones included.
+func (r *Repository) OwnedDocuments(ctx context.Context, ownerID string) ([]core.Document, error) {
+	filter := bson.M{"owner_id": ownerID}
+
+	return r.findDocuments(ctx, filter, options.Find())
+}
+
+// TransferDocument makes the document owned by another user, the grant of the
+// previous owner and its lock are removed.
+func (r *Repository) TransferDocument(
+	ctx context.Context,
+	documentID string,
+	ownerID string,
+	fromLogin string,
+	toLogin string,
+	name string,
+) error {
+	filter := bson.M{"_id": documentID}
+
+	update := bson.A{
+		bson.M{"$set": bson.M{
+			"owner_id": ownerID,
+			"name":     name,
+			"grant": bson.M{"$setUnion": bson.A{
+				bson.M{"$setDifference": bson.A{bson.M{"$ifNull": bson.A{"$grant", bson.A{}}}, bson.A{fromLogin}}},
+				bson.A{toLogin},
+			}},
+		}},
+		bson.M{"$unset": "lock"},
+	}
+
+	res, err := r.documentCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return &e.ErrInsert{Msg: "failed transfer document", Err: err}
+	}
+	if res.MatchedCount == 0 {
+		return e.ErrNoDocuments
+	}
+
+	return nil
+}
+
+// RemoveGrantee removes the login from the grants of every document.
+func (r *Repository) RemoveGrantee(ctx context.Context, login string) error {
+	filter := bson.M{"grant": login}
+
+	update := bson.M{
+		"$pull": bson.M{"grant": login},
+	}
+
+	_, err := r.documentCollection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return &e.ErrInsert{Msg: "failed remove grantee", Err: err}
+	}
+
+	return nil
+}
+
+func (r *Repository) AddGrant(ctx context.Context, login string, documentID string, grant []string) error {
+	filter := activeDocument(bson.M{
 		"_id": documentID,
 		"grant": bson.M{
 			"$in": []string{login},
 		},
+	})
+
+	update := bson.M{
+		"$addToSet": bson.M{
+			"grant": bson.M{"$each": grant},
+		},
+		"$inc": bson.M{"revision": 1},
+	}
+
+	res, err := r.documentCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return &e.ErrInsert{Msg: "failed share document", Err: err}
+	}
+	if res.MatchedCount == 0 {
+		return e.ErrNoDocuments
+	}
+
+	return nil
+}
+
+// DocumentByID finds a document regardless of its grants.
+func (r *Repository) DocumentByID(ctx context.Context, documentID string) (core.Document, error) {
+	filter := bson.M{"_id": documentID}
+
+	var document core.Document
+
+	err := r.documentCollection.FindOne(ctx, filter).Decode(&document)
+	switch {
+	case err != nil && errors.Is(err, mongo.ErrNoDocuments):
+		return core.Document{}, e.ErrNoDocuments
+	case err != nil:
+		return core.Document{}, &e.ErrFind{Msg: "failed to find document", Err: err}
+	default:
+		return document, nil
+	}
+}
+
+func (r *Repository) SetLegalHold(ctx context.Context, documentID string, hold bool) error {
+	filter := bson.M{"_id": documentID}
+
+	update := bson.M{
+		"$set": bson.M{"legal_hold": hold},
 	}
 
+	res, err := r.documentCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return &e.ErrInsert{Msg: "failed update legal hold", Err: err}
+	}
+	if res.MatchedCount == 0 {
+		return e.ErrNoDocuments
+	}
+
+	return nil
+}
+
+func (r *Repository) ExpiredDocuments(ctx context.Context, now time.Time) ([]core.Document, error) {
+	filter := bson.M{
+		"expires_at": bson.M{"$lte": now},
+		"legal_hold": bson.M{"$ne": true},
+	}
+
+	return r.findDocuments(ctx, filter, options.Find())
+}
+
+// PurgeDocument removes a document permanently regardless of its grants.
+func (r *Repository) PurgeDocument(ctx context.Context, documentID string) error {
+	filter := bson.M{"_id": documentID}
+
 	_, err := r.documentCollection.DeleteOne(ctx, filter)
 	if err != nil {
-		return &e.ErrDelete{Msg: "failed delete document", Err: err}
+		return &e.ErrDelete{Msg: "failed purge document", Err: err}
+	}
+
+	return nil
+}
+
+func (r *Repository) findDocuments(ctx context.Context, filter bson.M, findOptions *options.FindOptionsBuilder) ([]core.Document, error) {
+	cursor, err := r.documentCollection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, &e.ErrFind{Msg: "failed find documents", Err: err}
+	}
+	defer cursor.Close(ctx)
+
+	var documentsList []core.Document
+
+	if err := cursor.All(ctx, &documentsList); err != nil {
+		return nil, &e.ErrFind{Msg: "failed find documents", Err: err}
+	}
+
+	return documentsList, nil
+}
+
+// activeDocument restricts filter to documents that are not in the trash.
+func activeDocument(filter bson.M) bson.M {
+	filter["deleted_at"] = bson.M{"$exists": false}
+
+	return filter
+}
+
+// unexpiredDocument restricts filter to documents which have no expiration,
+// have not reached it yet or are kept by a legal hold.
+func unexpiredDocument(filter bson.M, now time.Time) bson.M {
+	filter["$or"] = bson.A{
+		bson.M{"expires_at": bson.M{"$exists": false}},
+		bson.M{"expires_at": bson.M{"$gt": now}},
+		bson.M{"legal_hold": true},
+	}
+
+	return filter
+}
+
+// revisionDocument restricts the filter to the expected revision, a nil
+// revision matches any. Documents stored before revisions were recorded are
+// at revision 0.
+func revisionDocument(filter bson.M, revision *int64) bson.M {
+	switch {
+	case revision == nil:
+	case *revision == 0:
+		filter["revision"] = bson.M{"$in": bson.A{0, nil}}
+	default:
+		filter["revision"] = *revision
+	}
+
+	return filter
+}
+
+// notMatched is the error of a conditional write which matched no document.
+func notMatched(revision *int64) error {
+	if revision != nil {
+		return e.ErrPreconditionFailed
+	}
+
+	return e.ErrNoDocuments
+}
+
+func trashedDocument(filter bson.M) bson.M {
+	filter["deleted_at"] = bson.M{"$exists": true}
+
+	return filter
+}
+
+func attributeField(key string) string {
+	return "attributes." + key
+}
+
+func documentsSort(df core.DocumentFilter) bson.D {
+	if len(df.Sort) == 0 {
+		return bson.D{{Key: "Name", Value: -1}, {Key: "created", Value: -1}}
+	}
+
+	order := -1
+	if df.Order == core.SortAsc {
+		order = 1
+	}
+
+	return bson.D{{Key: df.Sort, Value: order}, {Key: "created", Value: -1}}
+}
+
+func (r *Repository) CreateRetentionPolicy(ctx context.Context, policy core.RetentionPolicy) error {
+	_, err := r.retentionCollection.InsertOne(ctx, policy)
+	if err != nil {
+		return &e.ErrInsert{Msg: "failed create retention policy", Err: err}
+	}
+
+	return nil
+}
+
+func (r *Repository) RetentionPolicies(ctx context.Context) ([]core.RetentionPolicy, error) {
+	findOptions := options.Find()
+	findOptions.SetSort(bson.D{{Key: "created", Value: 1}})
+
+	cursor, err := r.retentionCollection.Find(ctx, bson.M{}, findOptions)
+	if err != nil {
+		return nil, &e.ErrFind{Msg: "failed find retention policies", Err: err}
+	}
+	defer cursor.Close(ctx)
+
+	var policies []core.RetentionPolicy
+
+	if err := cursor.All(ctx, &policies); err != nil {
+		return nil, &e.ErrFind{Msg: "failed find retention policies", Err: err}
+	}
+
+	return policies, nil
+}
+
+func (r *Repository) DeleteRetentionPolicy(ctx context.Context, policyID string) error {
+	filter := bson.M{"_id": policyID}
+
+	res, err := r.retentionCollection.DeleteOne(ctx, filter)
+	if err != nil {
+		return &e.ErrDelete{Msg: "failed delete retention policy", Err: err}
+	}
+	if res.DeletedCount == 0 {
+		return &e.ErrFind{Msg: "retention policy not found", Err: mongo.ErrNoDocuments}
 	}
 
 	return nil
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/GroVlAn/doc-store/internal/core"
+	"github.com/GroVlAn/doc-store/internal/core/e"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// SessionTokens returns the unexpired tokens of the user's sessions.
+func (r *Repository) SessionTokens(ctx context.Context, userID string, now time.Time) ([]core.AccessToken, error) {
+	filter := bson.M{
+		"user_id":   userID,
+		"family_id": bson.M{"$exists": true},
+		"end_ttl":   bson.M{"$gt": now},
+	}
+
+	findOptions := options.Find()
+	findOptions.SetSort(bson.D{{Key: "start_ttl", Value: 1}})
+
+	cursor, err := r.tokenCollection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, &e.ErrFind{Msg: "failed find tokens", Err: err}
+	}
+	defer cursor.Close(ctx)
+
+	var tokens []core.AccessToken
+
+	if err := cursor.All(ctx, &tokens); err != nil {
+		return nil, &e.ErrFind{Msg: "failed find tokens", Err: err}
+	}
+
+	return tokens, nil
+}
+
+func (r *Repository) TouchToken(ctx context.Context, token string, lastUsed time.Time) error {
+	filter := bson.M{"token": token}
+
+	update := bson.M{
+		"$set": bson.M{"last_used": lastUsed},
+	}
+
+	_, err := r.tokenCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return &e.ErrInsert{Msg: "failed update token", Err: err}
+	}
+
+	return nil
+}
+
+// DeleteSession revokes every token of the user's session.
+func (r *Repository) DeleteSession(ctx context.Context, userID string, sessionID string) error {
+	filter := bson.M{
+		"user_id":   userID,
+		"family_id": sessionID,
+	}
+
+	res, err := r.tokenCollection.DeleteMany(ctx, filter)
+	if err != nil {
+		return &e.ErrDelete{Msg: "failed delete session", Err: err}
+	}
+	if res.DeletedCount == 0 {
+		return e.ErrSessionNotFound
+	}
+
+	return nil
+}
+
+func (r *Repository) DeleteUserTokens(ctx context.Context, userID string) error {
+	filter := bson.M{"user_id": userID}
+
+	_, err := r.tokenCollection.DeleteMany(ctx, filter)
+	if err != nil {
+		return &e.ErrDelete{Msg: "failed delete user tokens", Err: err}
+	}
+
+	return nil
+}
+
+// DeleteUserTokensExcept deletes the tokens of the user outside the family.
+func (r *Repository) DeleteUserTokensExcept(ctx context.Context, userID string, familyID string) error {
+	filter := bson.M{
+		"user_id":   userID,
+		"family_id": bson.M{"$ne": familyID},
+	}
+
+	_, err := r.tokenCollection.DeleteMany(ctx, filter)
+	if err != nil {
+		return &e.ErrDelete{Msg: "failed delete user tokens", Err: err}
+	}
+
+	return nil
+}
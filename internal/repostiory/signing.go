@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/GroVlAn/doc-store/internal/core"
+	"github.com/GroVlAn/doc-store/internal/core/e"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+func (r *Repository) CreateSigningKey(ctx context.Context, key core.SigningKey) error {
+	_, err := r.signingCollection.InsertOne(ctx, key)
+	if err != nil {
+		return &e.ErrInsert{Msg: "failed create signing key", Err: err}
+	}
+
+	return nil
+}
+
+// SigningKeys returns the keys which are not retired, the newest first.
+func (r *Repository) SigningKeys(ctx context.Context, now time.Time) ([]core.SigningKey, error) {
+	filter := bson.M{
+		"retires_at": bson.M{"$gt": now},
+	}
+
+	findOptions := options.Find()
+	findOptions.SetSort(bson.D{{Key: "created", Value: -1}})
+
+	cursor, err := r.signingCollection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, &e.ErrFind{Msg: "failed find signing keys", Err: err}
+	}
+	defer cursor.Close(ctx)
+
+	var keys []core.SigningKey
+
+	if err := cursor.All(ctx, &keys); err != nil {
+		return nil, &e.ErrFind{Msg: "failed find signing keys", Err: err}
+	}
+
+	return keys, nil
+}
+
+func (r *Repository) DeleteRetiredSigningKeys(ctx context.Context, now time.Time) error {
+	filter := bson.M{
+		"retires_at": bson.M{"$lte": now},
+	}
+
+	_, err := r.signingCollection.DeleteMany(ctx, filter)
+	if err != nil {
+		return &e.ErrDelete{Msg: "failed delete signing keys", Err: err}
+	}
+
+	return nil
+}
@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/GroVlAn/doc-store/internal/core/e"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// SupportsTransactions reports whether the deployment is a replica set or a
+// sharded cluster, standalone servers don't support transactions.
+func (r *Repository) SupportsTransactions(ctx context.Context) bool {
+	var hello struct {
+		SetName string `bson:"setName"`
+		Msg     string `bson:"msg"`
+	}
+
+	err := r.client.Database("admin").RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello)
+	if err != nil {
+		return false
+	}
+
+	return len(hello.SetName) > 0 || hello.Msg == "isdbgrid"
+}
+
+// WithTransaction runs fn in a transaction. Repository calls made with the
+// context passed to fn are part of it, fn may run again on transient errors.
+func (r *Repository) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	session, err := r.client.StartSession()
+	if err != nil {
+		return &e.ErrInsert{Msg: "failed start session", Err: err}
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(ctx context.Context) (any, error) {
+		return nil, fn(ctx)
+	})
+
+	return err
+}
@@ -0,0 +1,200 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/GroVlAn/doc-store/internal/core"
+	"github.com/GroVlAn/doc-store/internal/core/e"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+const twoFactorPolicyID = "two_factor_policy"
+
+func (r *Repository) SetTwoFactor(ctx context.Context, userID string, twoFactor core.TwoFactor) error {
+	filter := bson.M{"_id": userID}
+
+	update := bson.M{
+		"$set": bson.M{"two_factor": twoFactor},
+	}
+
+	res, err := r.userCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return &e.ErrInsert{Msg: "failed update two-factor", Err: err}
+	}
+	if res.MatchedCount == 0 {
+		return e.ErrUserNotFound
+	}
+
+	return nil
+}
+
+func (r *Repository) DeleteTwoFactor(ctx context.Context, userID string) error {
+	filter := bson.M{"_id": userID}
+
+	update := bson.M{
+		"$unset": bson.M{"two_factor": ""},
+	}
+
+	_, err := r.userCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return &e.ErrDelete{Msg: "failed delete two-factor", Err: err}
+	}
+
+	return nil
+}
+
+func (r *Repository) SetRecoveryCodes(ctx context.Context, userID string, hashes []string) error {
+	filter := bson.M{
+		"_id":                userID,
+		"two_factor.enabled": true,
+	}
+
+	update := bson.M{
+		"$set": bson.M{"two_factor.recovery_codes": hashes},
+	}
+
+	res, err := r.userCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return &e.ErrInsert{Msg: "failed update recovery codes", Err: err}
+	}
+	if res.MatchedCount == 0 {
+		return e.ErrTwoFactorNotEnabled
+	}
+
+	return nil
+}
+
+// UseTOTPStep records the time step of an accepted code, a step that is not
+// newer than the last one means the code is replayed.
+func (r *Repository) UseTOTPStep(ctx context.Context, userID string, step int64) error {
+	filter := bson.M{
+		"_id": userID,
+		"$or": bson.A{
+			bson.M{"two_factor.last_step": bson.M{"$lt": step}},
+			bson.M{"two_factor.last_step": bson.M{"$exists": false}},
+		},
+	}
+
+	update := bson.M{
+		"$set": bson.M{"two_factor.last_step": step},
+	}
+
+	res, err := r.userCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return &e.ErrInsert{Msg: "failed use totp code", Err: err}
+	}
+	if res.MatchedCount == 0 {
+		return e.ErrInvalidTOTP
+	}
+
+	return nil
+}
+
+// UseRecoveryCode removes the recovery code hash, so every code works once.
+func (r *Repository) UseRecoveryCode(ctx context.Context, userID string, hash string) error {
+	filter := bson.M{
+		"_id":                       userID,
+		"two_factor.recovery_codes": hash,
+	}
+
+	update := bson.M{
+		"$pull": bson.M{"two_factor.recovery_codes": hash},
+	}
+
+	res, err := r.userCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return &e.ErrInsert{Msg: "failed use recovery code", Err: err}
+	}
+	if res.MatchedCount == 0 {
+		return e.ErrInvalidTOTP
+	}
+
+	return nil
+}
+
+func (r *Repository) CreateAuthChallenge(ctx context.Context, challenge core.AuthChallenge) error {
+	_, err := r.challengeCollection.InsertOne(ctx, challenge)
+	if err != nil {
+		return &e.ErrInsert{Msg: "failed create auth challenge", Err: err}
+	}
+
+	return nil
+}
+
+// ClaimAuthChallenge counts an attempt on an unexpired challenge and returns
+// it, a challenge with maxAttempts attempts can't be claimed again.
+func (r *Repository) ClaimAuthChallenge(
+	ctx context.Context,
+	challengeID string,
+	now time.Time,
+	maxAttempts int,
+) (core.AuthChallenge, error) {
+	filter := bson.M{
+		"_id":        challengeID,
+		"expires_at": bson.M{"$gt": now},
+		"attempts":   bson.M{"$lt": maxAttempts},
+	}
+
+	update := bson.M{
+		"$inc": bson.M{"attempts": 1},
+	}
+
+	var challenge core.AuthChallenge
+
+	err := r.challengeCollection.FindOneAndUpdate(
+		ctx,
+		filter,
+		update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&challenge)
+	switch {
+	case err != nil && errors.Is(err, mongo.ErrNoDocuments):
+		return core.AuthChallenge{}, e.ErrInvalidChallenge
+	case err != nil:
+		return core.AuthChallenge{}, &e.ErrFind{Msg: "failed to find auth challenge", Err: err}
+	default:
+		return challenge, nil
+	}
+}
+
+func (r *Repository) DeleteAuthChallenge(ctx context.Context, challengeID string) error {
+	_, err := r.challengeCollection.DeleteOne(ctx, bson.M{"_id": challengeID})
+	if err != nil {
+		return &e.ErrDelete{Msg: "failed delete auth challenge", Err: err}
+	}
+
+	return nil
+}
+
+// TwoFactorPolicy returns the stored policy, no policy requires nothing.
+func (r *Repository) TwoFactorPolicy(ctx context.Context) (core.TwoFactorPolicy, error) {
+	var policy core.TwoFactorPolicy
+
+	err := r.settingsCollection.FindOne(ctx, bson.M{"_id": twoFactorPolicyID}).Decode(&policy)
+	switch {
+	case err != nil && errors.Is(err, mongo.ErrNoDocuments):
+		return core.TwoFactorPolicy{}, nil
+	case err != nil:
+		return core.TwoFactorPolicy{}, &e.ErrFind{Msg: "failed to find two-factor policy", Err: err}
+	default:
+		return policy, nil
+	}
+}
+
+func (r *Repository) SetTwoFactorPolicy(ctx context.Context, policy core.TwoFactorPolicy) error {
+	_, err := r.settingsCollection.ReplaceOne(
+		ctx,
+		bson.M{"_id": twoFactorPolicyID},
+		policy,
+		options.Replace().SetUpsert(true),
+	)
+	if err != nil {
+		return &e.ErrInsert{Msg: "failed save two-factor policy", Err: err}
+	}
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/GroVlAn/doc-store/internal/core"
+	"github.com/GroVlAn/doc-store/internal/core/e"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// SaveVersion stores the version, saving the same version again replaces it.
+func (r *Repository) SaveVersion(ctx context.Context, version core.DocumentVersion) error {
+	filter := bson.M{"_id": version.ID}
+
+	_, err := r.versionCollection.ReplaceOne(ctx, filter, version, options.Replace().SetUpsert(true))
+	if err != nil {
+		return &e.ErrInsert{Msg: "failed save document version", Err: err}
+	}
+
+	return nil
+}
+
+// Versions lists the versions of the document without their JSON bodies.
+func (r *Repository) Versions(ctx context.Context, documentID string) ([]core.DocumentVersion, error) {
+	filter := bson.M{"document_id": documentID}
+
+	findOptions := options.Find()
+	findOptions.SetSort(bson.D{{Key: "revision", Value: 1}})
+	findOptions.SetProjection(bson.M{"json": 0})
+
+	cursor, err := r.versionCollection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, &e.ErrFind{Msg: "failed find document versions", Err: err}
+	}
+	defer cursor.Close(ctx)
+
+	var versions []core.DocumentVersion
+
+	if err := cursor.All(ctx, &versions); err != nil {
+		return nil, &e.ErrFind{Msg: "failed find document versions", Err: err}
+	}
+
+	return versions, nil
+}
+
+// VersionAt finds the version holding the content of the document at the
+// revision, e.ErrNoDocuments means the revision is the current content.
+func (r *Repository) VersionAt(ctx context.Context, documentID string, revision int64) (core.DocumentVersion, error) {
+	filter := bson.M{
+		"document_id": documentID,
+		"revision":    bson.M{"$gte": revision},
+	}
+
+	findOptions := options.FindOne()
+	findOptions.SetSort(bson.D{{Key: "revision", Value: 1}})
+
+	var version core.DocumentVersion
+
+	err := r.versionCollection.FindOne(ctx, filter, findOptions).Decode(&version)
+	switch {
+	case err != nil && errors.Is(err, mongo.ErrNoDocuments):
+		return core.DocumentVersion{}, e.ErrNoDocuments
+	case err != nil:
+		return core.DocumentVersion{}, &e.ErrFind{Msg: "failed find document version", Err: err}
+	default:
+		return version, nil
+	}
+}
+
+func (r *Repository) DeleteVersions(ctx context.Context, documentID string) error {
+	filter := bson.M{"document_id": documentID}
+
+	if _, err := r.versionCollection.DeleteMany(ctx, filter); err != nil {
+		return &e.ErrDelete{Msg: "failed delete document versions", Err: err}
+	}
+
+	return nil
+}
@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/GroVlAn/doc-store/internal/core"
+	"github.com/GroVlAn/doc-store/internal/core/e"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+func (r *Repository) CreateWebhook(ctx context.Context, webhook core.Webhook) error {
+	_, err := r.webhookCollection.InsertOne(ctx, webhook)
+	if err != nil {
+		return &e.ErrInsert{Msg: "failed create webhook", Err: err}
+	}
+
+	return nil
+}
+
+func (r *Repository) Webhook(ctx context.Context, webhookID string) (core.Webhook, error) {
+	filter := bson.M{"_id": webhookID}
+
+	var webhook core.Webhook
+
+	err := r.webhookCollection.FindOne(ctx, filter).Decode(&webhook)
+	switch {
+	case err != nil && errors.Is(err, mongo.ErrNoDocuments):
+		return core.Webhook{}, e.ErrWebhookNotFound
+	case err != nil:
+		return core.Webhook{}, &e.ErrFind{Msg: "failed to find webhook", Err: err}
+	default:
+		return webhook, nil
+	}
+}
+
+func (r *Repository) Webhooks(ctx context.Context, userID string) ([]core.Webhook, error) {
+	return r.findWebhooks(ctx, bson.M{"user_id": userID})
+}
+
+// SubscribedWebhooks returns webhooks of the given users subscribed to event.
+func (r *Repository) SubscribedWebhooks(ctx context.Context, logins []string, event string) ([]core.Webhook, error) {
+	filter := bson.M{
+		"login":  bson.M{"$in": logins},
+		"events": event,
+	}
+
+	return r.findWebhooks(ctx, filter)
+}
+
+func (r *Repository) DeleteWebhook(ctx context.Context, userID string, webhookID string) error {
+	filter := bson.M{
+		"_id":     webhookID,
+		"user_id": userID,
+	}
+
+	res, err := r.webhookCollection.DeleteOne(ctx, filter)
+	if err != nil {
+		return &e.ErrDelete{Msg: "failed delete webhook", Err: err}
+	}
+	if res.DeletedCount == 0 {
+		return e.ErrWebhookNotFound
+	}
+
+	return nil
+}
+
+func (r *Repository) DeleteUserWebhooks(ctx context.Context, userID string) error {
+	_, err := r.webhookCollection.DeleteMany(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return &e.ErrDelete{Msg: "failed delete webhooks", Err: err}
+	}
+
+	return nil
+}
+
+func (r *Repository) CreateDeliveries(ctx context.Context, deliveries []core.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	_, err := r.outboxCollection.InsertMany(ctx, deliveries)
+	if err != nil {
+		return &e.ErrInsert{Msg: "failed create webhook deliveries", Err: err}
+	}
+
+	return nil
+}
+
+// ClaimDelivery takes one due delivery and postpones its next attempt by lease
+// so that concurrent workers don't pick it up at the same time.
+func (r *Repository) ClaimDelivery(ctx context.Context, now time.Time, lease time.Duration) (core.WebhookDelivery, error) {
+	filter := bson.M{
+		"status":       core.DeliveryPending,
+		"next_attempt": bson.M{"$lte": now},
+	}
+
+	update := bson.M{
+		"$set": bson.M{"next_attempt": now.Add(lease)},
+	}
+
+	findOptions := options.FindOneAndUpdate()
+	findOptions.SetSort(bson.D{{Key: "next_attempt", Value: 1}})
+	findOptions.SetReturnDocument(options.After)
+
+	var delivery core.WebhookDelivery
+
+	err := r.outboxCollection.FindOneAndUpdate(ctx, filter, update, findOptions).Decode(&delivery)
+	switch {
+	case err != nil && errors.Is(err, mongo.ErrNoDocuments):
+		return core.WebhookDelivery{}, e.ErrNoDeliveries
+	case err != nil:
+		return core.WebhookDelivery{}, &e.ErrFind{Msg: "failed claim webhook delivery", Err: err}
+	default:
+		return delivery, nil
+	}
+}
+
+func (r *Repository) UpdateDelivery(ctx context.Context, delivery core.WebhookDelivery) error {
+	filter := bson.M{"_id": delivery.ID}
+
+	update := bson.M{
+		"$set": bson.M{
+			"status":       delivery.Status,
+			"attempts":     delivery.Attempts,
+			"next_attempt": delivery.NextAttempt,
+			"last_error":   delivery.LastError,
+		},
+	}
+
+	_, err := r.outboxCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return &e.ErrInsert{Msg: "failed update webhook delivery", Err: err}
+	}
+
+	return nil
+}
+
+func (r *Repository) CreateDeliveryAttempt(ctx context.Context, attempt core.DeliveryAttempt) error {
+	_, err := r.attemptCollection.InsertOne(ctx, attempt)
+	if err != nil {
+		return &e.ErrInsert{Msg: "failed create delivery attempt", Err: err}
+	}
+
+	return nil
+}
+
+func (r *Repository) DeliveryAttempts(ctx context.Context, webhookID string, limit int64) ([]core.DeliveryAttempt, error) {
+	filter := bson.M{"webhook_id": webhookID}
+
+	findOptions := options.Find()
+	findOptions.SetSort(bson.D{{Key: "time", Value: -1}})
+	findOptions.SetLimit(limit)
+
+	cursor, err := r.attemptCollection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, &e.ErrFind{Msg: "failed find delivery attempts", Err: err}
+	}
+	defer cursor.Close(ctx)
+
+	var attempts []core.DeliveryAttempt
+
+	if err := cursor.All(ctx, &attempts); err != nil {
+		return nil, &e.ErrFind{Msg: "failed find delivery attempts", Err: err}
+	}
+
+	return attempts, nil
+}
+
+func (r *Repository) findWebhooks(ctx context.Context, filter bson.M) ([]core.Webhook, error) {
+	cursor, err := r.webhookCollection.Find(ctx, filter)
+	if err != nil {
+		return nil, &e.ErrFind{Msg: "failed find webhooks", Err: err}
+	}
+	defer cursor.Close(ctx)
+
+	var webhooks []core.Webhook
+
+	if err := cursor.All(ctx, &webhooks); err != nil {
+		return nil, &e.ErrFind{Msg: "failed find webhooks", Err: err}
+	}
+
+	return webhooks, nil
+}
@@ -0,0 +1,510 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/GroVlAn/doc-store/internal/archive"
+	"github.com/GroVlAn/doc-store/internal/core"
+	"github.com/GroVlAn/doc-store/internal/core/e"
+)
+
+const (
+	manifestVersion = 1
+	maxManifestSize = 64 << 20
+	maxRenameTries  = 1000
+
+	defaultImportMaxEntrySize = 256 << 20
+)
+
+// ExportAccount describes every document owned by the token owner. The
+// entries paths are the ones listed in the manifest.
+func (s *Service) ExportAccount(principal core.Principal) (core.ExportManifest, []core.ArchiveEntry, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.DefaultTimeout)
+	defer cancel()
+
+	userID := principal.UserID
+
+	documents, err := s.DocumentRepo.DocumentsByOwner(ctx, userID)
+	if err != nil {
+		return core.ExportManifest{}, nil, fmt.Errorf("getting documents: %w", err)
+	}
+
+	manifest := core.ExportManifest{
+		Version:   manifestVersion,
+		Login:     principal.Login,
+		Exported:  time.Now(),
+		Documents: make([]core.ExportDocument, 0, len(documents)),
+	}
+	entries := make([]core.ArchiveEntry, 0, len(documents))
+
+	for _, document := range documents {
+		item := core.ExportDocument{Document: document}
+
+		if document.Json != nil {
+			sum := sha256.Sum256(document.Json)
+			item.Json = &core.ExportEntry{
+				Path:   fmt.Sprintf("json/%s.json", document.ID),
+				Size:   int64(len(document.Json)),
+				SHA256: hex.EncodeToString(sum[:]),
+			}
+
+			entries = append(entries, core.ArchiveEntry{
+				Name:     item.Json.Path,
+				Document: document,
+				Json:     document.Json,
+			})
+		}
+
+		if s.hasFile(userID, document) {
+			checksum, size, err := s.FileRepo.Checksum(userID, document.Name)
+			if err != nil {
+				return core.ExportManifest{}, nil, fmt.Errorf("calculating checksum: %w", err)
+			}
+
+			item.File = &core.ExportEntry{
+				Path:   fmt.Sprintf("files/%s/%s", document.ID, path.Base(document.Name)),
+				Size:   size,
+				SHA256: checksum,
+			}
+
+			entries = append(entries, core.ArchiveEntry{
+				Name:     item.File.Path,
+				Document: document,
+				Open:     s.fileOpener(userID, document.Name),
+			})
+		}
+
+		manifest.Documents = append(manifest.Documents, item)
+	}
+
+	return manifest, entries, nil
+}
+
+type importItem struct {
+	json      []byte
+	file      []byte
+	remaining int
+	done      bool
+}
+
+// ImportAccount recreates the documents of an exported account for the token
+// owner. Entries are verified against the manifest checksums, a document with
+// a missing or corrupted entry is reported and skipped.
+func (s *Service) ImportAccount(principal core.Principal, ar archive.Reader, conflict string) ([]core.BulkResult, error) {
+	if len(conflict) == 0 {
+		conflict = core.ConflictRename
+	}
+
+	if conflict != core.ConflictRename && conflict != core.ConflictSkip && conflict != core.ConflictOverwrite {
+		return nil, e.ErrInvalidConflict
+	}
+
+	manifest, err := readManifest(ar)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.validateManifest(manifest); err != nil {
+		return nil, err
+	}
+
+	items := make([]importItem, len(manifest.Documents))
+	results := make([]core.BulkResult, len(manifest.Documents))
+	paths := make(map[string]int, len(manifest.Documents))
+
+	for i, document := range manifest.Documents {
+		results[i] = core.BulkResult{Index: i, Name: document.Document.Name}
+
+		for _, entry := range []*core.ExportEntry{document.Json, document.File} {
+			if entry == nil {
+				continue
+			}
+
+			paths[entry.Path] = i
+			items[i].remaining++
+		}
+	}
+
+	for i := range items {
+		if items[i].remaining == 0 {
+			results[i] = s.importDocument(principal, manifest, i, items[i], conflict)
+			items[i].done = true
+		}
+	}
+
+	for {
+		name, r, err := ar.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", e.ErrInvalidArchive, err)
+		}
+
+		i, ok := paths[name]
+		if !ok || items[i].done {
+			continue
+		}
+
+		document := manifest.Documents[i]
+
+		switch {
+		case document.Json != nil && document.Json.Path == name:
+			items[i].json, err = readEntry(r, *document.Json)
+		default:
+			items[i].file, err = readEntry(r, *document.File)
+		}
+
+		if err != nil {
+			results[i].Err = err
+			items[i].done = true
+			continue
+		}
+
+		items[i].remaining--
+		if items[i].remaining == 0 {
+			results[i] = s.importDocument(principal, manifest, i, items[i], conflict)
+			items[i].done = true
+		}
+	}
+
+	for i := range items {
+		if !items[i].done {
+			results[i].Err = fmt.Errorf("%w: missing entries of document %s", e.ErrInvalidArchive, manifest.Documents[i].Document.ID)
+		}
+	}
+
+	return results, nil
+}
+
+func (s *Service) importDocument(
+	principal core.Principal,
+	manifest core.ExportManifest,
+	index int,
+	item importItem,
+	conflict string,
+) core.BulkResult {
+	ctx, cancel := context.WithTimeout(context.Background(), s.DefaultTimeout)
+	defer cancel()
+
+	login := principal.Login
+
+	document := manifest.Documents[index].Document
+	document.Token = ""
+	document.TTL = ""
+	document.DeletedAt = nil
+	document.Json = item.json
+	document.Grant = slices.DeleteFunc(slices.Clone(document.Grant), func(grant string) bool {
+		return grant == manifest.Login || grant == login
+	})
+
+	result := core.BulkResult{Index: index, Name: document.Name}
+
+	if len(document.Name) > 0 && conflict != core.ConflictOverwrite {
+		existDocument, err := s.existDocument(ctx, login, document.Name)
+		if err != nil {
+			result.Err = err
+
+			return result
+		}
+
+		if len(existDocument.ID) > 0 {
+			if conflict == core.ConflictSkip {
+				result.Err = e.ErrDocumentExist
+
+				return result
+			}
+
+			document.Name, err = s.freeName(ctx, login, document.Name)
+			if err != nil {
+				result.Err = err
+
+				return result
+			}
+		}
+	}
+
+	created, err := s.createDocument(ctx, principal, document, item.file)
+
+	result.ID = created.ID
+	result.Name = document.Name
+	result.Err = err
+
+	return result
+}
+
+// freeName finds a name not used by the user's documents, e.g. "report (1).pdf".
+func (s *Service) freeName(ctx context.Context, login string, name string) (string, error) {
+	ext := path.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+
+	for i := 1; i <= maxRenameTries; i++ {
+		candidate := fmt.Sprintf("%s (%d)%s", base, i, ext)
+
+		existDocument, err := s.existDocument(ctx, login, candidate)
+		if err != nil {
+			return "", err
+		}
+
+		if len(existDocument.ID) == 0 {
+			return candidate, nil
+		}
+	}
+
+	return "", e.ErrDocumentExist
+}
+
+func readManifest(ar archive.Reader) (core.ExportManifest, error) {
+	name, r, err := ar.Next()
+	if err != nil {
+		return core.ExportManifest{}, fmt.Errorf("%w: %w", e.ErrInvalidArchive, err)
+	}
+
+	if name != core.ManifestName {
+		return core.ExportManifest{}, fmt.Errorf("%w: %s must be the first entry", e.ErrInvalidArchive, core.ManifestName)
+	}
+
+	var manifest core.ExportManifest
+
+	if err := json.NewDecoder(io.LimitReader(r, maxManifestSize)).Decode(&manifest); err != nil {
+		return core.ExportManifest{}, fmt.Errorf("%w: decoding manifest: %w", e.ErrInvalidArchive, err)
+	}
+
+	if manifest.Version != manifestVersion {
+		return core.ExportManifest{}, fmt.Errorf("%w: unsupported manifest version %d", e.ErrInvalidArchive, manifest.Version)
+	}
+
+	return manifest, nil
+}
+
+// validateManifest rejects a manifest declaring an entry larger than the
+// import allows, the declared size bounds how much of an entry is read.
+func (s *Service) validateManifest(manifest core.ExportManifest) error {
+	maxSize := s.ImportMaxEntrySize
+	if maxSize <= 0 {
+		maxSize = defaultImportMaxEntrySize
+	}
+
+	for _, document := range manifest.Documents {
+		for _, entry := range []*core.ExportEntry{document.Json, document.File} {
+			if entry == nil {
+				continue
+			}
+
+			if entry.Size < 0 || entry.Size > maxSize {
+				return fmt.Errorf("%w: %s declares %d bytes, at most %d are allowed", e.ErrInvalidArchive, entry.Path, entry.Size, maxSize)
+			}
+		}
+	}
+
+	return nil
+}
+
+// readEntry reads an entry of the declared size, a longer entry is rejected
+// after one more byte.
+func readEntry(r io.Reader, entry core.ExportEntry) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, entry.Size+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: reading %s: %w", e.ErrInvalidArchive, entry.Path, err)
+	}
+
+	if int64(len(data)) != entry.Size {
+		return nil, fmt.Errorf("%w: size mismatch of %s", e.ErrInvalidArchive, entry.Path)
+	}
+
+	sum := sha256.Sum256(data)
+	if hex.EncodeToString(sum[:]) != entry.SHA256 {
+		return nil, fmt.Errorf("%w: checksum mismatch of %s", e.ErrInvalidArchive, entry.Path)
+	}
+
+	return data, nil
+}
+
+// DeleteAccount deletes the caller together with its tokens, API keys and
+// webhooks and removes its login from shared documents. Owned documents are
+// transferred to deletion.TransferTo or purged, purging fails before anything
+// is deleted when one of them is retained.
+func (s *Service) DeleteAccount(principal core.Principal, deletion core.AccountDeletion) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.DefaultTimeout)
+	defer cancel()
+
+	user, err := s.accountUser(ctx, principal)
+	if err != nil {
+		return err
+	}
+
+	if len(user.Password) > 0 {
+		if err := s.Hashes.Compare(ctx, user.Password, deletion.Password); err != nil {
+			return err
+		}
+	}
+
+	if user.TwoFactor != nil && user.TwoFactor.Enabled {
+		if err := s.verifySecondFactor(ctx, user, deletion.Code); err != nil {
+			return err
+		}
+	}
+
+	documents, err := s.DocumentRepo.OwnedDocuments(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("getting owned documents: %w", err)
+	}
+
+	if len(deletion.TransferTo) > 0 {
+		err = s.transferDocuments(ctx, user, deletion.TransferTo, documents)
+	} else {
+		err = s.purgeDocuments(documents)
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.deleteAccountData(user)
+}
+
+// deleteAccountData removes what is left of the user once its documents are
+// gone. Every step has its own timeout, the document loop before may have used
+// up the one of the request.
+func (s *Service) deleteAccountData(user core.User) error {
+	steps := []struct {
+		name string
+		run  func(ctx context.Context) error
+	}{
+		{name: "removing grants", run: func(ctx context.Context) error {
+			return s.DocumentRepo.RemoveGrantee(ctx, user.Login)
+		}},
+		{name: "deleting api keys", run: func(ctx context.Context) error {
+			return s.APIKeyRepo.DeleteUserAPIKeys(ctx, user.ID)
+		}},
+		{name: "deleting webhooks", run: func(ctx context.Context) error {
+			return s.WebhookRepo.DeleteUserWebhooks(ctx, user.ID)
+		}},
+		{name: "deleting password resets", run: func(ctx context.Context) error {
+			return s.ResetRepo.DeletePasswordResets(ctx, user.ID)
+		}},
+		{name: "deleting files", run: func(context.Context) error {
+			return s.FileRepo.DeleteUserFiles(user.ID)
+		}},
+		{name: "deleting user tokens", run: func(ctx context.Context) error {
+			return s.TokenRepo.DeleteUserTokens(ctx, user.ID)
+		}},
+		{name: "deleting user", run: func(ctx context.Context) error {
+			return s.UserRepo.DeleteUser(ctx, user.ID)
+		}},
+	}
+
+	for _, step := range steps {
+		if err := s.withTimeout(step.run); err != nil {
+			return fmt.Errorf("%s: %w", step.name, err)
+		}
+	}
+
+	return nil
+}
+
+// withTimeout runs fn with a fresh default timeout.
+func (s *Service) withTimeout(fn func(ctx context.Context) error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.DefaultTimeout)
+	defer cancel()
+
+	return fn(ctx)
+}
+
+func (s *Service) purgeDocuments(documents []core.Document) error {
+	for _, document := range documents {
+		err := s.withTimeout(func(ctx context.Context) error {
+			return s.checkRetention(ctx, document)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, document := range documents {
+		if err := s.purgeOwnedDocument(document); err != nil {
+			return fmt.Errorf("purging document %s: %w", document.ID, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) purgeOwnedDocument(document core.Document) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.DefaultTimeout)
+	defer cancel()
+
+	return s.purgeDocument(ctx, document)
+}
+
+func (s *Service) transferDocuments(ctx context.Context, user core.User, login string, documents []core.Document) error {
+	target, err := s.UserRepo.User(ctx, login)
+	if err != nil {
+		return fmt.Errorf("getting user: %w", err)
+	}
+
+	if target.ID == user.ID {
+		return e.ErrInvalidTransfer
+	}
+
+	for _, document := range documents {
+		if err := s.transferDocument(user, target, document); err != nil {
+			return fmt.Errorf("transferring document %s: %w", document.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// transferDocument moves the document and its file to the target user, an
+// active document is renamed when the target has one with the same name.
+func (s *Service) transferDocument(user core.User, target core.User, document core.Document) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.DefaultTimeout)
+	defer cancel()
+
+	name := document.Name
+
+	if document.DeletedAt == nil && len(name) > 0 {
+		existDocument, err := s.existDocument(ctx, target.Login, name)
+		if err != nil {
+			return err
+		}
+
+		if len(existDocument.ID) > 0 && existDocument.ID != document.ID {
+			name, err = s.freeName(ctx, target.Login, name)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	switch {
+	case document.DeletedAt != nil:
+		if s.FileRepo.TrashFileExist(user.ID, document.ID) {
+			if err := s.FileRepo.MoveTrashFile(user.ID, target.ID, document.ID); err != nil {
+				return err
+			}
+		}
+	case s.hasFile(user.ID, document):
+		if err := s.FileRepo.MoveFile(user.ID, target.ID, document.Name, name); err != nil {
+			return err
+		}
+	}
+
+	if err := s.DocumentRepo.TransferDocument(ctx, document.ID, target.ID, user.Login, target.Login, name); err != nil {
+		return fmt.Errorf("updating document owner: %w", err)
+	}
+
+	s.Cache.Delete(s.documentKey(document.ID))
+
+	return nil
+}
@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/GroVlAn/doc-store/internal/core"
+	"github.com/GroVlAn/doc-store/internal/core/e"
+	"github.com/google/uuid"
+)
+
+const (
+	apiKeySize       = 32
+	apiKeyPrefixSize = 8
+	apiKeyNameSize   = 128
+)
+
+// CreateAPIKey creates a key for the caller and returns it with the secret,
+// the secret is never shown again. API keys can't create other keys.
+func (s *Service) CreateAPIKey(principal core.Principal, key core.APIKey) (core.APIKey, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.DefaultTimeout)
+	defer cancel()
+
+	if len(principal.APIKeyID) > 0 {
+		return core.APIKey{}, e.ErrForbidden
+	}
+
+	key.Created = time.Now()
+
+	if err := validateAPIKey(&key); err != nil {
+		return core.APIKey{}, err
+	}
+
+	secret, err := generateSecret(apiKeySize)
+	if err != nil {
+		return core.APIKey{}, fmt.Errorf("generating api key: %w", err)
+	}
+
+	key.ID = uuid.NewString()
+	key.Token = core.APIKeyPrefix + secret
+	key.UserID = principal.UserID
+	key.Login = principal.Login
+	key.Prefix = key.Token[:len(core.APIKeyPrefix)+apiKeyPrefixSize]
+	key.Hash = hashToken(key.Token)
+	key.LastUsed = nil
+
+	if err := s.APIKeyRepo.CreateAPIKey(ctx, key); err != nil {
+		return core.APIKey{}, fmt.Errorf("creating api key: %w", err)
+	}
+
+	return key, nil
+}
+
+func (s *Service) APIKeys(principal core.Principal) ([]core.APIKey, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.DefaultTimeout)
+	defer cancel()
+
+	keys, err := s.APIKeyRepo.APIKeys(ctx, principal.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("getting api keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+func (s *Service) DeleteAPIKey(principal core.Principal, keyID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.DefaultTimeout)
+	defer cancel()
+
+	if len(principal.APIKeyID) > 0 {
+		return e.ErrForbidden
+	}
+
+	if err := s.APIKeyRepo.DeleteAPIKey(ctx, principal.UserID, keyID); err != nil {
+		return fmt.Errorf("deleting api key: %w", err)
+	}
+
+	return nil
+}
+
+// authenticateAPIKey verifies an API key, its expiration and the address it
+// is used from.
+func (s *Service) authenticateAPIKey(ctx context.Context, token string, client core.Client) (core.Principal, error) {
+	key, err := s.APIKeyRepo.APIKeyByHash(ctx, hashToken(token))
+	if errors.Is(err, e.ErrAPIKeyNotFound) {
+		return core.Principal{}, &e.ErrInvalidToken{Msg: "invalid api key", Err: err}
+	}
+	if err != nil {
+		return core.Principal{}, fmt.Errorf("getting api key: %w", err)
+	}
+
+	now := time.Now()
+	if key.ExpiresAt != nil && !now.Before(*key.ExpiresAt) {
+		return core.Principal{}, &e.ErrInvalidToken{Msg: "api key expired"}
+	}
+
+	if !ipAllowed(key.AllowedIPs, client.IP) {
+		return core.Principal{}, e.ErrForbidden
+	}
+
+	if _, err := s.UserRepo.User(ctx, key.Login); err != nil {
+		return core.Principal{}, e.ErrUserNotFound
+	}
+
+	if key.LastUsed == nil || now.Sub(*key.LastUsed) >= sessionTouchInterval {
+		if err := s.APIKeyRepo.TouchAPIKey(ctx, key.ID, now); err != nil {
+			return core.Principal{}, fmt.Errorf("updating api key last use: %w", err)
+		}
+	}
+
+	return core.Principal{
+		UserID:   key.UserID,
+		Login:    key.Login,
+		APIKeyID: key.ID,
+		Scopes:   key.Scopes,
+	}, nil
+}
+
+func validateAPIKey(key *core.APIKey) error {
+	key.Name = strings.TrimSpace(key.Name)
+	if len(key.Name) == 0 || len(key.Name) > apiKeyNameSize {
+		return fmt.Errorf("%w: name is required", e.ErrInvalidAPIKey)
+	}
+
+	if len(key.Scopes) == 0 {
+		return fmt.Errorf("%w: at least one scope is required", e.ErrInvalidAPIKey)
+	}
+
+	for _, scope := range key.Scopes {
+		if !slices.Contains(core.Scopes, scope) {
+			return fmt.Errorf("%w: unknown scope %q", e.ErrInvalidAPIKey, scope)
+		}
+	}
+	slices.Sort(key.Scopes)
+	key.Scopes = slices.Compact(key.Scopes)
+
+	for _, allowed := range key.AllowedIPs {
+		if net.ParseIP(allowed) != nil {
+			continue
+		}
+
+		if _, _, err := net.ParseCIDR(allowed); err != nil {
+			return fmt.Errorf("%w: invalid address %q", e.ErrInvalidAPIKey, allowed)
+		}
+	}
+
+	if len(key.TTL) > 0 && key.ExpiresAt == nil {
+		ttl, err := time.ParseDuration(key.TTL)
+		if err != nil || ttl <= 0 {
+			return fmt.Errorf("%w: invalid ttl", e.ErrInvalidAPIKey)
+		}
+
+		expiresAt := key.Created.Add(ttl)
+		key.ExpiresAt = &expiresAt
+	}
+	key.TTL = ""
+
+	if key.ExpiresAt != nil && !key.ExpiresAt.After(key.Created) {
+		return fmt.Errorf("%w: expiration is in the past", e.ErrInvalidAPIKey)
+	}
+
+	return nil
+}
+
+// ipAllowed reports whether the address matches the allowlist of addresses
+// and CIDR ranges. An empty allowlist allows any address.
+func ipAllowed(allowed []string, address string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return false
+	}
+
+	for _, entry := range allowed {
+		if allowedIP := net.ParseIP(entry); allowedIP != nil {
+			if allowedIP.Equal(ip) {
+				return true
+			}
+
+			continue
+		}
+
+		if _, network, err := net.ParseCIDR(entry); err == nil && network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/GroVlAn/doc-store/internal/core"
+	"github.com/GroVlAn/doc-store/internal/core/e"
+)
+
+const jsonExtension = ".json"
+
+// ArchiveDocuments resolves the documents to put into an archive. Documents
+// the caller can't access are skipped.
+func (s *Service) ArchiveDocuments(principal core.Principal, documentIDs []string, filter *core.DocumentFilter) ([]core.ArchiveEntry, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.DefaultTimeout)
+	defer cancel()
+
+	login := principal.Login
+
+	var (
+		documents []core.Document
+		err       error
+	)
+
+	if len(documentIDs) > 0 {
+		documents, err = s.DocumentRepo.DocumentsByIDs(ctx, login, documentIDs)
+		if err != nil {
+			return nil, fmt.Errorf("getting documents: %w", err)
+		}
+	}
+
+	if filter != nil {
+		filter.Login = login
+
+		if err := validateFilter(*filter); err != nil {
+			return nil, err
+		}
+
+		filtered, err := s.DocumentRepo.DocumentsList(ctx, *filter)
+		if err != nil {
+			return nil, fmt.Errorf("getting documents list: %w", err)
+		}
+
+		documents = append(documents, filtered...)
+	}
+
+	if len(documentIDs) == 0 && filter == nil {
+		return nil, e.ErrEmptyBody
+	}
+
+	return s.archiveEntries(documents, principal.UserID), nil
+}
+
+func (s *Service) archiveEntries(documents []core.Document, userID string) []core.ArchiveEntry {
+	entries := make([]core.ArchiveEntry, 0, len(documents))
+	seen := make(map[string]struct{}, len(documents))
+	names := make(map[string]struct{}, len(documents))
+
+	for _, document := range documents {
+		if _, ok := seen[document.ID]; ok {
+			continue
+		}
+		seen[document.ID] = struct{}{}
+
+		if document.Json != nil {
+			name := document.ID + jsonExtension
+			if len(document.Name) > 0 {
+				name = document.Name + jsonExtension
+			}
+
+			entries = append(entries, core.ArchiveEntry{
+				Name:     uniqueName(names, name),
+				Document: document,
+				Json:     document.Json,
+			})
+		}
+
+		ownerID := fileOwnerID(document, userID)
+		if !s.hasFile(ownerID, document) {
+			continue
+		}
+
+		entries = append(entries, core.ArchiveEntry{
+			Name:     uniqueName(names, document.Name),
+			Document: document,
+			Open:     s.fileOpener(ownerID, document.Name),
+		})
+	}
+
+	return entries
+}
+
+// fileOpener defers opening the file until the entry is written, so only one
+// file of an archive is open at a time.
+func (s *Service) fileOpener(userID string, fileName string) func() (io.ReadCloser, fs.FileInfo, error) {
+	return func() (io.ReadCloser, fs.FileInfo, error) {
+		return s.FileRepo.OpenFile(userID, fileName)
+	}
+}
+
+// uniqueName appends a counter to names already used in the archive,
+// e.g. "report (1).pdf".
+func uniqueName(used map[string]struct{}, name string) string {
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+
+	ext := path.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	candidate := name
+
+	for i := 1; ; i++ {
+		if _, ok := used[candidate]; !ok {
+			used[candidate] = struct{}{}
+
+			return candidate
+		}
+
+		candidate = fmt.Sprintf("%s (%d)%s", base, i, ext)
+	}
+}
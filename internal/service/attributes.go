@@ -0,0 +1,89 @@
+package service
+
+import (
+	"strings"
+
+	"github.com/GroVlAn/doc-store/internal/core"
+	"github.com/GroVlAn/doc-store/internal/core/e"
+)
+
+const (
+	maxAttributes        = 64
+	maxAttributeKeyLen   = 64
+	maxAttributeValueLen = 1024
+
+	attributesSortPrefix = "attributes."
+)
+
+var documentSortFields = map[string]struct{}{
+	"name":    {},
+	"mime":    {},
+	"created": {},
+}
+
+func validateAttributes(attributes core.Attributes) error {
+	if len(attributes) > maxAttributes {
+		return e.ErrInvalidAttribute
+	}
+
+	for key, value := range attributes {
+		if err := validateAttributeKey(key); err != nil {
+			return err
+		}
+
+		if len(value) > maxAttributeValueLen {
+			return e.ErrInvalidAttribute
+		}
+	}
+
+	return nil
+}
+
+// validateAttributeKey rejects keys that would be interpreted by mongo
+// as a nested path or an operator.
+func validateAttributeKey(key string) error {
+	if len(key) == 0 || len(key) > maxAttributeKeyLen {
+		return e.ErrInvalidAttribute
+	}
+
+	if strings.ContainsAny(key, ".$\x00") {
+		return e.ErrInvalidAttribute
+	}
+
+	return nil
+}
+
+// validateFilter checks the attributes, the key and the sort of a documents
+// filter. The key is matched as a field, an operator is rejected.
+func validateFilter(filter core.DocumentFilter) error {
+	if err := validateAttributes(filter.Attributes); err != nil {
+		return err
+	}
+
+	if strings.HasPrefix(filter.Key, "$") {
+		return e.ErrInvalidFilter
+	}
+
+	return validateSort(filter.Sort, filter.Order)
+}
+
+func validateSort(sort string, order string) error {
+	if len(order) > 0 && order != core.SortAsc && order != core.SortDesc {
+		return e.ErrInvalidSort
+	}
+
+	if len(sort) == 0 {
+		return nil
+	}
+
+	if _, ok := documentSortFields[sort]; ok {
+		return nil
+	}
+
+	key, ok := strings.CutPrefix(sort, attributesSortPrefix)
+	if !ok || validateAttributeKey(key) != nil {
+		return e.ErrInvalidSort
+	}
+
+	return nil
+}
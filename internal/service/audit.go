@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/GroVlAn/doc-store/internal/core"
+	"github.com/GroVlAn/doc-store/internal/core/e"
+	"github.com/google/uuid"
+)
+
+// RecordAudit stores the event. When the actor is not known yet it is taken
+// from the principal, an unauthenticated request leaves the actor empty.
+func (s *Service) RecordAudit(principal core.Principal, event core.AuditEvent) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.DefaultTimeout)
+	defer cancel()
+
+	if len(event.Actor) == 0 {
+		event.Actor = principal.Login
+	}
+
+	event.ID = uuid.NewString()
+	event.Time = time.Now()
+
+	if err := s.AuditRepo.CreateAuditEvent(ctx, event); err != nil {
+		return fmt.Errorf("creating audit event: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Service) AuditLog(principal core.Principal, filter core.AuditFilter) ([]core.AuditEvent, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.DefaultTimeout)
+	defer cancel()
+
+	if err := s.requireAdmin(ctx, principal); err != nil {
+		return nil, err
+	}
+
+	events, err := s.AuditRepo.AuditEvents(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("getting audit events: %w", err)
+	}
+
+	return events, nil
+}
+
+// DocumentAudit returns the access history of a document to its owner or
+// to an admin.
+func (s *Service) DocumentAudit(principal core.Principal, documentID string, filter core.AuditFilter) ([]core.AuditEvent, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.DefaultTimeout)
+	defer cancel()
+
+	document, err := s.DocumentRepo.DocumentByID(ctx, documentID)
+	if err != nil {
+		return nil, fmt.Errorf("getting document: %w", err)
+	}
+
+	if document.OwnerID != principal.UserID {
+		admin, err := s.isAdmin(ctx, principal)
+		if err != nil {
+			return nil, err
+		}
+		if !admin {
+			return nil, e.ErrForbidden
+		}
+	}
+
+	filter.DocumentID = documentID
+
+	events, err := s.AuditRepo.AuditEvents(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("getting audit events: %w", err)
+	}
+
+	return events, nil
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/GroVlAn/doc-store/internal/core"
+	"github.com/GroVlAn/doc-store/internal/core/e"
+	"github.com/google/uuid"
+)
+
+const ProviderPassword = "password"
+
+// PasswordAuthenticator checks the bcrypt hash stored with the user. Users
+// without a password, e.g. provisioned by LDAP or OIDC, are left to the next
+// authenticator.
+type PasswordAuthenticator struct {
+	users  userRepo
+	hashes *HashLimiter
+}
+
+func NewPasswordAuthenticator(users userRepo, hashes *HashLimiter) *PasswordAuthenticator {
+	return &PasswordAuthenticator{users: users, hashes: hashes}
+}
+
+func (a *PasswordAuthenticator) Authenticate(ctx context.Context, login string, password string) (core.Identity, error) {
+	user, err := a.users.User(ctx, login)
+	if err != nil {
+		return core.Identity{}, fmt.Errorf("getting user: %w", err)
+	}
+
+	if len(user.Password) == 0 {
+		return core.Identity{}, e.ErrUserNotFound
+	}
+
+	if err := a.hashes.Compare(ctx, user.Password, password); err != nil {
+		return core.Identity{}, err
+	}
+
+	return core.Identity{Login: user.Login, Provider: ProviderPassword}, nil
+}
+
+func (s *Service) authenticate(ctx context.Context, login string, password string) (core.Identity, error) {
+	if len(login) == 0 {
+		return core.Identity{}, e.ErrInvalidLogin
+	}
+
+	err := e.ErrUserNotFound
+
+	for _, a := range s.Authenticators {
+		var identity core.Identity
+
+		identity, err = a.Authenticate(ctx, login, password)
+		if err == nil {
+			return identity, nil
+		}
+		if !errors.Is(err, e.ErrUserNotFound) {
+			return core.Identity{}, err
+		}
+	}
+
+	return core.Identity{}, err
+}
+
+// identityUser returns the user of the identity, provisions it when allowed
+// and applies the role mapped by the authenticator. Password identities carry
+// no role and keep the stored one, directory identities always carry one. An
+// external identity only signs in a user its provider provisioned, a directory
+// entry sharing the login of another user is refused.
+func (s *Service) identityUser(ctx context.Context, identity core.Identity) (core.User, error) {
+	user, err := s.UserRepo.User(ctx, identity.Login)
+	switch {
+	case err == nil:
+		if identity.Provider != ProviderPassword && identity.Provider != user.Provider {
+			return core.User{}, e.ErrUserAlreadyExist
+		}
+	case !errors.Is(err, e.ErrUserNotFound):
+		return core.User{}, fmt.Errorf("getting user: %w", err)
+	case identity.Provider == ProviderPassword || !s.AuthProvision:
+		return core.User{}, e.ErrUserNotFound
+	default:
+		return s.provisionUser(ctx, identity)
+	}
+
+	if len(identity.Role) > 0 && identity.Role != user.Role {
+		if err := s.UserRepo.UpdateUserRole(ctx, user.ID, identity.Role); err != nil {
+			return core.User{}, fmt.Errorf("updating user role: %w", err)
+		}
+		user.Role = identity.Role
+	}
+
+	return user, nil
+}
+
+func (s *Service) provisionUser(ctx context.Context, identity core.Identity) (core.User, error) {
+	user := core.User{
+		ID:       uuid.NewString(),
+		Login:    identity.Login,
+		Role:     identity.Role,
+		Provider: identity.Provider,
+	}
+	if len(user.Role) == 0 {
+		user.Role = core.RoleUser
+	}
+
+	if err := s.UserRepo.CreateUser(ctx, user); err != nil {
+		return core.User{}, fmt.Errorf("creating new user: %w", err)
+	}
+
+	return user, nil
+}
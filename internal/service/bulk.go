@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/GroVlAn/doc-store/internal/core"
+	"github.com/GroVlAn/doc-store/internal/core/e"
+)
+
+// CreateDocuments creates every document on its own, a failed item doesn't
+// stop the others.
+func (s *Service) CreateDocuments(principal core.Principal, documents []core.BulkDocument) ([]core.BulkResult, error) {
+	results := make([]core.BulkResult, 0, len(documents))
+
+	for i, item := range documents {
+		ctx, cancel := context.WithTimeout(context.Background(), s.DefaultTimeout)
+
+		document, err := s.createDocument(ctx, principal, item.Meta, item.File)
+		cancel()
+
+		results = append(results, core.BulkResult{
+			Index: i,
+			ID:    document.ID,
+			Name:  item.Meta.Name,
+			Err:   err,
+		})
+	}
+
+	return results, nil
+}
+
+// DeleteDocuments moves the listed documents and the documents matching the
+// filter to the trash, each one on its own. The filter only matches documents
+// owned by the caller.
+func (s *Service) DeleteDocuments(principal core.Principal, documentIDs []string, filter *core.DocumentFilter) ([]core.BulkResult, error) {
+	if filter != nil {
+		ids, err := s.filterDocumentIDs(principal, *filter)
+		if err != nil {
+			return nil, err
+		}
+
+		documentIDs = append(documentIDs, ids...)
+	}
+
+	if len(documentIDs) == 0 {
+		return nil, e.ErrEmptyBody
+	}
+
+	results := make([]core.BulkResult, 0, len(documentIDs))
+
+	for i, documentID := range documentIDs {
+		ctx, cancel := context.WithTimeout(context.Background(), s.DefaultTimeout)
+
+		err := s.deleteDocument(ctx, principal, documentID, nil)
+		cancel()
+
+		results = append(results, core.BulkResult{
+			Index: i,
+			ID:    documentID,
+			Err:   err,
+		})
+	}
+
+	return results, nil
+}
+
+func (s *Service) filterDocumentIDs(principal core.Principal, filter core.DocumentFilter) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.DefaultTimeout)
+	defer cancel()
+
+	// an empty filter would match every document of the caller
+	if !hasCriteria(filter) {
+		return nil, e.ErrInvalidFilter
+	}
+
+	filter.Login = principal.Login
+	filter.OwnerID = principal.UserID
+
+	if err := validateFilter(filter); err != nil {
+		return nil, err
+	}
+
+	documents, err := s.DocumentRepo.DocumentsList(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("getting documents list: %w", err)
+	}
+
+	ids := make([]string, 0, len(documents))
+	for _, document := range documents {
+		ids = append(ids, document.ID)
+	}
+
+	return ids, nil
+}
+
+func hasCriteria(filter core.DocumentFilter) bool {
+	return (len(filter.Key) > 0 && len(filter.Value) > 0) ||
+		len(filter.Attributes) > 0 ||
+		len(filter.Tag) > 0 ||
+		len(filter.Folder) > 0
+}
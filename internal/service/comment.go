@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/GroVlAn/doc-store/internal/core"
+	"github.com/GroVlAn/doc-store/internal/core/e"
+	"github.com/google/uuid"
+)
+
+const (
+	maxCommentLen = 10000
+)
+
+// CreateComment adds a comment to a document visible to the token owner. A
+// reply to a reply joins the thread of its parent.
+func (s *Service) CreateComment(principal core.Principal, documentID string, comment core.Comment) (core.Comment, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.DefaultTimeout)
+	defer cancel()
+
+	if _, err := s.commentDocument(ctx, principal, documentID); err != nil {
+		return core.Comment{}, err
+	}
+
+	if err := validateComment(comment); err != nil {
+		return core.Comment{}, err
+	}
+
+	if len(comment.ParentID) > 0 {
+		parent, err := s.CommentRepo.Comment(ctx, documentID, comment.ParentID)
+		if err != nil {
+			return core.Comment{}, err
+		}
+
+		if len(parent.ParentID) > 0 {
+			comment.ParentID = parent.ParentID
+		}
+	}
+
+	comment.ID = uuid.NewString()
+	comment.Token = ""
+	comment.DocumentID = documentID
+	comment.Author = principal.Login
+	comment.Resolved = false
+	comment.ResolvedBy = ""
+	comment.Created = time.Now()
+	comment.Updated = nil
+
+	if err := s.CommentRepo.CreateComment(ctx, comment); err != nil {
+		return core.Comment{}, fmt.Errorf("creating comment: %w", err)
+	}
+
+	return comment, nil
+}
+
+func (s *Service) Comments(principal core.Principal, documentID string) ([]core.Comment, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.DefaultTimeout)
+	defer cancel()
+
+	if _, err := s.commentDocument(ctx, principal, documentID); err != nil {
+		return nil, err
+	}
+
+	comments, err := s.CommentRepo.Comments(ctx, documentID)
+	if err != nil {
+		return nil, fmt.Errorf("getting comments: %w", err)
+	}
+
+	return comments, nil
+}
+
+// UpdateComment edits the body of a comment, only its author may do it.
+func (s *Service) UpdateComment(principal core.Principal, documentID string, commentID string, body string) (core.Comment, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.DefaultTimeout)
+	defer cancel()
+
+	if _, err := s.commentDocument(ctx, principal, documentID); err != nil {
+		return core.Comment{}, err
+	}
+
+	comment, err := s.CommentRepo.Comment(ctx, documentID, commentID)
+	if err != nil {
+		return core.Comment{}, err
+	}
+
+	if comment.Author != principal.Login {
+		return core.Comment{}, e.ErrForbidden
+	}
+
+	comment.Body = body
+	if err := validateComment(comment); err != nil {
+		return core.Comment{}, err
+	}
+
+	updated := time.Now()
+
+	if err := s.CommentRepo.UpdateComment(ctx, commentID, body, updated); err != nil {
+		return core.Comment{}, fmt.Errorf("updating comment: %w", err)
+	}
+
+	comment.Updated = &updated
+
+	return comment, nil
+}
+
+// ResolveComment resolves or reopens a comment, anyone who can see the
+// document may do it.
+func (s *Service) ResolveComment(principal core.Principal, documentID string, commentID string, resolved bool) (core.Comment, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.DefaultTimeout)
+	defer cancel()
+
+	if _, err := s.commentDocument(ctx, principal, documentID); err != nil {
+		return core.Comment{}, err
+	}
+
+	comment, err := s.CommentRepo.Comment(ctx, documentID, commentID)
+	if err != nil {
+		return core.Comment{}, err
+	}
+
+	comment.Resolved = resolved
+	comment.ResolvedBy = ""
+	if resolved {
+		comment.ResolvedBy = principal.Login
+	}
+
+	if err := s.CommentRepo.ResolveComment(ctx, commentID, comment.ResolvedBy); err != nil {
+		return core.Comment{}, fmt.Errorf("resolving comment: %w", err)
+	}
+
+	return comment, nil
+}
+
+// DeleteComment deletes a comment with its replies. It is allowed for the
+// comment author, the document owner and admins.
+func (s *Service) DeleteComment(principal core.Principal, documentID string, commentID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.DefaultTimeout)
+	defer cancel()
+
+	document, err := s.commentDocument(ctx, principal, documentID)
+	if err != nil {
+		return err
+	}
+
+	comment, err := s.CommentRepo.Comment(ctx, documentID, commentID)
+	if err != nil {
+		return err
+	}
+
+	if comment.Author != principal.Login && document.OwnerID != principal.UserID {
+		admin, err := s.isAdmin(ctx, principal)
+		if err != nil {
+			return err
+		}
+		if !admin {
+			return e.ErrForbidden
+		}
+	}
+
+	if err := s.CommentRepo.DeleteComment(ctx, commentID); err != nil {
+		return fmt.Errorf("deleting comment: %w", err)
+	}
+
+	return nil
+}
+
+// commentDocument returns the document if the token owner is in its grant.
+func (s *Service) commentDocument(ctx context.Context, principal core.Principal, documentID string) (core.Document, error) {
+	document, err := s.DocumentRepo.Document(ctx, principal.Login, documentID)
+	if err != nil {
+		return core.Document{}, e.ErrNoDocuments
+	}
+
+	return document, nil
+}
+
+func validateComment(comment core.Comment) error {
+	body := strings.TrimSpace(comment.Body)
+	if len(body) == 0 || len(body) > maxCommentLen {
+		return e.ErrInvalidComment
+	}
+
+	if len(comment.JSONPath) > 0 && !strings.HasPrefix(comment.JSONPath, "/") {
+		return e.ErrInvalidComment
+	}
+
+	if comment.Page < 0 {
+		return e.ErrInvalidComment
+	}
+
+	return nil
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/GroVlAn/doc-store/internal/core"
@@ -11,74 +13,169 @@ import (
 	"github.com/google/uuid"
 )
 
-func (s *Service) CreateDocument(document core.Document, file []byte) error {
+func (s *Service) CreateDocument(principal core.Principal, document core.Document, file []byte) (core.Document, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), s.DefaultTimeout)
 	defer cancel()
 
-	tokenDetails, err := s.parseToken(document.Token)
-	if err != nil {
-		return &e.ErrInvalidToken{Msg: "invalid token", Err: err}
+	if s.RequireIfMatch && document.IfMatch == nil {
+		existDocument, err := s.existDocument(ctx, principal.Login, document.Name)
+		if err != nil {
+			return core.Document{}, err
+		}
+
+		if len(existDocument.ID) > 0 {
+			return core.Document{}, e.ErrPreconditionRequired
+		}
+	}
+
+	return s.createDocument(ctx, principal, document, file)
+}
+
+func (s *Service) createDocument(
+	ctx context.Context,
+	principal core.Principal,
+	document core.Document,
+	file []byte,
+) (core.Document, error) {
+	if err := validateAttributes(document.Attributes); err != nil {
+		return core.Document{}, err
+	}
+
+	if err := validateName(document.Name); err != nil {
+		return core.Document{}, err
 	}
 
+	ifMatch := document.IfMatch
+
 	document.ID = uuid.NewString()
+	document.Revision = 1
+	document.IfMatch = nil
+	document.Token = ""
+	document.OwnerID = principal.UserID
+	document.File = file != nil
+	document.LegalHold = false
+	document.Lock = nil
+	document.DeletedAt = nil
 	document.Created = time.Now()
-	document.Grant = append(document.Grant, tokenDetails["login"].(string))
 
-	existDocument, err := s.DocumentRepo.DocumentByName(ctx, tokenDetails["login"].(string), document.Name)
-	if err != nil && !errors.Is(err, e.ErrNoDocuments) {
-		return fmt.Errorf("getting error by name: %w", err)
+	if err := setExpiration(&document); err != nil {
+		return core.Document{}, err
+	}
+	document.Grant = append(document.Grant, principal.Login)
+
+	existDocument, err := s.existDocument(ctx, principal.Login, document.Name)
+	if err != nil {
+		return core.Document{}, err
+	}
+
+	if len(existDocument.ID) == 0 && ifMatch != nil {
+		return core.Document{}, e.ErrPreconditionFailed
 	}
 
 	if len(existDocument.ID) > 0 {
-		err = s.DocumentRepo.DeleteDocument(ctx, tokenDetails["login"].(string), existDocument.ID)
-		if err != nil {
-			return fmt.Errorf("deleting document: %w", err)
+		if err := s.checkPrecondition(existDocument, ifMatch); err != nil {
+			return core.Document{}, err
 		}
-		s.Cache.Delete(s.Cache.GenerateKey(core.AddrCacheDocument, tokenDetails["user_id"].(string), document.ID))
+
+		if err := s.checkRetention(ctx, existDocument); err != nil {
+			return core.Document{}, err
+		}
+
+		if err := checkLock(existDocument, principal.Login); err != nil {
+			return core.Document{}, err
+		}
+
+		if existDocument.Lock.Active(document.Created) {
+			document.Lock = existDocument.Lock
+		}
+
+		if err := s.saveVersion(ctx, existDocument, principal.UserID); err != nil {
+			return core.Document{}, err
+		}
+
+		document.ID = existDocument.ID
+		document.Revision = existDocument.Revision + 1
 	}
 
-	err = s.DocumentRepo.CreateDocument(ctx, document)
+	event := core.EventDocumentCreated
+	if len(existDocument.ID) > 0 {
+		event = core.EventDocumentReplaced
+	}
+
+	err = s.inTransaction(ctx, func(ctx context.Context) error {
+		if len(existDocument.ID) > 0 {
+			if err := s.DocumentRepo.ReplaceDocument(ctx, principal.Login, document, existDocument.Revision); err != nil {
+				return fmt.Errorf("replacing document: %w", err)
+			}
+		} else {
+			if err := s.DocumentRepo.CreateDocument(ctx, document); err != nil {
+				return fmt.Errorf("creating document: %w", err)
+			}
+		}
+
+		return s.enqueueEvent(ctx, event, principal.Login, document)
+	})
 	if err != nil {
-		return fmt.Errorf("creating document: %w", err)
+		return core.Document{}, err
 	}
 
-	if file == nil {
-		return nil
+	if file != nil {
+		if err := s.createFile(principal.UserID, document.Name, file); err != nil {
+			fileErr := fmt.Errorf("creating file: %w", err)
+
+			if revertErr := s.revertCreate(ctx, principal.Login, document, existDocument); revertErr != nil {
+				return core.Document{}, errors.Join(fileErr, revertErr)
+			}
+
+			return core.Document{}, fileErr
+		}
 	}
-	err = s.createFile(tokenDetails["user_id"].(string), document.Name, file)
-	if err != nil {
-		return fmt.Errorf("creating file: %w", err)
+
+	if len(existDocument.ID) > 0 {
+		s.Cache.Delete(s.documentKey(existDocument.ID))
 	}
 
-	s.Cache.Set(s.Cache.GenerateKey(core.AddrCacheDocument, tokenDetails["user_id"].(string), document.ID), document)
+	if file != nil {
+		s.Cache.Set(s.documentKey(document.ID), document)
+	}
 
-	return nil
+	s.notify(event, principal.Login, document)
+
+	return document, nil
 }
 
-func (s *Service) Document(token string, documentID string) (core.Document, string, error) {
+func (s *Service) Document(principal core.Principal, documentID string) (core.Document, string, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), s.DefaultTimeout)
 	defer cancel()
 
-	tokenDetails, err := s.parseToken(token)
-	if err != nil {
-		return core.Document{}, "", &e.ErrInvalidToken{Msg: "invalid token", Err: err}
-	}
+	var (
+		document core.Document
+		err      error
+	)
 
-	var document core.Document
-
-	documentCache, ok := s.Cache.Get(s.Cache.GenerateKey(core.AddrCacheDocument, tokenDetails["user_id"].(string), documentID))
+	// the cached document is shared by its grantees, the grant is checked here
+	documentCache, ok := s.Cache.Get(s.documentKey(documentID))
 	if ok {
 		document = documentCache.(core.Document)
-	} else {
-		document, err = s.DocumentRepo.Document(ctx, tokenDetails["login"].(string), documentID)
+	}
+
+	if !ok || !slices.Contains(document.Grant, principal.Login) {
+		document, err = s.DocumentRepo.Document(ctx, principal.Login, documentID)
 		if err != nil {
 			return core.Document{}, "", e.ErrNoDocuments
 		}
 	}
 
-	s.Cache.Set(s.Cache.GenerateKey(core.AddrCacheDocument, tokenDetails["user_id"].(string), documentID), document)
+	if documentExpired(document, time.Now()) && !document.LegalHold {
+		s.Cache.Delete(s.documentKey(documentID))
+
+		return core.Document{}, "", e.ErrNoDocuments
+	}
 
-	file, err := s.FileRepo.File(tokenDetails["user_id"].(string), document.Name)
+	s.Cache.Set(s.documentKey(documentID), document)
+	hideExpiredLock(&document, time.Now())
+
+	file, err := s.FileRepo.File(fileOwnerID(document, principal.UserID), document.Name)
 	if err != nil {
 		return core.Document{}, "", fmt.Errorf("loading file: %w", err)
 	}
@@ -86,53 +183,222 @@ func (s *Service) Document(token string, documentID string) (core.Document, stri
 	return document, file, nil
 }
 
-func (s *Service) DocumentsList(token string, filter core.DocumentFilter) ([]core.Document, error) {
+func (s *Service) DocumentsList(principal core.Principal, filter core.DocumentFilter) ([]core.Document, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), s.DefaultTimeout)
 	defer cancel()
 
-	tokenDetails, err := s.parseToken(token)
-	if err != nil {
-		return nil, &e.ErrInvalidToken{Msg: "invalid token", Err: err}
-	}
+	filter.Login = principal.Login
 
-	filter.Login = tokenDetails["login"].(string)
+	if err := validateFilter(filter); err != nil {
+		return nil, err
+	}
 
 	documentsList, err := s.DocumentRepo.DocumentsList(ctx, filter)
 	if err != nil {
 		return nil, fmt.Errorf("getting documents list: %w", err)
 	}
 
+	now := time.Now()
+	for i := range documentsList {
+		hideExpiredLock(&documentsList[i], now)
+	}
+
 	return documentsList, nil
 }
 
-func (s *Service) DeleteDocument(token string, documentID string) error {
+func (s *Service) UpdateDocument(principal core.Principal, documentID string, update core.DocumentUpdate) (core.Document, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), s.DefaultTimeout)
 	defer cancel()
 
-	tokenDetails, err := s.parseToken(token)
+	if err := s.requirePrecondition(update.IfMatch); err != nil {
+		return core.Document{}, err
+	}
+
+	if err := validateAttributes(update.Attributes); err != nil {
+		return core.Document{}, err
+	}
+
+	for _, key := range update.Unset {
+		if err := validateAttributeKey(key); err != nil {
+			return core.Document{}, err
+		}
+	}
+
+	document, err := s.DocumentRepo.Document(ctx, principal.Login, documentID)
 	if err != nil {
+		return core.Document{}, e.ErrNoDocuments
+	}
+
+	if err := s.checkPrecondition(document, update.IfMatch); err != nil {
+		return core.Document{}, err
+	}
+
+	if err := checkLock(document, principal.Login); err != nil {
+		return core.Document{}, err
+	}
+
+	err = s.inTransaction(ctx, func(ctx context.Context) error {
+		err := s.DocumentRepo.UpdateDocumentAttributes(
+			ctx,
+			principal.Login,
+			documentID,
+			update.Attributes,
+			update.Unset,
+			expectedRevision(document, update.IfMatch),
+		)
+		if err != nil {
+			return fmt.Errorf("updating document attributes: %w", err)
+		}
+
+		document, err = s.DocumentRepo.Document(ctx, principal.Login, documentID)
+		if err != nil {
+			return e.ErrNoDocuments
+		}
+		hideExpiredLock(&document, time.Now())
+
+		return s.enqueueEvent(ctx, core.EventDocumentUpdated, principal.Login, document)
+	})
+	if err != nil {
+		return core.Document{}, err
+	}
+
+	s.Cache.Delete(s.documentKey(documentID))
+
+	s.notify(core.EventDocumentUpdated, principal.Login, document)
+
+	return document, nil
+}
+
+func (s *Service) ShareDocument(principal core.Principal, documentID string, grant []string) (core.Document, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.DefaultTimeout)
+	defer cancel()
+
+	if len(grant) == 0 {
+		return core.Document{}, e.ErrEmptyBody
+	}
+
+	var document core.Document
+
+	err := s.inTransaction(ctx, func(ctx context.Context) error {
+		if err := s.DocumentRepo.AddGrant(ctx, principal.Login, documentID, grant); err != nil {
+			return fmt.Errorf("sharing document: %w", err)
+		}
+
+		var err error
+		document, err = s.DocumentRepo.Document(ctx, principal.Login, documentID)
+		if err != nil {
+			return e.ErrNoDocuments
+		}
+
+		return s.enqueueEvent(ctx, core.EventDocumentShared, principal.Login, document)
+	})
+	if err != nil {
+		return core.Document{}, err
+	}
+
+	s.Cache.Delete(s.documentKey(documentID))
+
+	s.notify(core.EventDocumentShared, principal.Login, document)
+
+	return document, nil
+}
+
+func (s *Service) DeleteDocument(principal core.Principal, documentID string, ifMatch *int64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.DefaultTimeout)
+	defer cancel()
+
+	if err := s.requirePrecondition(ifMatch); err != nil {
 		return err
 	}
 
-	document, err := s.DocumentRepo.Document(ctx, tokenDetails["login"].(string), documentID)
+	return s.deleteDocument(ctx, principal, documentID, ifMatch)
+}
+
+func (s *Service) deleteDocument(
+	ctx context.Context,
+	principal core.Principal,
+	documentID string,
+	ifMatch *int64,
+) error {
+	document, err := s.DocumentRepo.Document(ctx, principal.Login, documentID)
 	if err != nil {
 		return e.ErrNoDocuments
 	}
 
-	if err := s.deleteFile(tokenDetails["user_id"].(string), document.Name); err != nil {
-		return fmt.Errorf("deleting file: %w", err)
+	if err := s.checkPrecondition(document, ifMatch); err != nil {
+		return err
+	}
+
+	if err := s.checkRetention(ctx, document); err != nil {
+		return err
+	}
+
+	if err := checkLock(document, principal.Login); err != nil {
+		return err
 	}
 
-	err = s.DocumentRepo.DeleteDocument(ctx, tokenDetails["login"].(string), documentID)
+	ownerID := fileOwnerID(document, principal.UserID)
+
+	err = s.inTransaction(ctx, func(ctx context.Context) error {
+		err := s.DocumentRepo.TrashDocument(
+			ctx,
+			principal.Login,
+			documentID,
+			time.Now(),
+			expectedRevision(document, ifMatch),
+		)
+		if err != nil {
+			return fmt.Errorf("moving document to trash: %w", err)
+		}
+
+		return s.enqueueEvent(ctx, core.EventDocumentDeleted, principal.Login, document)
+	})
 	if err != nil {
-		return fmt.Errorf("deleting document: %w", err)
+		return err
+	}
+
+	if s.hasFile(ownerID, document) {
+		if err := s.FileRepo.TrashFile(ownerID, document.Name, document.ID); err != nil {
+			fileErr := fmt.Errorf("moving file to trash: %w", err)
+
+			revertErr := s.revertChange(ctx, core.EventDocumentCreated, principal.Login, document, func(ctx context.Context) error {
+				return s.DocumentRepo.RestoreDocument(ctx, principal.Login, documentID)
+			})
+			if revertErr != nil {
+				return errors.Join(fileErr, revertErr)
+			}
+
+			return fileErr
+		}
 	}
 
-	s.Cache.Delete(s.Cache.GenerateKey(core.AddrCacheDocument, tokenDetails["user_id"].(string), documentID))
+	s.Cache.Delete(s.documentKey(documentID))
+
+	s.notify(core.EventDocumentDeleted, principal.Login, document)
 
 	return nil
 }
 
+// revertCreate puts back the replaced document, or removes the created one,
+// when its file couldn't be written.
+func (s *Service) revertCreate(ctx context.Context, login string, document core.Document, existDocument core.Document) error {
+	if len(existDocument.ID) == 0 {
+		return s.revertChange(ctx, core.EventDocumentDeleted, login, document, func(ctx context.Context) error {
+			return s.DocumentRepo.PurgeDocument(ctx, document.ID)
+		})
+	}
+
+	return s.revertChange(ctx, core.EventDocumentReplaced, login, existDocument, func(ctx context.Context) error {
+		return s.DocumentRepo.ReplaceDocument(ctx, login, existDocument, document.Revision)
+	})
+}
+
+// documentKey is the cache key of a document. One entry serves every grantee,
+// so a change or purge invalidates it for all of them.
+func (s *Service) documentKey(documentID string) string {
+	return s.Cache.GenerateKey(core.AddrCacheDocument, documentID)
+}
+
 func (s *Service) setLoginToFilter(ctx context.Context, userID string, filter *core.DocumentFilter) error {
 	if len(filter.Login) == 0 {
 		return nil
@@ -169,6 +435,51 @@ func (s *Service) deleteExistedFile(userID string, fileName string) error {
 	return s.deleteFile(userID, fileName)
 }
 
+// existDocument finds the document replaced by an upload with the same name.
+// Documents without a name, e.g. JSON only ones, never replace each other.
+func (s *Service) existDocument(ctx context.Context, login string, name string) (core.Document, error) {
+	if len(name) == 0 {
+		return core.Document{}, nil
+	}
+
+	document, err := s.DocumentRepo.DocumentByName(ctx, login, name)
+	if err != nil && !errors.Is(err, e.ErrNoDocuments) {
+		return core.Document{}, fmt.Errorf("getting document by name: %w", err)
+	}
+
+	return document, nil
+}
+
+// validateName rejects names which aren't a single path element, the name
+// of an uploaded file is used as its file name.
+func validateName(name string) error {
+	if len(name) == 0 {
+		return nil
+	}
+
+	if name == "." || strings.Contains(name, "..") || strings.ContainsAny(name, "/\\\x00") {
+		return e.ErrInvalidName
+	}
+
+	return nil
+}
+
+// hasFile reports whether a file is stored for the document. Only the flag
+// recorded at upload is trusted, the name of a JSON only document is chosen
+// by the client and may point at any file.
+func (s *Service) hasFile(ownerID string, document core.Document) bool {
+	return document.File && s.FileRepo.FileExist(ownerID, document.Name)
+}
+
+// fileOwnerID returns the ID of the user whose directory holds the document file.
+func fileOwnerID(document core.Document, userID string) string {
+	if len(document.OwnerID) > 0 {
+		return document.OwnerID
+	}
+
+	return userID
+}
+
 func (s *Service) deleteFile(userID string, fileName string) error {
 	if err := s.FileRepo.DeleteFile(userID, fileName); err != nil {
 		return err
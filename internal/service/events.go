@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/GroVlAn/doc-store/internal/core"
+	"github.com/GroVlAn/doc-store/internal/core/e"
+	"github.com/google/uuid"
+)
+
+// subscriptionCheckInterval is how often a subscription checks that its
+// session or API key is still valid.
+const subscriptionCheckInterval = time.Minute
+
+// SubscribeEvents streams events of documents visible to the token owner.
+// The stream is closed once the session or API key it was opened with is
+// revoked or the user is deleted. The returned function stops the
+// subscription and must be called.
+func (s *Service) SubscribeEvents(principal core.Principal) (<-chan core.FeedEvent, func(), error) {
+	login := principal.Login
+
+	events, unsubscribe := s.Events.Subscribe()
+	visible := make(chan core.FeedEvent)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(visible)
+
+		check := time.NewTicker(subscriptionCheckInterval)
+		defer check.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-check.C:
+				if err := s.withTimeout(func(ctx context.Context) error {
+					return s.checkPrincipal(ctx, principal)
+				}); err != nil {
+					return
+				}
+			case event, ok := <-events:
+				if !ok {
+					return
+				}
+				if !slices.Contains(event.Document.Grant, login) {
+					continue
+				}
+
+				select {
+				case visible <- feedEvent(event):
+				case <-done:
+					return
+				}
+			}
+		}
+	}()
+
+	var once sync.Once
+	stop := func() {
+		once.Do(func() {
+			close(done)
+			unsubscribe()
+		})
+	}
+
+	return visible, stop, nil
+}
+
+// checkPrincipal verifies that the user of the principal still exists and
+// that its API key or session hasn't been revoked or expired.
+func (s *Service) checkPrincipal(ctx context.Context, principal core.Principal) error {
+	if _, err := s.UserRepo.UserByID(ctx, principal.UserID); err != nil {
+		return e.ErrUserNotFound
+	}
+
+	now := time.Now()
+
+	switch {
+	case len(principal.APIKeyID) > 0:
+		keys, err := s.APIKeyRepo.APIKeys(ctx, principal.UserID)
+		if err != nil {
+			return fmt.Errorf("getting api keys: %w", err)
+		}
+
+		i := slices.IndexFunc(keys, func(key core.APIKey) bool { return key.ID == principal.APIKeyID })
+		if i < 0 {
+			return &e.ErrInvalidToken{Msg: "api key revoked"}
+		}
+		if keys[i].ExpiresAt != nil && !now.Before(*keys[i].ExpiresAt) {
+			return &e.ErrInvalidToken{Msg: "api key expired"}
+		}
+	case len(principal.SessionID) > 0:
+		tokens, err := s.TokenRepo.SessionTokens(ctx, principal.UserID, now)
+		if err != nil {
+			return fmt.Errorf("getting session tokens: %w", err)
+		}
+
+		if !slices.ContainsFunc(tokens, func(token core.AccessToken) bool { return token.FamilyID == principal.SessionID }) {
+			return &e.ErrInvalidToken{Msg: "session revoked"}
+		}
+	}
+
+	return nil
+}
+
+// notify publishes the event to the change feed once the change is stored,
+// its webhook deliveries are enqueued together with the change.
+func (s *Service) notify(eventType string, actor string, document core.Document) {
+	s.Events.Publish(core.DocumentEvent{
+		ID:       uuid.NewString(),
+		Type:     eventType,
+		Actor:    actor,
+		Time:     time.Now(),
+		Document: document,
+	})
+}
+
+func feedEvent(event core.DocumentEvent) core.FeedEvent {
+	document := event.Document
+
+	return core.FeedEvent{
+		ID:    event.ID,
+		Type:  event.Type,
+		Actor: event.Actor,
+		Time:  event.Time,
+		Document: core.EventDocument{
+			ID:         document.ID,
+			Name:       document.Name,
+			Mime:       document.Mime,
+			File:       document.File,
+			Public:     document.Public,
+			Created:    document.Created,
+			Grant:      document.Grant,
+			Attributes: document.Attributes,
+			Tags:       document.Tags,
+			Folder:     document.Folder,
+			LegalHold:  document.LegalHold,
+			DeletedAt:  document.DeletedAt,
+			ExpiresAt:  document.ExpiresAt,
+			Lock:       document.Lock,
+			Revision:   document.Revision,
+		},
+	}
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/GroVlAn/doc-store/internal/core"
+	"github.com/GroVlAn/doc-store/internal/core/e"
+)
+
+// RemoveExpired permanently removes documents whose expiration has passed.
+func (s *Service) RemoveExpired(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, s.DefaultTimeout)
+	defer cancel()
+
+	documents, err := s.DocumentRepo.ExpiredDocuments(ctx, time.Now())
+	if err != nil {
+		return fmt.Errorf("getting expired documents: %w", err)
+	}
+
+	var errs []error
+	for _, document := range documents {
+		err := s.checkRetention(ctx, document)
+		if errors.Is(err, e.ErrDocumentRetained) {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		if err := s.purgeDocument(ctx, document); err != nil {
+			errs = append(errs, fmt.Errorf("removing expired document %s: %w", document.ID, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// setExpiration resolves the document expiration from either an absolute
+// expires_at or a relative ttl, expires_at taking precedence.
+func setExpiration(document *core.Document) error {
+	if document.ExpiresAt != nil {
+		if !document.ExpiresAt.After(document.Created) {
+			return e.ErrInvalidTTL
+		}
+
+		return nil
+	}
+
+	if len(document.TTL) == 0 {
+		return nil
+	}
+
+	ttl, err := time.ParseDuration(document.TTL)
+	if err != nil || ttl <= 0 {
+		return e.ErrInvalidTTL
+	}
+
+	expiresAt := document.Created.Add(ttl)
+	document.ExpiresAt = &expiresAt
+
+	return nil
+}
+
+func documentExpired(document core.Document, now time.Time) bool {
+	return document.ExpiresAt != nil && !now.Before(*document.ExpiresAt)
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+
+	"github.com/GroVlAn/doc-store/internal/core/e"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// HashLimiter bounds the number of concurrent bcrypt operations, so password
+// checks can't take every CPU. Callers wait for a free slot until their
+// context is done.
+type HashLimiter struct {
+	slots chan struct{}
+}
+
+// NewHashLimiter allows n concurrent operations, one per CPU when n is not
+// positive.
+func NewHashLimiter(n int) *HashLimiter {
+	if n <= 0 {
+		n = runtime.NumCPU()
+	}
+
+	return &HashLimiter{slots: make(chan struct{}, n)}
+}
+
+// Compare returns e.ErrInvalidPassword when the password doesn't match the
+// hash or there is no hash.
+func (l *HashLimiter) Compare(ctx context.Context, hash string, password string) error {
+	if len(hash) == 0 {
+		return e.ErrInvalidPassword
+	}
+
+	if err := l.acquire(ctx); err != nil {
+		return err
+	}
+	defer l.release()
+
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return e.ErrInvalidPassword
+	}
+	if err != nil {
+		return fmt.Errorf("comparing hash and password: %w", err)
+	}
+
+	return nil
+}
+
+func (l *HashLimiter) Generate(ctx context.Context, password string, cost int) (string, error) {
+	if err := l.acquire(ctx); err != nil {
+		return "", err
+	}
+	defer l.release()
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), cost)
+	if err != nil {
+		return "", fmt.Errorf("generating password: %w", err)
+	}
+
+	return string(hash), nil
+}
+
+func (l *HashLimiter) acquire(ctx context.Context) error {
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%w: %w", e.ErrBusy, ctx.Err())
+	}
+}
+
+func (l *HashLimiter) release() {
+	<-l.slots
+}
+
+func (s *Service) hashPassword(ctx context.Context, password string) (string, error) {
+	return s.Hashes.Generate(ctx, password, s.HashCost)
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/GroVlAn/doc-store/internal/core"
+	"github.com/GroVlAn/doc-store/internal/core/e"
+)
+
+// LockDocument checks the document out for the token owner. Locking a
+// document again by its lock owner extends the lock.
+func (s *Service) LockDocument(principal core.Principal, documentID string, request core.LockRequest) (core.Document, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.DefaultTimeout)
+	defer cancel()
+
+	ttl := s.LockTTL
+	if len(request.TTL) > 0 {
+		var err error
+
+		ttl, err = time.ParseDuration(request.TTL)
+		if err != nil {
+			return core.Document{}, e.ErrInvalidLock
+		}
+	}
+
+	if ttl <= 0 || (s.LockMaxTTL > 0 && ttl > s.LockMaxTTL) {
+		return core.Document{}, e.ErrInvalidLock
+	}
+
+	login := principal.Login
+	now := time.Now()
+
+	lock := core.Lock{
+		Owner:     login,
+		Reason:    request.Reason,
+		Created:   now,
+		ExpiresAt: now.Add(ttl),
+	}
+
+	if err := s.DocumentRepo.LockDocument(ctx, login, documentID, lock); err != nil {
+		return core.Document{}, err
+	}
+
+	s.Cache.Delete(s.documentKey(documentID))
+
+	document, err := s.DocumentRepo.Document(ctx, login, documentID)
+	if err != nil {
+		return core.Document{}, e.ErrNoDocuments
+	}
+
+	return document, nil
+}
+
+// UnlockDocument checks the document in. Only the lock owner may unlock it,
+// unlocking a document without an active lock does nothing.
+func (s *Service) UnlockDocument(principal core.Principal, documentID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.DefaultTimeout)
+	defer cancel()
+
+	login := principal.Login
+
+	document, err := s.DocumentRepo.Document(ctx, login, documentID)
+	if err != nil {
+		return e.ErrNoDocuments
+	}
+
+	if !document.Lock.Active(time.Now()) {
+		return nil
+	}
+
+	if document.Lock.Owner != login {
+		return e.ErrDocumentLocked
+	}
+
+	if err := s.DocumentRepo.UnlockDocument(ctx, documentID, login); err != nil {
+		return fmt.Errorf("unlocking document: %w", err)
+	}
+
+	s.Cache.Delete(s.documentKey(documentID))
+
+	return nil
+}
+
+// ForceUnlockDocument removes a lock held by anyone. It is allowed for the
+// document owner and admins.
+func (s *Service) ForceUnlockDocument(principal core.Principal, documentID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.DefaultTimeout)
+	defer cancel()
+
+	document, err := s.DocumentRepo.DocumentByID(ctx, documentID)
+	if err != nil {
+		return err
+	}
+
+	if document.OwnerID != principal.UserID {
+		admin, err := s.isAdmin(ctx, principal)
+		if err != nil {
+			return err
+		}
+		if !admin {
+			return e.ErrForbidden
+		}
+	}
+
+	if err := s.DocumentRepo.UnlockDocument(ctx, documentID, ""); err != nil {
+		return fmt.Errorf("unlocking document: %w", err)
+	}
+
+	s.Cache.Delete(s.documentKey(documentID))
+
+	return nil
+}
+
+// checkLock returns e.ErrDocumentLocked when another user holds an active
+// lock on the document.
+func checkLock(document core.Document, login string) error {
+	if document.Lock.Active(time.Now()) && document.Lock.Owner != login {
+		return e.ErrDocumentLocked
+	}
+
+	return nil
+}
+
+// hideExpiredLock drops a lock which is no longer active from the document
+// returned to the caller.
+func hideExpiredLock(document *core.Document, now time.Time) {
+	if document.Lock != nil && !document.Lock.Active(now) {
+		document.Lock = nil
+	}
+}
@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/GroVlAn/doc-store/internal/core"
+	"github.com/GroVlAn/doc-store/internal/core/e"
+)
+
+const (
+	loginKeyPrefix     = "login:"
+	ipKeyPrefix        = "ip:"
+	twoFactorKeyPrefix = "2fa:"
+
+	// the delay doubles per failure, the shift is capped so it can't overflow
+	maxBackoffShift = 30
+)
+
+// LoginThrottle configures failed login tracking. After Free failures every
+// attempt waits BaseDelay doubled per further failure up to MaxDelay, after
+// Lockout failures the login or IP is locked for LockoutTime. Failures are
+// forgotten after Window without one.
+type LoginThrottle struct {
+	Window      time.Duration
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	LockoutTime time.Duration
+	Login       AttemptLimit
+	IP          AttemptLimit
+}
+
+type AttemptLimit struct {
+	Free    int
+	Lockout int
+}
+
+var defaultLoginThrottle = LoginThrottle{
+	Window:      time.Hour,
+	BaseDelay:   time.Second,
+	MaxDelay:    15 * time.Minute,
+	LockoutTime: 15 * time.Minute,
+	Login:       AttemptLimit{Free: 3, Lockout: 10},
+	IP:          AttemptLimit{Free: 20, Lockout: 100},
+}
+
+// reserveLogin reserves an attempt of the login and the IP of the client
+// before the password is verified, see reserveAttempt.
+func (s *Service) reserveLogin(ctx context.Context, login string, client core.Client) error {
+	throttle := s.loginThrottle()
+	limits := map[string]AttemptLimit{loginKeyPrefix + login: throttle.Login}
+
+	if len(client.IP) > 0 {
+		limits[ipKeyPrefix+client.IP] = throttle.IP
+	}
+
+	return s.reserveAttempt(ctx, limits)
+}
+
+// recordLogin settles a reserved login. A failed login keeps the failures, a
+// successful one clears the failures of the login and takes back the one of
+// the IP only, so a valid account can't be used to reset the counter of an IP.
+// Other errors take back the reserved failures.
+func (s *Service) recordLogin(ctx context.Context, login string, client core.Client, loginErr error) error {
+	if s.AttemptRepo == nil {
+		return nil
+	}
+
+	if errors.Is(loginErr, e.ErrInvalidPassword) || errors.Is(loginErr, e.ErrUserNotFound) {
+		return nil
+	}
+
+	if loginErr == nil {
+		if err := s.AttemptRepo.ClearLoginAttempts(ctx, loginKeyPrefix+login); err != nil {
+			return fmt.Errorf("clearing login attempts: %w", err)
+		}
+	} else if err := s.AttemptRepo.ReleaseLoginAttempt(ctx, loginKeyPrefix+login); err != nil {
+		return fmt.Errorf("releasing login attempt: %w", err)
+	}
+
+	if len(client.IP) > 0 {
+		if err := s.AttemptRepo.ReleaseLoginAttempt(ctx, ipKeyPrefix+client.IP); err != nil {
+			return fmt.Errorf("releasing login attempt: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// reserveAttempt counts an attempt for the keys before it is verified, so
+// parallel attempts can't pass the limit together. It returns
+// e.ErrTooManyAttempts while a key has to wait, including when an attempt
+// reserved in parallel raised the failures past the free ones. The caller
+// clears the keys on success.
+func (s *Service) reserveAttempt(ctx context.Context, limits map[string]AttemptLimit) error {
+	if s.AttemptRepo == nil {
+		return nil
+	}
+
+	keys := make([]string, 0, len(limits))
+	for key := range limits {
+		keys = append(keys, key)
+	}
+
+	now := time.Now()
+
+	attempts, err := s.AttemptRepo.LoginAttempts(ctx, keys, now)
+	if err != nil {
+		return fmt.Errorf("getting login attempts: %w", err)
+	}
+
+	throttle := s.loginThrottle()
+	failures := make(map[string]int, len(attempts))
+
+	var wait time.Duration
+
+	for _, attempt := range attempts {
+		failures[attempt.Key] = attempt.Failures
+		wait = max(wait, throttle.retryAfter(attempt, limits[attempt.Key], now))
+	}
+
+	if wait > 0 {
+		return &e.ErrTooManyAttempts{RetryAfter: wait}
+	}
+
+	expiresAt := now.Add(max(throttle.Window, throttle.LockoutTime, throttle.MaxDelay))
+
+	for _, key := range keys {
+		attempt, err := s.AttemptRepo.FailLogin(ctx, key, now, expiresAt)
+		if err != nil {
+			return fmt.Errorf("saving login attempt: %w", err)
+		}
+
+		if attempt.Failures > failures[key]+1 {
+			raced := core.LoginAttempt{Failures: attempt.Failures - 1, LastFailure: now}
+			wait = max(wait, throttle.retryAfter(raced, limits[key], now))
+		}
+	}
+
+	if wait > 0 {
+		return &e.ErrTooManyAttempts{RetryAfter: wait}
+	}
+
+	return nil
+}
+
+func (s *Service) loginThrottle() LoginThrottle {
+	throttle := s.Throttle
+	defaults := defaultLoginThrottle
+
+	if throttle.Window <= 0 {
+		throttle.Window = defaults.Window
+	}
+	if throttle.BaseDelay <= 0 {
+		throttle.BaseDelay = defaults.BaseDelay
+	}
+	if throttle.MaxDelay <= 0 {
+		throttle.MaxDelay = defaults.MaxDelay
+	}
+	if throttle.LockoutTime <= 0 {
+		throttle.LockoutTime = defaults.LockoutTime
+	}
+	if throttle.Login.Lockout <= 0 {
+		throttle.Login = defaults.Login
+	}
+	if throttle.IP.Lockout <= 0 {
+		throttle.IP = defaults.IP
+	}
+
+	return throttle
+}
+
+// retryAfter returns how long the key has to wait before the next attempt.
+func (t LoginThrottle) retryAfter(attempt core.LoginAttempt, limit AttemptLimit, now time.Time) time.Duration {
+	var delay time.Duration
+
+	switch {
+	case attempt.Failures >= limit.Lockout:
+		delay = t.LockoutTime
+	case attempt.Failures >= limit.Free:
+		shift := min(attempt.Failures-limit.Free, maxBackoffShift)
+		delay = min(t.BaseDelay<<shift, t.MaxDelay)
+	default:
+		return 0
+	}
+
+	return max(attempt.LastFailure.Add(delay).Sub(now), 0)
+}
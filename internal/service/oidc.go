@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/GroVlAn/doc-store/internal/core"
+	"github.com/GroVlAn/doc-store/internal/core/e"
+	"github.com/google/uuid"
+	"golang.org/x/oauth2"
+)
+
+const (
+	oidcStateSize       = 32
+	defaultOIDCClaim    = "preferred_username"
+	defaultOIDCStateTTL = 10 * time.Minute
+)
+
+// OIDCLogin starts an authorization code flow and returns the URL of the
+// identity provider together with the state, which the caller binds to the
+// browser. The state, nonce and PKCE verifier are kept until the callback.
+func (s *Service) OIDCLogin() (string, string, error) {
+	if s.OIDC == nil {
+		return "", "", e.ErrOIDCDisabled
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.DefaultTimeout)
+	defer cancel()
+
+	state, err := generateSecret(oidcStateSize)
+	if err != nil {
+		return "", "", fmt.Errorf("generating oidc state: %w", err)
+	}
+
+	nonce, err := generateSecret(oidcStateSize)
+	if err != nil {
+		return "", "", fmt.Errorf("generating oidc nonce: %w", err)
+	}
+
+	stateTTL := s.OIDCStateTTL
+	if stateTTL <= 0 {
+		stateTTL = defaultOIDCStateTTL
+	}
+
+	oidcState := core.OIDCState{
+		State:     state,
+		Nonce:     nonce,
+		Verifier:  oauth2.GenerateVerifier(),
+		ExpiresAt: time.Now().Add(stateTTL),
+	}
+
+	authURL, err := s.OIDC.AuthURL(ctx, oidcState.State, oidcState.Nonce, oidcState.Verifier)
+	if err != nil {
+		return "", "", fmt.Errorf("building oidc auth url: %w", err)
+	}
+
+	if err := s.OIDCStateRepo.CreateOIDCState(ctx, oidcState); err != nil {
+		return "", "", fmt.Errorf("creating oidc state: %w", err)
+	}
+
+	return authURL, state, nil
+}
+
+// OIDCCallback completes the login: the code is exchanged, the ID token is
+// verified and the user it names gets doc-store tokens or a 2FA challenge
+// like on Auth. The state must match the one bound to the browser, so a login
+// can't be completed in another browser. Unknown users are provisioned when
+// enabled, existing users are linked to the identity on their first OIDC
+// login when the provider verified the email they have.
+func (s *Service) OIDCCallback(code string, state string, browserState string, client core.Client) (core.AuthResult, error) {
+	if s.OIDC == nil {
+		return core.AuthResult{}, e.ErrOIDCDisabled
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.DefaultTimeout)
+	defer cancel()
+
+	if len(code) == 0 || len(state) == 0 {
+		return core.AuthResult{}, fmt.Errorf("%w: missing code or state", e.ErrOIDCFailed)
+	}
+
+	if subtle.ConstantTimeCompare([]byte(state), []byte(browserState)) != 1 {
+		return core.AuthResult{}, fmt.Errorf("%w: state doesn't match the browser", e.ErrOIDCFailed)
+	}
+
+	oidcState, err := s.OIDCStateRepo.TakeOIDCState(ctx, state, time.Now())
+	if err != nil {
+		return core.AuthResult{}, fmt.Errorf("taking oidc state: %w", err)
+	}
+
+	claims, err := s.OIDC.Exchange(ctx, code, oidcState.Verifier, oidcState.Nonce)
+	if err != nil {
+		return core.AuthResult{}, fmt.Errorf("%w: %w", e.ErrOIDCFailed, err)
+	}
+
+	user, err := s.oidcUser(ctx, claims)
+	if err != nil {
+		return core.AuthResult{}, err
+	}
+
+	return s.secondFactor(ctx, user, client)
+}
+
+func (s *Service) oidcUser(ctx context.Context, claims map[string]any) (core.User, error) {
+	issuer, _ := claims["iss"].(string)
+	subject, _ := claims["sub"].(string)
+	if len(issuer) == 0 || len(subject) == 0 {
+		return core.User{}, fmt.Errorf("%w: id token has no subject", e.ErrOIDCFailed)
+	}
+
+	claim := s.OIDCLoginClaim
+	if len(claim) == 0 {
+		claim = defaultOIDCClaim
+	}
+
+	login, _ := claims[claim].(string)
+	if len(login) == 0 {
+		return core.User{}, fmt.Errorf("%w: id token has no %s claim", e.ErrOIDCFailed, claim)
+	}
+
+	externalID := issuer + "#" + subject
+
+	user, err := s.UserRepo.UserByExternalID(ctx, externalID)
+	if err == nil {
+		return user, nil
+	}
+	if !errors.Is(err, e.ErrUserNotFound) {
+		return core.User{}, fmt.Errorf("getting user by external id: %w", err)
+	}
+
+	user, err = s.UserRepo.User(ctx, login)
+	switch {
+	case err == nil:
+		// the login claim is chosen by the user at the provider, only a
+		// verified email the account already has proves it's the same person
+		if !verifiedEmail(claims, user.Email) {
+			return core.User{}, e.ErrUserAlreadyExist
+		}
+
+		if err := s.UserRepo.LinkExternalID(ctx, user.ID, externalID); err != nil {
+			return core.User{}, fmt.Errorf("linking user: %w", err)
+		}
+		user.ExternalID = externalID
+
+		return user, nil
+	case !errors.Is(err, e.ErrUserNotFound):
+		return core.User{}, fmt.Errorf("getting user: %w", err)
+	case !s.OIDCProvision:
+		return core.User{}, e.ErrUserNotFound
+	}
+
+	// the login is chosen at the provider, it has to pass the rules of Register
+	if err := validateLogin(login); err != nil {
+		return core.User{}, err
+	}
+
+	user = core.User{
+		ID:         uuid.NewString(),
+		Login:      login,
+		Role:       core.RoleUser,
+		ExternalID: externalID,
+	}
+
+	if err := s.UserRepo.CreateUser(ctx, user); err != nil {
+		return core.User{}, fmt.Errorf("creating new user: %w", err)
+	}
+
+	return user, nil
+}
+
+func verifiedEmail(claims map[string]any, email string) bool {
+	verified, _ := claims["email_verified"].(bool)
+	claimed, _ := claims["email"].(string)
+
+	return verified && len(email) > 0 && strings.EqualFold(claimed, email)
+}
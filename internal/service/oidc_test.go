@@ -0,0 +1,353 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/GroVlAn/doc-store/internal/core"
+	"github.com/GroVlAn/doc-store/internal/core/e"
+)
+
+const testIssuer = "https://idp.example.org"
+
+// fakeUserRepo keeps users by login. Methods the tests don't use panic
+// through the nil interface.
+type fakeUserRepo struct {
+	userRepo
+
+	mu    sync.Mutex
+	users map[string]core.User
+}
+
+func newFakeUserRepo(users ...core.User) *fakeUserRepo {
+	r := &fakeUserRepo{users: make(map[string]core.User)}
+	for _, user := range users {
+		r.users[user.Login] = user
+	}
+
+	return r
+}
+
+func (r *fakeUserRepo) CreateUser(_ context.Context, user core.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[user.Login]; ok {
+		return e.ErrUserAlreadyExist
+	}
+	r.users[user.Login] = user
+
+	return nil
+}
+
+func (r *fakeUserRepo) User(_ context.Context, login string) (core.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[login]
+	if !ok {
+		return core.User{}, e.ErrUserNotFound
+	}
+
+	return user, nil
+}
+
+func (r *fakeUserRepo) UserByExternalID(_ context.Context, externalID string) (core.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, user := range r.users {
+		if user.ExternalID == externalID {
+			return user, nil
+		}
+	}
+
+	return core.User{}, e.ErrUserNotFound
+}
+
+func (r *fakeUserRepo) LinkExternalID(_ context.Context, userID string, externalID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for login, user := range r.users {
+		if user.ID != userID {
+			continue
+		}
+		if len(user.ExternalID) > 0 {
+			return e.ErrAccountLinked
+		}
+
+		user.ExternalID = externalID
+		r.users[login] = user
+
+		return nil
+	}
+
+	return e.ErrUserNotFound
+}
+
+type fakeOIDCStateRepo struct {
+	mu     sync.Mutex
+	states map[string]core.OIDCState
+}
+
+func (r *fakeOIDCStateRepo) CreateOIDCState(_ context.Context, state core.OIDCState) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.states[state.State] = state
+
+	return nil
+}
+
+func (r *fakeOIDCStateRepo) TakeOIDCState(_ context.Context, state string, now time.Time) (core.OIDCState, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	oidcState, ok := r.states[state]
+	if !ok || !oidcState.ExpiresAt.After(now) {
+		return core.OIDCState{}, e.ErrOIDCFailed
+	}
+	delete(r.states, state)
+
+	return oidcState, nil
+}
+
+// fakeOIDCClient stands in for the provider. It accepts a code only with the
+// verifier and nonce of the last authorization request.
+type fakeOIDCClient struct {
+	claims   map[string]any
+	nonce    string
+	verifier string
+}
+
+func (c *fakeOIDCClient) AuthURL(_ context.Context, state string, nonce string, verifier string) (string, error) {
+	c.nonce = nonce
+	c.verifier = verifier
+
+	return testIssuer + "/authorize?" + url.Values{"state": {state}}.Encode(), nil
+}
+
+func (c *fakeOIDCClient) Exchange(_ context.Context, code string, verifier string, nonce string) (map[string]any, error) {
+	if code != "code-1" || verifier != c.verifier || nonce != c.nonce {
+		return nil, errors.New("invalid grant")
+	}
+
+	return c.claims, nil
+}
+
+type fakeTwoFactorRepo struct {
+	twoFactorRepo
+
+	challenges []core.AuthChallenge
+}
+
+func (r *fakeTwoFactorRepo) CreateAuthChallenge(_ context.Context, challenge core.AuthChallenge) error {
+	r.challenges = append(r.challenges, challenge)
+
+	return nil
+}
+
+func oidcClaims(login string, email string, verified bool) map[string]any {
+	return map[string]any{
+		"iss":                testIssuer,
+		"sub":                "subject-1",
+		"preferred_username": login,
+		"email":              email,
+		"email_verified":     verified,
+	}
+}
+
+// newOIDCService returns a service whose provider signs in the claims. Users
+// with 2FA end the callback at a challenge, so no tokens are issued.
+func newOIDCService(claims map[string]any, users ...core.User) (*Service, *fakeOIDCStateRepo, *fakeTwoFactorRepo) {
+	states := &fakeOIDCStateRepo{states: make(map[string]core.OIDCState)}
+	twoFactor := &fakeTwoFactorRepo{}
+
+	s := New(Deps{
+		UserRepo:       newFakeUserRepo(users...),
+		OIDCStateRepo:  states,
+		TwoFactorRepo:  twoFactor,
+		OIDC:           &fakeOIDCClient{claims: claims},
+		OIDCProvision:  true,
+		DefaultTimeout: time.Second,
+	})
+
+	return s, states, twoFactor
+}
+
+func TestOIDCCallback(t *testing.T) {
+	user := core.User{
+		ID:         "user-1",
+		Login:      "alice",
+		ExternalID: testIssuer + "#subject-1",
+		TwoFactor:  &core.TwoFactor{Enabled: true},
+	}
+
+	s, states, twoFactor := newOIDCService(oidcClaims("alice", "", false), user)
+
+	authURL, state, err := s.OIDCLogin()
+	if err != nil {
+		t.Fatalf("OIDCLogin() error = %v", err)
+	}
+
+	u, err := url.Parse(authURL)
+	if err != nil || u.Query().Get("state") != state {
+		t.Fatalf("auth url %q doesn't carry the state %q", authURL, state)
+	}
+
+	result, err := s.OIDCCallback("code-1", state, state, core.Client{})
+	if err != nil {
+		t.Fatalf("OIDCCallback() error = %v", err)
+	}
+
+	if result.TwoFactor == nil || len(twoFactor.challenges) != 1 || twoFactor.challenges[0].UserID != user.ID {
+		t.Errorf("OIDCCallback() = %+v, want a 2FA challenge of %s", result, user.ID)
+	}
+
+	if _, err := s.OIDCCallback("code-1", state, state, core.Client{}); !errors.Is(err, e.ErrOIDCFailed) {
+		t.Errorf("replayed OIDCCallback() error = %v, want %v", err, e.ErrOIDCFailed)
+	}
+
+	if len(states.states) != 0 {
+		t.Errorf("states = %v, want the state taken", states.states)
+	}
+}
+
+func TestOIDCCallbackBrowserState(t *testing.T) {
+	tests := []struct {
+		name         string
+		browserState func(state string) string
+	}{
+		{name: "no cookie", browserState: func(string) string { return "" }},
+		{name: "other browser", browserState: func(state string) string { return state + "x" }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, states, _ := newOIDCService(oidcClaims("alice", "", false))
+
+			_, state, err := s.OIDCLogin()
+			if err != nil {
+				t.Fatalf("OIDCLogin() error = %v", err)
+			}
+
+			_, err = s.OIDCCallback("code-1", state, tt.browserState(state), core.Client{})
+			if !errors.Is(err, e.ErrOIDCFailed) {
+				t.Errorf("OIDCCallback() error = %v, want %v", err, e.ErrOIDCFailed)
+			}
+
+			// a rejected callback leaves the state to the browser that started it
+			if _, ok := states.states[state]; !ok {
+				t.Error("state was taken by a callback from another browser")
+			}
+		})
+	}
+}
+
+func TestOIDCUser(t *testing.T) {
+	local := core.User{ID: "user-1", Login: "alice", Email: "alice@example.org"}
+	linked := core.User{ID: "user-2", Login: "bob", ExternalID: testIssuer + "#subject-1"}
+
+	tests := []struct {
+		name      string
+		claims    map[string]any
+		users     []core.User
+		provision bool
+		wantID    string
+		wantLogin string
+		wantErr   error
+	}{
+		{
+			name:   "linked user",
+			claims: oidcClaims("someone-else", "", false),
+			users:  []core.User{linked},
+			wantID: linked.ID,
+		},
+		{
+			name:   "verified email links",
+			claims: oidcClaims("alice", "ALICE@example.org", true),
+			users:  []core.User{local},
+			wantID: local.ID,
+		},
+		{
+			name:    "unverified email",
+			claims:  oidcClaims("alice", "alice@example.org", false),
+			users:   []core.User{local},
+			wantErr: e.ErrUserAlreadyExist,
+		},
+		{
+			name:    "other email",
+			claims:  oidcClaims("alice", "mallory@example.org", true),
+			users:   []core.User{local},
+			wantErr: e.ErrUserAlreadyExist,
+		},
+		{
+			name:    "account without email",
+			claims:  oidcClaims("alice", "", true),
+			users:   []core.User{{ID: "user-1", Login: "alice"}},
+			wantErr: e.ErrUserAlreadyExist,
+		},
+		{
+			name:      "provisioned",
+			claims:    oidcClaims("carol", "", false),
+			provision: true,
+			wantLogin: "carol",
+		},
+		{
+			name:    "provisioning disabled",
+			claims:  oidcClaims("carol", "", false),
+			wantErr: e.ErrUserNotFound,
+		},
+		{
+			name:      "invalid provisioned login",
+			claims:    oidcClaims("carol\nadmin", "", false),
+			provision: true,
+			wantErr:   e.ErrInvalidLogin,
+		},
+		{
+			name:    "no subject",
+			claims:  map[string]any{"iss": testIssuer, "preferred_username": "carol"},
+			wantErr: e.ErrOIDCFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _, _ := newOIDCService(tt.claims, tt.users...)
+			s.OIDCProvision = tt.provision
+
+			user, err := s.oidcUser(context.Background(), tt.claims)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("oidcUser() error = %v, want %v", err, tt.wantErr)
+				}
+
+				return
+			}
+			if err != nil {
+				t.Fatalf("oidcUser() error = %v", err)
+			}
+
+			if len(tt.wantID) > 0 && user.ID != tt.wantID {
+				t.Errorf("user = %s, want %s", user.ID, tt.wantID)
+			}
+			if len(tt.wantLogin) > 0 && user.Login != tt.wantLogin {
+				t.Errorf("login = %s, want %s", user.Login, tt.wantLogin)
+			}
+
+			if user.ExternalID != testIssuer+"#subject-1" {
+				t.Errorf("external id = %q, want the identity linked", user.ExternalID)
+			}
+
+			stored, err := s.UserRepo.User(context.Background(), user.Login)
+			if err != nil || stored.ExternalID != user.ExternalID {
+				t.Errorf("stored user = %+v, %v, want the identity stored", stored, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/GroVlAn/doc-store/internal/core"
+	"github.com/GroVlAn/doc-store/internal/core/e"
+)
+
+const (
+	resetTokenSize = 32
+
+	defaultPasswordResetTTL = time.Hour
+)
+
+// ChangePassword replaces the password of the caller and revokes every other
+// session, the session of the caller stays signed in.
+func (s *Service) ChangePassword(principal core.Principal, change core.PasswordChange) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.DefaultTimeout)
+	defer cancel()
+
+	user, err := s.accountUser(ctx, principal)
+	if err != nil {
+		return err
+	}
+
+	if err := s.Hashes.Compare(ctx, user.Password, change.OldPassword); err != nil {
+		return err
+	}
+
+	if err := s.setPassword(ctx, user, change.NewPassword); err != nil {
+		return err
+	}
+
+	if err := s.TokenRepo.DeleteUserTokensExcept(ctx, user.ID, principal.SessionID); err != nil {
+		return fmt.Errorf("deleting user tokens: %w", err)
+	}
+
+	return nil
+}
+
+// RequestPasswordReset sends a reset token to the user. It succeeds for
+// unknown logins and users without a local password as well, so the result
+// doesn't tell which logins exist.
+func (s *Service) RequestPasswordReset(login string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.DefaultTimeout)
+	defer cancel()
+
+	if len(login) == 0 {
+		return e.ErrInvalidLogin
+	}
+
+	user, err := s.UserRepo.User(ctx, login)
+	if errors.Is(err, e.ErrUserNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("getting user: %w", err)
+	}
+
+	if len(user.Password) == 0 || s.Notifier == nil {
+		return nil
+	}
+
+	token, err := generateSecret(resetTokenSize)
+	if err != nil {
+		return fmt.Errorf("generating reset token: %w", err)
+	}
+
+	ttl := s.PasswordResetTTL
+	if ttl <= 0 {
+		ttl = defaultPasswordResetTTL
+	}
+
+	reset := core.PasswordReset{
+		ID:        hashToken(token),
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+
+	if err := s.ResetRepo.CreatePasswordReset(ctx, reset); err != nil {
+		return fmt.Errorf("creating password reset: %w", err)
+	}
+
+	if err := s.Notifier.Notify(ctx, resetNotification(user, token, ttl, s.PasswordResetURL)); err != nil {
+		return fmt.Errorf("sending password reset: %w", err)
+	}
+
+	return nil
+}
+
+// ResetPassword sets a new password with a reset token and revokes every
+// session, API key and pending reset of the user.
+func (s *Service) ResetPassword(token string, password string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.DefaultTimeout)
+	defer cancel()
+
+	if len(token) == 0 {
+		return e.ErrInvalidResetToken
+	}
+
+	// the password is checked first, so a weak one doesn't use up the token
+	if !s.validatePassword(password) {
+		return e.ErrInvalidPassword
+	}
+
+	reset, err := s.ResetRepo.TakePasswordReset(ctx, hashToken(token), time.Now())
+	if err != nil {
+		return err
+	}
+
+	user, err := s.UserRepo.UserByID(ctx, reset.UserID)
+	if err != nil {
+		return e.ErrInvalidResetToken
+	}
+
+	if err := s.setPassword(ctx, user, password); err != nil {
+		return err
+	}
+
+	if err := s.ResetRepo.DeletePasswordResets(ctx, user.ID); err != nil {
+		return fmt.Errorf("deleting password resets: %w", err)
+	}
+
+	if err := s.TokenRepo.DeleteUserTokens(ctx, user.ID); err != nil {
+		return fmt.Errorf("deleting user tokens: %w", err)
+	}
+
+	// keys created with a stolen password must not outlive the reset
+	if err := s.APIKeyRepo.DeleteUserAPIKeys(ctx, user.ID); err != nil {
+		return fmt.Errorf("deleting api keys: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Service) setPassword(ctx context.Context, user core.User, password string) error {
+	if !s.validatePassword(password) {
+		return e.ErrInvalidPassword
+	}
+
+	hash, err := s.hashPassword(ctx, password)
+	if err != nil {
+		return err
+	}
+
+	if err := s.UserRepo.UpdatePassword(ctx, user.ID, hash); err != nil {
+		return fmt.Errorf("updating password: %w", err)
+	}
+
+	return nil
+}
+
+// accountUser returns the caller, API keys can't manage the account.
+func (s *Service) accountUser(ctx context.Context, principal core.Principal) (core.User, error) {
+	if len(principal.APIKeyID) > 0 {
+		return core.User{}, e.ErrForbidden
+	}
+
+	user, err := s.UserRepo.UserByID(ctx, principal.UserID)
+	if err != nil {
+		return core.User{}, e.ErrUserNotFound
+	}
+
+	return user, nil
+}
+
+func resetNotification(user core.User, token string, ttl time.Duration, resetURL string) core.Notification {
+	to := user.Email
+	if len(to) == 0 {
+		to = user.Login
+	}
+
+	var body strings.Builder
+
+	fmt.Fprintf(&body, "A password reset was requested for %s.\n\n", user.Login)
+	if len(resetURL) > 0 {
+		fmt.Fprintf(&body, "Open %s to choose a new password.\n\n", strings.ReplaceAll(resetURL, "%s", token))
+	} else {
+		fmt.Fprintf(&body, "Reset token: %s\n\n", token)
+	}
+	fmt.Fprintf(&body, "The token expires in %s. Ignore this message if you didn't request it.\n", ttl)
+
+	return core.Notification{
+		To:      to,
+		Subject: "doc-store password reset",
+		Body:    body.String(),
+	}
+}
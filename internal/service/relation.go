@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/GroVlAn/doc-store/internal/core"
+	"github.com/GroVlAn/doc-store/internal/core/e"
+	"github.com/google/uuid"
+)
+
+var relationTypePattern = regexp.MustCompile(`^[a-z][a-z0-9_.-]{0,63}$`)
+
+// CreateRelation links the source document to the target one. The token
+// owner must see both documents.
+func (s *Service) CreateRelation(principal core.Principal, sourceID string, relation core.Relation) (core.Relation, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.DefaultTimeout)
+	defer cancel()
+
+	if !relationTypePattern.MatchString(relation.Type) || len(relation.TargetID) == 0 || relation.TargetID == sourceID {
+		return core.Relation{}, e.ErrInvalidRelation
+	}
+
+	login := principal.Login
+
+	documents, err := s.DocumentRepo.DocumentsByIDs(ctx, login, []string{sourceID, relation.TargetID})
+	if err != nil {
+		return core.Relation{}, fmt.Errorf("getting documents: %w", err)
+	}
+	if len(documents) != 2 {
+		return core.Relation{}, e.ErrNoDocuments
+	}
+
+	relation.ID = relationID(sourceID, relation.Type, relation.TargetID)
+	relation.Token = ""
+	relation.SourceID = sourceID
+	relation.Creator = login
+	relation.Created = time.Now()
+
+	if err := s.RelationRepo.CreateRelation(ctx, relation); err != nil {
+		return core.Relation{}, err
+	}
+
+	return relation, nil
+}
+
+// Relations lists the links of a document, leaving out the ones whose other
+// document the token owner can't see.
+func (s *Service) Relations(principal core.Principal, documentID string) (core.DocumentRelations, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.DefaultTimeout)
+	defer cancel()
+
+	login := principal.Login
+
+	if _, err := s.DocumentRepo.Document(ctx, login, documentID); err != nil {
+		return core.DocumentRelations{}, e.ErrNoDocuments
+	}
+
+	relations, err := s.RelationRepo.Relations(ctx, documentID)
+	if err != nil {
+		return core.DocumentRelations{}, fmt.Errorf("getting relations: %w", err)
+	}
+
+	linkedIDs := make([]string, 0, len(relations))
+	for _, relation := range relations {
+		linkedIDs = append(linkedIDs, linkedDocumentID(relation, documentID))
+	}
+
+	visible, err := s.DocumentRepo.DocumentsByIDs(ctx, login, linkedIDs)
+	if err != nil {
+		return core.DocumentRelations{}, fmt.Errorf("getting documents: %w", err)
+	}
+
+	visibleIDs := make(map[string]struct{}, len(visible))
+	for _, document := range visible {
+		visibleIDs[document.ID] = struct{}{}
+	}
+
+	documentRelations := core.DocumentRelations{
+		Outgoing: []core.Relation{},
+		Incoming: []core.Relation{},
+	}
+
+	for _, relation := range relations {
+		if _, ok := visibleIDs[linkedDocumentID(relation, documentID)]; !ok {
+			continue
+		}
+
+		if relation.SourceID == documentID {
+			documentRelations.Outgoing = append(documentRelations.Outgoing, relation)
+		} else {
+			documentRelations.Incoming = append(documentRelations.Incoming, relation)
+		}
+	}
+
+	return documentRelations, nil
+}
+
+// DeleteRelation removes an outgoing link of a document the token owner can
+// see.
+func (s *Service) DeleteRelation(principal core.Principal, documentID string, relationID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.DefaultTimeout)
+	defer cancel()
+
+	if _, err := s.DocumentRepo.Document(ctx, principal.Login, documentID); err != nil {
+		return e.ErrNoDocuments
+	}
+
+	relation, err := s.RelationRepo.Relation(ctx, relationID)
+	if err != nil {
+		return err
+	}
+
+	if relation.SourceID != documentID {
+		return e.ErrRelationNotFound
+	}
+
+	return s.RelationRepo.DeleteRelation(ctx, relationID)
+}
+
+// relationID is derived from the link so that the same relation can't be
+// created twice.
+func relationID(sourceID string, relationType string, targetID string) string {
+	return uuid.NewSHA1(uuid.NameSpaceOID, []byte(sourceID+"\x00"+relationType+"\x00"+targetID)).String()
+}
+
+func linkedDocumentID(relation core.Relation, documentID string) string {
+	if relation.SourceID == documentID {
+		return relation.TargetID
+	}
+
+	return relation.SourceID
+}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/GroVlAn/doc-store/internal/core"
+	"github.com/GroVlAn/doc-store/internal/core/e"
+	"github.com/google/uuid"
+)
+
+func (s *Service) PlaceLegalHold(principal core.Principal, documentID string) error {
+	return s.setLegalHold(principal, documentID, true)
+}
+
+func (s *Service) ReleaseLegalHold(principal core.Principal, documentID string) error {
+	return s.setLegalHold(principal, documentID, false)
+}
+
+func (s *Service) CreateRetentionPolicy(principal core.Principal, policy core.RetentionPolicy) (core.RetentionPolicy, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.DefaultTimeout)
+	defer cancel()
+
+	if err := s.requireAdmin(ctx, principal); err != nil {
+		return core.RetentionPolicy{}, err
+	}
+
+	keepFor, err := time.ParseDuration(policy.Keep)
+	if err != nil || keepFor <= 0 {
+		return core.RetentionPolicy{}, e.ErrInvalidPolicy
+	}
+
+	if len(policy.Name) == 0 || (len(policy.Tag) == 0 && len(policy.Folder) == 0) {
+		return core.RetentionPolicy{}, e.ErrInvalidPolicy
+	}
+
+	policy.ID = uuid.NewString()
+	policy.KeepFor = keepFor
+	policy.Created = time.Now()
+	policy.Token = ""
+
+	if err := s.RetentionRepo.CreateRetentionPolicy(ctx, policy); err != nil {
+		return core.RetentionPolicy{}, fmt.Errorf("creating retention policy: %w", err)
+	}
+
+	return policy, nil
+}
+
+func (s *Service) RetentionPolicies(principal core.Principal) ([]core.RetentionPolicy, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.DefaultTimeout)
+	defer cancel()
+
+	if err := s.requireAdmin(ctx, principal); err != nil {
+		return nil, err
+	}
+
+	policies, err := s.RetentionRepo.RetentionPolicies(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting retention policies: %w", err)
+	}
+
+	for i := range policies {
+		policies[i].Keep = policies[i].KeepFor.String()
+	}
+
+	return policies, nil
+}
+
+func (s *Service) DeleteRetentionPolicy(principal core.Principal, policyID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.DefaultTimeout)
+	defer cancel()
+
+	if err := s.requireAdmin(ctx, principal); err != nil {
+		return err
+	}
+
+	if err := s.RetentionRepo.DeleteRetentionPolicy(ctx, policyID); err != nil {
+		return fmt.Errorf("deleting retention policy: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Service) setLegalHold(principal core.Principal, documentID string, hold bool) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.DefaultTimeout)
+	defer cancel()
+
+	if err := s.requireAdmin(ctx, principal); err != nil {
+		return err
+	}
+
+	document, err := s.DocumentRepo.DocumentByID(ctx, documentID)
+	if err != nil {
+		return fmt.Errorf("getting document: %w", err)
+	}
+
+	if err := s.DocumentRepo.SetLegalHold(ctx, documentID, hold); err != nil {
+		return fmt.Errorf("setting legal hold: %w", err)
+	}
+
+	s.Cache.Delete(s.documentKey(document.ID))
+
+	return nil
+}
+
+// checkRetention returns e.ErrDocumentRetained when the document is under
+// legal hold or one of the retention policies still keeps it.
+func (s *Service) checkRetention(ctx context.Context, document core.Document) error {
+	if document.LegalHold {
+		return e.ErrDocumentRetained
+	}
+
+	policies, err := s.RetentionRepo.RetentionPolicies(ctx)
+	if err != nil {
+		return fmt.Errorf("getting retention policies: %w", err)
+	}
+
+	now := time.Now()
+	for _, policy := range policies {
+		if policyApplies(policy, document) && now.Before(document.Created.Add(policy.KeepFor)) {
+			return e.ErrDocumentRetained
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) requireAdmin(ctx context.Context, principal core.Principal) error {
+	admin, err := s.isAdmin(ctx, principal)
+	if err != nil {
+		return err
+	}
+	if !admin {
+		return e.ErrForbidden
+	}
+
+	return nil
+}
+
+func (s *Service) isAdmin(ctx context.Context, principal core.Principal) (bool, error) {
+	login := principal.Login
+
+	if slices.Contains(s.Admins, login) {
+		return true, nil
+	}
+
+	user, err := s.UserRepo.User(ctx, login)
+	if errors.Is(err, e.ErrUserNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("getting user: %w", err)
+	}
+
+	return user.Role == core.RoleAdmin, nil
+}
+
+func policyApplies(policy core.RetentionPolicy, document core.Document) bool {
+	if len(policy.Tag) > 0 && slices.Contains(document.Tags, policy.Tag) {
+		return true
+	}
+
+	if len(policy.Folder) == 0 {
+		return false
+	}
+
+	return document.Folder == policy.Folder || strings.HasPrefix(document.Folder, policy.Folder+"/")
+}
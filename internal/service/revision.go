@@ -0,0 +1,42 @@
+package service
+
+import (
+	"github.com/GroVlAn/doc-store/internal/core"
+	"github.com/GroVlAn/doc-store/internal/core/e"
+)
+
+// checkPrecondition compares the revision expected by an If-Match header with
+// the current one. A nil revision means an unconditional write.
+func (s *Service) checkPrecondition(document core.Document, ifMatch *int64) error {
+	if ifMatch == nil || *ifMatch == core.AnyRevision {
+		return nil
+	}
+
+	if *ifMatch != document.Revision {
+		return e.ErrPreconditionFailed
+	}
+
+	return nil
+}
+
+// requirePrecondition rejects unconditional single document writes when the
+// service is configured to require If-Match. Bulk operations stay
+// unconditional.
+func (s *Service) requirePrecondition(ifMatch *int64) error {
+	if s.RequireIfMatch && ifMatch == nil {
+		return e.ErrPreconditionRequired
+	}
+
+	return nil
+}
+
+// expectedRevision returns the revision a conditional write must still find
+// in the repository, so that a concurrent write in between is detected.
+// "If-Match: *" matches any revision, the write stays unconditional.
+func expectedRevision(document core.Document, ifMatch *int64) *int64 {
+	if ifMatch == nil || *ifMatch == core.AnyRevision {
+		return nil
+	}
+
+	return &document.Revision
+}
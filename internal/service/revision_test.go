@@ -0,0 +1,34 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/GroVlAn/doc-store/internal/core"
+)
+
+func TestExpectedRevision(t *testing.T) {
+	revision := func(r int64) *int64 { return &r }
+
+	tests := []struct {
+		name    string
+		ifMatch *int64
+		want    *int64
+	}{
+		{name: "unconditional", ifMatch: nil, want: nil},
+		{name: "any revision", ifMatch: revision(core.AnyRevision), want: nil},
+		{name: "matching revision", ifMatch: revision(3), want: revision(3)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := expectedRevision(core.Document{Revision: 3}, tt.ifMatch)
+
+			switch {
+			case tt.want == nil && got != nil:
+				t.Errorf("expectedRevision() = %d, want nil", *got)
+			case tt.want != nil && (got == nil || *got != *tt.want):
+				t.Errorf("expectedRevision() = %v, want %d", got, *tt.want)
+			}
+		})
+	}
+}
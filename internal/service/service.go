@@ -2,20 +2,65 @@ package service
 
 import (
 	"context"
+	"io"
+	"io/fs"
+	"net/http"
 	"time"
 
 	"github.com/GroVlAn/doc-store/internal/core"
+	"github.com/GroVlAn/doc-store/internal/keyring"
 )
 
 type userRepo interface {
 	CreateUser(ctx context.Context, user core.User) error
 	User(ctx context.Context, login string) (core.User, error)
+	UserByID(ctx context.Context, id string) (core.User, error)
+	UserByExternalID(ctx context.Context, externalID string) (core.User, error)
+	LinkExternalID(ctx context.Context, userID string, externalID string) error
+	UpdateUserRole(ctx context.Context, userID string, role string) error
+	SetTwoFactor(ctx context.Context, userID string, twoFactor core.TwoFactor) error
+	DeleteTwoFactor(ctx context.Context, userID string) error
+	SetRecoveryCodes(ctx context.Context, userID string, hashes []string) error
+	UseTOTPStep(ctx context.Context, userID string, step int64) error
+	UseRecoveryCode(ctx context.Context, userID string, hash string) error
+	UpdatePassword(ctx context.Context, userID string, password string) error
+	DeleteUser(ctx context.Context, userID string) error
+}
+
+// authenticator checks a login and password. e.ErrUserNotFound passes the
+// login to the next authenticator, any other error ends the login.
+type authenticator interface {
+	Authenticate(ctx context.Context, login string, password string) (core.Identity, error)
 }
 
 type tokenRepo interface {
 	CreateToken(ctx context.Context, token core.AccessToken) error
 	Token(ctx context.Context, token string) (core.AccessToken, error)
 	DeleteToken(ctx context.Context, token string) error
+	UseRefreshToken(ctx context.Context, token string, usedAt time.Time) error
+	DeleteTokenFamily(ctx context.Context, familyID string) error
+	SessionTokens(ctx context.Context, userID string, now time.Time) ([]core.AccessToken, error)
+	TouchToken(ctx context.Context, token string, lastUsed time.Time) error
+	DeleteSession(ctx context.Context, userID string, sessionID string) error
+	DeleteUserTokens(ctx context.Context, userID string) error
+	DeleteUserTokensExcept(ctx context.Context, userID string, familyID string) error
+}
+
+type passwordResetRepo interface {
+	CreatePasswordReset(ctx context.Context, reset core.PasswordReset) error
+	TakePasswordReset(ctx context.Context, resetID string, now time.Time) (core.PasswordReset, error)
+	DeletePasswordResets(ctx context.Context, userID string) error
+}
+
+type loginAttemptRepo interface {
+	LoginAttempts(ctx context.Context, keys []string, now time.Time) ([]core.LoginAttempt, error)
+	FailLogin(ctx context.Context, key string, now time.Time, expiresAt time.Time) (core.LoginAttempt, error)
+	ReleaseLoginAttempt(ctx context.Context, key string) error
+	ClearLoginAttempts(ctx context.Context, key string) error
+}
+
+type notifier interface {
+	Notify(ctx context.Context, notification core.Notification) error
 }
 
 type documentRepo interface {
@@ -23,14 +68,162 @@ type documentRepo interface {
 	Document(ctx context.Context, login string, documentID string) (core.Document, error)
 	DocumentsList(ctx context.Context, df core.DocumentFilter) ([]core.Document, error)
 	DocumentByName(ctx context.Context, userID string, name string) (core.Document, error)
-	DeleteDocument(ctx context.Context, login string, documentID string) error
+	UpdateDocumentAttributes(
+		ctx context.Context,
+		login string,
+		documentID string,
+		attributes core.Attributes,
+		unset []string,
+		revision *int64,
+	) error
+	ReplaceDocument(ctx context.Context, login string, document core.Document, revision int64) error
+	TrashDocument(ctx context.Context, login string, documentID string, deletedAt time.Time, revision *int64) error
+	TrashedDocument(ctx context.Context, login string, documentID string) (core.Document, error)
+	TrashList(ctx context.Context, login string) ([]core.Document, error)
+	TrashedBefore(ctx context.Context, before time.Time) ([]core.Document, error)
+	RestoreDocument(ctx context.Context, login string, documentID string) error
+	AddGrant(ctx context.Context, login string, documentID string, grant []string) error
+	DocumentsByIDs(ctx context.Context, login string, documentIDs []string) ([]core.Document, error)
+	DocumentsByOwner(ctx context.Context, ownerID string) ([]core.Document, error)
+	DocumentByID(ctx context.Context, documentID string) (core.Document, error)
+	SetLegalHold(ctx context.Context, documentID string, hold bool) error
+	ExpiredDocuments(ctx context.Context, now time.Time) ([]core.Document, error)
+	PurgeDocument(ctx context.Context, documentID string) error
+	LockDocument(ctx context.Context, login string, documentID string, lock core.Lock) error
+	UnlockDocument(ctx context.Context, documentID string, owner string) error
+	OwnedDocuments(ctx context.Context, ownerID string) ([]core.Document, error)
+	TransferDocument(
+		ctx context.Context,
+		documentID string,
+		ownerID string,
+		fromLogin string,
+		toLogin string,
+		name string,
+	) error
+	RemoveGrantee(ctx context.Context, login string) error
+}
+
+type versionRepo interface {
+	SaveVersion(ctx context.Context, version core.DocumentVersion) error
+	Versions(ctx context.Context, documentID string) ([]core.DocumentVersion, error)
+	VersionAt(ctx context.Context, documentID string, revision int64) (core.DocumentVersion, error)
+	DeleteVersions(ctx context.Context, documentID string) error
+}
+
+type commentRepo interface {
+	CreateComment(ctx context.Context, comment core.Comment) error
+	Comment(ctx context.Context, documentID string, commentID string) (core.Comment, error)
+	Comments(ctx context.Context, documentID string) ([]core.Comment, error)
+	UpdateComment(ctx context.Context, commentID string, body string, updated time.Time) error
+	ResolveComment(ctx context.Context, commentID string, login string) error
+	DeleteComment(ctx context.Context, commentID string) error
+	DeleteComments(ctx context.Context, documentID string) error
+}
+
+type relationRepo interface {
+	CreateRelation(ctx context.Context, relation core.Relation) error
+	Relation(ctx context.Context, relationID string) (core.Relation, error)
+	Relations(ctx context.Context, documentID string) ([]core.Relation, error)
+	DeleteRelation(ctx context.Context, relationID string) error
+	DeleteRelations(ctx context.Context, documentID string) error
+}
+
+type apiKeyRepo interface {
+	CreateAPIKey(ctx context.Context, key core.APIKey) error
+	APIKeyByHash(ctx context.Context, hash string) (core.APIKey, error)
+	APIKeys(ctx context.Context, userID string) ([]core.APIKey, error)
+	DeleteAPIKey(ctx context.Context, userID string, keyID string) error
+	TouchAPIKey(ctx context.Context, keyID string, lastUsed time.Time) error
+	DeleteUserAPIKeys(ctx context.Context, userID string) error
+}
+
+type signingKeyRepo interface {
+	CreateSigningKey(ctx context.Context, key core.SigningKey) error
+	SigningKeys(ctx context.Context, now time.Time) ([]core.SigningKey, error)
+	DeleteRetiredSigningKeys(ctx context.Context, now time.Time) error
+}
+
+type keyRing interface {
+	Set(keys []keyring.Key)
+	Signing() (keyring.Key, bool)
+	Key(id string) (keyring.Key, bool)
+	Keys() []keyring.Key
+}
+
+type oidcStateRepo interface {
+	CreateOIDCState(ctx context.Context, state core.OIDCState) error
+	TakeOIDCState(ctx context.Context, state string, now time.Time) (core.OIDCState, error)
+}
+
+type twoFactorRepo interface {
+	CreateAuthChallenge(ctx context.Context, challenge core.AuthChallenge) error
+	ClaimAuthChallenge(ctx context.Context, challengeID string, now time.Time, maxAttempts int) (core.AuthChallenge, error)
+	DeleteAuthChallenge(ctx context.Context, challengeID string) error
+	TwoFactorPolicy(ctx context.Context) (core.TwoFactorPolicy, error)
+	SetTwoFactorPolicy(ctx context.Context, policy core.TwoFactorPolicy) error
+}
+
+type oidcClient interface {
+	AuthURL(ctx context.Context, state string, nonce string, verifier string) (string, error)
+	Exchange(ctx context.Context, code string, verifier string, nonce string) (map[string]any, error)
+}
+
+type retentionRepo interface {
+	CreateRetentionPolicy(ctx context.Context, policy core.RetentionPolicy) error
+	RetentionPolicies(ctx context.Context) ([]core.RetentionPolicy, error)
+	DeleteRetentionPolicy(ctx context.Context, policyID string) error
+}
+
+type auditRepo interface {
+	CreateAuditEvent(ctx context.Context, event core.AuditEvent) error
+	AuditEvents(ctx context.Context, filter core.AuditFilter) ([]core.AuditEvent, error)
+}
+
+type webhookRepo interface {
+	CreateWebhook(ctx context.Context, webhook core.Webhook) error
+	Webhook(ctx context.Context, webhookID string) (core.Webhook, error)
+	Webhooks(ctx context.Context, userID string) ([]core.Webhook, error)
+	SubscribedWebhooks(ctx context.Context, logins []string, event string) ([]core.Webhook, error)
+	DeleteWebhook(ctx context.Context, userID string, webhookID string) error
+	DeleteUserWebhooks(ctx context.Context, userID string) error
+	CreateDeliveries(ctx context.Context, deliveries []core.WebhookDelivery) error
+	ClaimDelivery(ctx context.Context, now time.Time, lease time.Duration) (core.WebhookDelivery, error)
+	UpdateDelivery(ctx context.Context, delivery core.WebhookDelivery) error
+	CreateDeliveryAttempt(ctx context.Context, attempt core.DeliveryAttempt) error
+	DeliveryAttempts(ctx context.Context, webhookID string, limit int64) ([]core.DeliveryAttempt, error)
+}
+
+type httpClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+type transactor interface {
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type eventBus interface {
+	Publish(event core.DocumentEvent)
+	Subscribe() (<-chan core.DocumentEvent, func())
 }
 
 type fileRepo interface {
 	SaveFile(userID string, fileName string, file []byte) error
 	File(userID string, fileName string) (string, error)
+	OpenFile(userID string, fileName string) (io.ReadCloser, fs.FileInfo, error)
 	DeleteFile(userID string, fileName string) error
 	FileExist(userID string, fileName string) bool
+	Checksum(userID string, fileName string) (string, int64, error)
+	TrashFile(userID string, fileName string, documentID string) error
+	RestoreFile(userID string, documentID string, fileName string) error
+	DeleteTrashFile(userID string, documentID string) error
+	TrashFileExist(userID string, documentID string) bool
+	SaveVersionFile(userID string, fileName string, documentID string, revision int64) error
+	VersionFile(documentID string, revision int64) (string, error)
+	DeleteVersionFiles(documentID string) error
+	ReadFile(filePath string, limit int64) ([]byte, error)
+	MoveFile(fromUserID string, toUserID string, fileName string, newName string) error
+	MoveTrashFile(fromUserID string, toUserID string, documentID string) error
+	DeleteUserFiles(userID string) error
 }
 
 type cache interface {
@@ -41,15 +234,77 @@ type cache interface {
 }
 
 type Deps struct {
-	UserRepo       userRepo
-	TokenRepo      tokenRepo
-	Cache          cache
-	DocumentRepo   documentRepo
-	FileRepo       fileRepo
-	DefaultTimeout time.Duration
-	HashCost       int
-	TokenEndTTL    time.Duration
-	SecretKey      string
+	UserRepo        userRepo
+	TokenRepo       tokenRepo
+	Cache           cache
+	DocumentRepo    documentRepo
+	FileRepo        fileRepo
+	RetentionRepo   retentionRepo
+	VersionRepo     versionRepo
+	CommentRepo     commentRepo
+	RelationRepo    relationRepo
+	APIKeyRepo      apiKeyRepo
+	SigningKeyRepo  signingKeyRepo
+	OIDCStateRepo   oidcStateRepo
+	TwoFactorRepo   twoFactorRepo
+	ResetRepo       passwordResetRepo
+	AttemptRepo     loginAttemptRepo
+	Notifier        notifier
+	Keys            keyRing
+	AuditRepo       auditRepo
+	WebhookRepo     webhookRepo
+	WebhookClient   httpClient
+	Events          eventBus
+	DefaultTimeout  time.Duration
+	HashCost        int
+	Hashes          *HashLimiter
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+	SecretKey       string
+	TrashRetention  time.Duration
+	Admins          []string
+	LockTTL         time.Duration
+	LockMaxTTL      time.Duration
+	RequireIfMatch  bool
+
+	// ImportMaxEntrySize bounds every entry of an imported archive, 0 uses
+	// the default.
+	ImportMaxEntrySize int64
+
+	// Tx is nil when the database doesn't support transactions, changes and
+	// their webhook deliveries are written separately then.
+	Tx transactor
+
+	// Authenticators are tried in order, nil checks the stored password only.
+	// AuthProvision creates users confirmed by an external authenticator.
+	Authenticators []authenticator
+	AuthProvision  bool
+	Throttle       LoginThrottle
+
+	// PasswordResetURL is sent with the reset token, %s is replaced by the
+	// token.
+	PasswordResetTTL time.Duration
+	PasswordResetURL string
+
+	TOTPIssuer        string
+	ChallengeTTL      time.Duration
+	ChallengeAttempts int
+
+	SigningAlgorithm string
+	KeyRotation      time.Duration
+	KeyGracePeriod   time.Duration
+	KeyPublishDelay  time.Duration
+
+	// OIDC is nil when OIDC login is disabled.
+	OIDC           oidcClient
+	OIDCLoginClaim string
+	OIDCProvision  bool
+	OIDCStateTTL   time.Duration
+
+	WebhookTimeout      time.Duration
+	WebhookBackoff      time.Duration
+	WebhookMaxAttempts  int
+	WebhookAllowPrivate bool
 }
 
 type Service struct {
@@ -57,6 +312,14 @@ type Service struct {
 }
 
 func New(deps Deps) *Service {
+	if deps.Hashes == nil {
+		deps.Hashes = NewHashLimiter(0)
+	}
+
+	if len(deps.Authenticators) == 0 {
+		deps.Authenticators = []authenticator{NewPasswordAuthenticator(deps.UserRepo, deps.Hashes)}
+	}
+
 	return &Service{
 		Deps: deps,
 	}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/GroVlAn/doc-store/internal/core"
+	"github.com/GroVlAn/doc-store/internal/core/e"
+)
+
+// sessionTouchInterval limits how often the last use of a token is written.
+const sessionTouchInterval = time.Minute
+
+// Sessions lists the caller's sessions which still have a valid token.
+func (s *Service) Sessions(principal core.Principal) ([]core.Session, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.DefaultTimeout)
+	defer cancel()
+
+	tokens, err := s.TokenRepo.SessionTokens(ctx, principal.UserID, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("getting session tokens: %w", err)
+	}
+
+	sessions := make(map[string]*core.Session)
+	for _, token := range tokens {
+		session, ok := sessions[token.FamilyID]
+		if !ok {
+			session = &core.Session{
+				ID:      token.FamilyID,
+				Created: token.SessionCreated,
+				Current: token.FamilyID == principal.SessionID,
+			}
+			sessions[token.FamilyID] = session
+		}
+
+		lastUsed := token.StartTTL
+		if token.LastUsed != nil && token.LastUsed.After(lastUsed) {
+			lastUsed = *token.LastUsed
+		}
+
+		if lastUsed.After(session.LastUsed) {
+			session.LastUsed = lastUsed
+			session.IP = token.IP
+			session.UserAgent = token.UserAgent
+		}
+
+		if token.EndTTl.After(session.ExpiresAt) {
+			session.ExpiresAt = token.EndTTl
+		}
+
+		if session.Created.IsZero() || token.StartTTL.Before(session.Created) {
+			session.Created = token.StartTTL
+		}
+	}
+
+	list := make([]core.Session, 0, len(sessions))
+	for _, session := range sessions {
+		list = append(list, *session)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].LastUsed.After(list[j].LastUsed)
+	})
+
+	return list, nil
+}
+
+func (s *Service) RevokeSession(principal core.Principal, sessionID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.DefaultTimeout)
+	defer cancel()
+
+	if len(sessionID) == 0 {
+		return e.ErrSessionNotFound
+	}
+
+	if err := s.TokenRepo.DeleteSession(ctx, principal.UserID, sessionID); err != nil {
+		return fmt.Errorf("deleting session: %w", err)
+	}
+
+	return nil
+}
+
+// RevokeSessions logs the caller out everywhere, the current session included.
+func (s *Service) RevokeSessions(principal core.Principal) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.DefaultTimeout)
+	defer cancel()
+
+	if err := s.TokenRepo.DeleteUserTokens(ctx, principal.UserID); err != nil {
+		return fmt.Errorf("deleting user tokens: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Service) touchToken(ctx context.Context, token core.AccessToken) error {
+	now := time.Now()
+	if token.LastUsed != nil && now.Sub(*token.LastUsed) < sessionTouchInterval {
+		return nil
+	}
+
+	if err := s.TokenRepo.TouchToken(ctx, token.Token, now); err != nil {
+		return fmt.Errorf("updating token last use: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/GroVlAn/doc-store/internal/core"
+	"github.com/GroVlAn/doc-store/internal/keyring"
+	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
+)
+
+// RotateSigningKeys loads the signing keys shared by all instances, creates a
+// new key once the newest one is older than the rotation period and drops
+// retired keys. A new key only signs after the publish delay, so the other
+// instances and JWKS consumers load it first. A key retires a grace period
+// after the next key replaced it, so tokens it signed can still be verified.
+func (s *Service) RotateSigningKeys(ctx context.Context) error {
+	if !s.asymmetricSigning() {
+		return nil
+	}
+
+	if s.KeyRotation <= 0 {
+		return errors.New("signing key rotation period is not set")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.DefaultTimeout)
+	defer cancel()
+
+	now := time.Now()
+
+	if err := s.SigningKeyRepo.DeleteRetiredSigningKeys(ctx, now); err != nil {
+		return fmt.Errorf("deleting retired signing keys: %w", err)
+	}
+
+	stored, err := s.SigningKeyRepo.SigningKeys(ctx, now)
+	if err != nil {
+		return fmt.Errorf("getting signing keys: %w", err)
+	}
+
+	if len(stored) == 0 || stored[0].Algorithm != s.SigningAlgorithm || now.Sub(stored[0].Created) >= s.KeyRotation {
+		// the first key has nobody to be published to and signs right away
+		activeAt := now
+		if len(stored) > 0 {
+			activeAt = now.Add(s.KeyPublishDelay)
+		}
+
+		key, err := s.createSigningKey(ctx, now, activeAt)
+		if err != nil {
+			return err
+		}
+
+		stored = append([]core.SigningKey{key}, stored...)
+	}
+
+	keys := make([]keyring.Key, 0, len(stored))
+	for _, key := range stored {
+		sealed, err := keyring.Open(s.SecretKey, key.PrivateKey)
+		if err != nil {
+			return fmt.Errorf("opening signing key %s: %w", key.ID, err)
+		}
+
+		private, err := keyring.ParsePrivate(sealed)
+		if err != nil {
+			return fmt.Errorf("parsing signing key %s: %w", key.ID, err)
+		}
+
+		keys = append(keys, keyring.Key{
+			ID:        key.ID,
+			Algorithm: key.Algorithm,
+			Private:   private,
+			Created:   key.Created,
+			ActiveAt:  key.ActiveAt,
+			RetiresAt: key.RetiresAt,
+		})
+	}
+
+	s.Keys.Set(keys)
+
+	return nil
+}
+
+// JWKS returns the public keys access tokens can be verified with.
+func (s *Service) JWKS() keyring.JWKS {
+	if !s.asymmetricSigning() {
+		return keyring.JWKS{Keys: []keyring.JWK{}}
+	}
+
+	return keyring.PublicSet(s.Keys.Keys())
+}
+
+func (s *Service) createSigningKey(ctx context.Context, now time.Time, activeAt time.Time) (core.SigningKey, error) {
+	private, err := keyring.Generate(s.SigningAlgorithm)
+	if err != nil {
+		return core.SigningKey{}, fmt.Errorf("generating signing key: %w", err)
+	}
+
+	encoded, err := keyring.MarshalPrivate(private)
+	if err != nil {
+		return core.SigningKey{}, err
+	}
+
+	sealed, err := keyring.Seal(s.SecretKey, encoded)
+	if err != nil {
+		return core.SigningKey{}, fmt.Errorf("sealing signing key: %w", err)
+	}
+
+	key := core.SigningKey{
+		ID:         uuid.NewString(),
+		Algorithm:  s.SigningAlgorithm,
+		PrivateKey: sealed,
+		Created:    now,
+		ActiveAt:   activeAt,
+		RetiresAt:  activeAt.Add(s.KeyRotation + s.KeyGracePeriod),
+	}
+
+	if err := s.SigningKeyRepo.CreateSigningKey(ctx, key); err != nil {
+		return core.SigningKey{}, fmt.Errorf("creating signing key: %w", err)
+	}
+
+	return key, nil
+}
+
+// signToken signs the claims with the newest key of the ring, or with the
+// secret key when tokens are signed with HS256.
+func (s *Service) signToken(claims jwt.MapClaims) (string, error) {
+	if !s.asymmetricSigning() {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(s.SecretKey))
+	}
+
+	key, ok := s.Keys.Signing()
+	if !ok {
+		return "", errors.New("no signing key")
+	}
+
+	token := jwt.NewWithClaims(key.Method(), claims)
+	token.Header["kid"] = key.ID
+
+	return token.SignedString(key.Private)
+}
+
+// verificationKey resolves the key of a token by its kid. The algorithm of
+// the token must match the key, so a public key is never used as an HMAC
+// secret.
+func (s *Service) verificationKey(token *jwt.Token) (interface{}, error) {
+	if !s.asymmetricSigning() {
+		if token.Method != jwt.SigningMethodHS256 {
+			return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+		}
+
+		return []byte(s.SecretKey), nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+
+	key, ok := s.Keys.Key(kid)
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	if token.Method.Alg() != key.Algorithm {
+		return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+	}
+
+	return key.Public(), nil
+}
+
+func (s *Service) asymmetricSigning() bool {
+	return len(s.SigningAlgorithm) > 0 && s.SigningAlgorithm != keyring.AlgorithmHS256
+}
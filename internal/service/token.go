@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/GroVlAn/doc-store/internal/core"
+	"github.com/GroVlAn/doc-store/internal/core/e"
+	"github.com/google/uuid"
+)
+
+const refreshTokenSize = 32
+
+// Refresh rotates a refresh token: it is exchanged once for a new token pair
+// of the same family. Presenting a used refresh token again means it has
+// leaked, so the whole family is revoked.
+func (s *Service) Refresh(refreshToken string, client core.Client) (core.TokenPair, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.DefaultTimeout)
+	defer cancel()
+
+	if len(refreshToken) == 0 {
+		return core.TokenPair{}, &e.ErrInvalidToken{Msg: "invalid refresh token"}
+	}
+
+	hash := hashToken(refreshToken)
+
+	token, err := s.TokenRepo.Token(ctx, hash)
+	if err != nil || token.Kind != core.TokenKindRefresh {
+		return core.TokenPair{}, &e.ErrInvalidToken{Msg: "invalid refresh token", Err: err}
+	}
+
+	now := time.Now()
+	if now.After(token.EndTTl) {
+		if err := s.TokenRepo.DeleteToken(ctx, hash); err != nil {
+			return core.TokenPair{}, fmt.Errorf("deleting refresh token: %w", err)
+		}
+
+		return core.TokenPair{}, &e.ErrInvalidToken{Msg: "invalid refresh token"}
+	}
+
+	err = s.TokenRepo.UseRefreshToken(ctx, hash, now)
+	if errors.Is(err, e.ErrRefreshTokenReused) {
+		if err := s.TokenRepo.DeleteTokenFamily(ctx, token.FamilyID); err != nil {
+			return core.TokenPair{}, fmt.Errorf("deleting token family: %w", err)
+		}
+
+		return core.TokenPair{}, &e.ErrInvalidToken{Msg: "invalid refresh token", Err: err}
+	}
+	if err != nil {
+		return core.TokenPair{}, fmt.Errorf("using refresh token: %w", err)
+	}
+
+	user, err := s.UserRepo.UserByID(ctx, token.UserID)
+	if err != nil {
+		return core.TokenPair{}, e.ErrUserNotFound
+	}
+
+	sessionCreated := token.SessionCreated
+	if sessionCreated.IsZero() {
+		sessionCreated = token.StartTTL
+	}
+
+	return s.issueTokens(ctx, user, token.FamilyID, sessionCreated, client)
+}
+
+// issueTokens creates an access token and a refresh token in the family.
+func (s *Service) issueTokens(
+	ctx context.Context,
+	user core.User,
+	familyID string,
+	sessionCreated time.Time,
+	client core.Client,
+) (core.TokenPair, error) {
+	accessToken, err := s.createAccessToken(user, familyID)
+	if err != nil {
+		return core.TokenPair{}, err
+	}
+	accessToken.SessionCreated = sessionCreated
+	accessToken.IP = client.IP
+	accessToken.UserAgent = client.UserAgent
+
+	if err := s.saveAccessToken(ctx, accessToken); err != nil {
+		return core.TokenPair{}, err
+	}
+
+	refreshToken, err := generateSecret(refreshTokenSize)
+	if err != nil {
+		return core.TokenPair{}, fmt.Errorf("generating refresh token: %w", err)
+	}
+
+	now := time.Now()
+
+	err = s.saveAccessToken(ctx, core.AccessToken{
+		ID:             uuid.NewString(),
+		Token:          hashToken(refreshToken),
+		Kind:           core.TokenKindRefresh,
+		FamilyID:       familyID,
+		StartTTL:       now,
+		EndTTl:         now.Add(s.RefreshTokenTTL),
+		UserID:         user.ID,
+		SessionCreated: sessionCreated,
+		IP:             client.IP,
+		UserAgent:      client.UserAgent,
+	})
+	if err != nil {
+		return core.TokenPair{}, err
+	}
+
+	return core.TokenPair{
+		AccessToken:  accessToken.Token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(s.AccessTokenTTL.Seconds()),
+	}, nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(sum[:])
+}
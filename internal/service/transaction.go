@@ -0,0 +1,38 @@
+package service
+
+import (
+	"context"
+
+	"github.com/GroVlAn/doc-store/internal/core"
+)
+
+// inTransaction runs fn in a transaction, so a change and the webhook
+// deliveries it enqueues are written together. fn may run again on transient
+// errors, file operations belong after it. Without transaction support fn runs
+// directly.
+func (s *Service) inTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if s.Tx == nil {
+		return fn(ctx)
+	}
+
+	return s.Tx.WithTransaction(ctx, fn)
+}
+
+// revertChange undoes a committed change whose file operation failed. The
+// revert enqueues its own event, so subscribers end up with the document as
+// it is stored.
+func (s *Service) revertChange(
+	ctx context.Context,
+	event string,
+	actor string,
+	document core.Document,
+	revert func(ctx context.Context) error,
+) error {
+	return s.inTransaction(ctx, func(ctx context.Context) error {
+		if err := revert(ctx); err != nil {
+			return err
+		}
+
+		return s.enqueueEvent(ctx, event, actor, document)
+	})
+}
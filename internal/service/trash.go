@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/GroVlAn/doc-store/internal/core"
+	"github.com/GroVlAn/doc-store/internal/core/e"
+)
+
+const defaultTrashRetention = 30 * 24 * time.Hour
+
+func (s *Service) TrashList(principal core.Principal) ([]core.Document, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.DefaultTimeout)
+	defer cancel()
+
+	trashList, err := s.DocumentRepo.TrashList(ctx, principal.Login)
+	if err != nil {
+		return nil, fmt.Errorf("getting trash list: %w", err)
+	}
+
+	return trashList, nil
+}
+
+func (s *Service) RestoreDocument(principal core.Principal, documentID string) (core.Document, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.DefaultTimeout)
+	defer cancel()
+
+	document, err := s.DocumentRepo.TrashedDocument(ctx, principal.Login, documentID)
+	if err != nil {
+		return core.Document{}, fmt.Errorf("getting document from trash: %w", err)
+	}
+
+	if len(document.Name) > 0 {
+		existDocument, err := s.DocumentRepo.DocumentByName(ctx, principal.Login, document.Name)
+		if err != nil && !errors.Is(err, e.ErrNoDocuments) {
+			return core.Document{}, fmt.Errorf("getting document by name: %w", err)
+		}
+		if len(existDocument.ID) > 0 {
+			return core.Document{}, e.ErrDocumentExist
+		}
+	}
+
+	ownerID := fileOwnerID(document, principal.UserID)
+
+	err = s.inTransaction(ctx, func(ctx context.Context) error {
+		if err := s.DocumentRepo.RestoreDocument(ctx, principal.Login, documentID); err != nil {
+			return fmt.Errorf("restoring document: %w", err)
+		}
+
+		restored := document
+		restored.DeletedAt = nil
+
+		return s.enqueueEvent(ctx, core.EventDocumentCreated, principal.Login, restored)
+	})
+	if err != nil {
+		return core.Document{}, err
+	}
+
+	if s.FileRepo.TrashFileExist(ownerID, document.ID) {
+		if err := s.FileRepo.RestoreFile(ownerID, document.ID, document.Name); err != nil {
+			fileErr := fmt.Errorf("restoring file: %w", err)
+
+			revertErr := s.revertChange(ctx, core.EventDocumentDeleted, principal.Login, document, func(ctx context.Context) error {
+				return s.DocumentRepo.TrashDocument(ctx, principal.Login, documentID, *document.DeletedAt, nil)
+			})
+			if revertErr != nil {
+				return core.Document{}, errors.Join(fileErr, revertErr)
+			}
+
+			return core.Document{}, fileErr
+		}
+	}
+
+	document.DeletedAt = nil
+
+	s.notify(core.EventDocumentCreated, principal.Login, document)
+
+	return document, nil
+}
+
+func (s *Service) PurgeDocument(principal core.Principal, documentID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.DefaultTimeout)
+	defer cancel()
+
+	document, err := s.DocumentRepo.TrashedDocument(ctx, principal.Login, documentID)
+	if err != nil {
+		return fmt.Errorf("getting document from trash: %w", err)
+	}
+
+	if len(document.OwnerID) == 0 {
+		document.OwnerID = principal.UserID
+	}
+
+	if err := s.checkRetention(ctx, document); err != nil {
+		return err
+	}
+
+	return s.purgeDocument(ctx, document)
+}
+
+// PurgeTrash permanently removes documents which stayed in the trash
+// longer than the configured retention.
+func (s *Service) PurgeTrash(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, s.DefaultTimeout)
+	defer cancel()
+
+	retention := s.TrashRetention
+	if retention <= 0 {
+		retention = defaultTrashRetention
+	}
+
+	documents, err := s.DocumentRepo.TrashedBefore(ctx, time.Now().Add(-retention))
+	if err != nil {
+		return fmt.Errorf("getting expired trash: %w", err)
+	}
+
+	var errs []error
+	for _, document := range documents {
+		err := s.checkRetention(ctx, document)
+		if errors.Is(err, e.ErrDocumentRetained) {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		if err := s.purgeDocument(ctx, document); err != nil {
+			errs = append(errs, fmt.Errorf("purging document %s: %w", document.ID, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// purgeDocument permanently removes the document together with its file,
+// wherever the file is stored, its versions, comments and relations.
+func (s *Service) purgeDocument(ctx context.Context, document core.Document) error {
+	if err := s.purgeFile(document); err != nil {
+		return fmt.Errorf("deleting file: %w", err)
+	}
+
+	if err := s.DocumentRepo.PurgeDocument(ctx, document.ID); err != nil {
+		return fmt.Errorf("deleting document: %w", err)
+	}
+
+	if err := s.FileRepo.DeleteVersionFiles(document.ID); err != nil {
+		return fmt.Errorf("deleting version files: %w", err)
+	}
+
+	if err := s.VersionRepo.DeleteVersions(ctx, document.ID); err != nil {
+		return fmt.Errorf("deleting document versions: %w", err)
+	}
+
+	if err := s.CommentRepo.DeleteComments(ctx, document.ID); err != nil {
+		return fmt.Errorf("deleting comments: %w", err)
+	}
+
+	if err := s.RelationRepo.DeleteRelations(ctx, document.ID); err != nil {
+		return fmt.Errorf("deleting relations: %w", err)
+	}
+
+	s.Cache.Delete(s.documentKey(document.ID))
+
+	return nil
+}
+
+func (s *Service) purgeFile(document core.Document) error {
+	if len(document.OwnerID) == 0 {
+		return nil
+	}
+
+	if document.DeletedAt != nil {
+		if !s.FileRepo.TrashFileExist(document.OwnerID, document.ID) {
+			return nil
+		}
+
+		return s.FileRepo.DeleteTrashFile(document.OwnerID, document.ID)
+	}
+
+	if !document.File || !s.FileRepo.FileExist(document.OwnerID, document.Name) {
+		return nil
+	}
+
+	return s.deleteFile(document.OwnerID, document.Name)
+}
@@ -0,0 +1,517 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/base64"
+	"fmt"
+	"image/png"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/GroVlAn/doc-store/internal/core"
+	"github.com/GroVlAn/doc-store/internal/core/e"
+	"github.com/GroVlAn/doc-store/internal/keyring"
+	"github.com/google/uuid"
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+)
+
+const (
+	totpPeriod        = 30
+	totpDigits        = 6
+	qrSize            = 256
+	challengeSize     = 32
+	recoveryCodeCount = 10
+	recoveryCodeSize  = 6
+
+	defaultTOTPIssuer        = "doc-store"
+	defaultChallengeTTL      = 5 * time.Minute
+	defaultChallengeAttempts = 5
+)
+
+var totpOpts = totp.ValidateOpts{
+	Period:    totpPeriod,
+	Digits:    otp.DigitsSix,
+	Algorithm: otp.AlgorithmSHA1,
+}
+
+// CompleteTwoFactor finishes a login challenged by Auth. A login of a user
+// the policy forces to enroll confirms the enrollment and also returns the
+// recovery codes.
+func (s *Service) CompleteTwoFactor(challenge string, code string, client core.Client) (core.AuthResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.DefaultTimeout)
+	defer cancel()
+
+	if len(challenge) == 0 {
+		return core.AuthResult{}, e.ErrInvalidChallenge
+	}
+
+	challengeID := hashToken(challenge)
+
+	authChallenge, err := s.TwoFactorRepo.ClaimAuthChallenge(ctx, challengeID, time.Now(), s.challengeAttempts())
+	if err != nil {
+		return core.AuthResult{}, err
+	}
+
+	user, err := s.UserRepo.UserByID(ctx, authChallenge.UserID)
+	if err != nil {
+		return core.AuthResult{}, e.ErrUserNotFound
+	}
+
+	var recoveryCodes []string
+
+	if authChallenge.Enroll && (user.TwoFactor == nil || !user.TwoFactor.Enabled) {
+		recoveryCodes, err = s.confirmTOTP(ctx, user, code)
+	} else {
+		err = s.verifySecondFactor(ctx, user, code)
+	}
+	if err != nil {
+		return core.AuthResult{}, err
+	}
+
+	if err := s.TwoFactorRepo.DeleteAuthChallenge(ctx, challengeID); err != nil {
+		return core.AuthResult{}, fmt.Errorf("deleting auth challenge: %w", err)
+	}
+
+	tokens, err := s.issueTokens(ctx, user, uuid.NewString(), time.Now(), client)
+	if err != nil {
+		return core.AuthResult{}, err
+	}
+
+	return core.AuthResult{TokenPair: &tokens, RecoveryCodes: recoveryCodes}, nil
+}
+
+// EnrollTOTP starts a TOTP enrollment, it replaces a pending one and takes
+// effect once ConfirmTOTP accepts a code.
+func (s *Service) EnrollTOTP(principal core.Principal) (core.TOTPEnrollment, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.DefaultTimeout)
+	defer cancel()
+
+	user, err := s.accountUser(ctx, principal)
+	if err != nil {
+		return core.TOTPEnrollment{}, err
+	}
+
+	if user.TwoFactor != nil && user.TwoFactor.Enabled {
+		return core.TOTPEnrollment{}, e.ErrTwoFactorEnabled
+	}
+
+	return s.startEnrollment(ctx, user)
+}
+
+// ConfirmTOTP enables the pending enrollment and returns the recovery codes,
+// they are never shown again.
+func (s *Service) ConfirmTOTP(principal core.Principal, code string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.DefaultTimeout)
+	defer cancel()
+
+	user, err := s.accountUser(ctx, principal)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.TwoFactor != nil && user.TwoFactor.Enabled {
+		return nil, e.ErrTwoFactorEnabled
+	}
+
+	return s.confirmTOTP(ctx, user, code)
+}
+
+// DisableTOTP removes the enrollment, it needs a current code and is denied
+// while the policy requires 2FA for the user.
+func (s *Service) DisableTOTP(principal core.Principal, code string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.DefaultTimeout)
+	defer cancel()
+
+	user, err := s.accountUser(ctx, principal)
+	if err != nil {
+		return err
+	}
+
+	if user.TwoFactor == nil || !user.TwoFactor.Enabled {
+		return e.ErrTwoFactorNotEnabled
+	}
+
+	required, err := s.twoFactorRequired(ctx, user)
+	if err != nil {
+		return err
+	}
+	if required {
+		return e.ErrTwoFactorRequired
+	}
+
+	if err := s.verifySecondFactor(ctx, user, code); err != nil {
+		return err
+	}
+
+	if err := s.UserRepo.DeleteTwoFactor(ctx, user.ID); err != nil {
+		return fmt.Errorf("deleting two-factor: %w", err)
+	}
+
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of the caller.
+func (s *Service) RegenerateRecoveryCodes(principal core.Principal, code string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.DefaultTimeout)
+	defer cancel()
+
+	user, err := s.accountUser(ctx, principal)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.TwoFactor == nil || !user.TwoFactor.Enabled {
+		return nil, e.ErrTwoFactorNotEnabled
+	}
+
+	if err := s.verifySecondFactor(ctx, user, code); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.UserRepo.SetRecoveryCodes(ctx, user.ID, hashes); err != nil {
+		return nil, fmt.Errorf("updating recovery codes: %w", err)
+	}
+
+	return codes, nil
+}
+
+func (s *Service) TwoFactorPolicy(principal core.Principal) (core.TwoFactorPolicy, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.DefaultTimeout)
+	defer cancel()
+
+	if err := s.requireAdmin(ctx, principal); err != nil {
+		return core.TwoFactorPolicy{}, err
+	}
+
+	policy, err := s.TwoFactorRepo.TwoFactorPolicy(ctx)
+	if err != nil {
+		return core.TwoFactorPolicy{}, fmt.Errorf("getting two-factor policy: %w", err)
+	}
+
+	return policy, nil
+}
+
+// SetTwoFactorPolicy replaces the policy. Users it covers without TOTP are
+// asked to enroll on their next login.
+func (s *Service) SetTwoFactorPolicy(principal core.Principal, policy core.TwoFactorPolicy) (core.TwoFactorPolicy, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.DefaultTimeout)
+	defer cancel()
+
+	if err := s.requireAdmin(ctx, principal); err != nil {
+		return core.TwoFactorPolicy{}, err
+	}
+
+	for _, role := range policy.Roles {
+		if role != core.RoleUser && role != core.RoleAdmin {
+			return core.TwoFactorPolicy{}, e.ErrInvalidTwoFactorRule
+		}
+	}
+
+	policy.Token = ""
+	policy.Updated = time.Now()
+
+	if err := s.TwoFactorRepo.SetTwoFactorPolicy(ctx, policy); err != nil {
+		return core.TwoFactorPolicy{}, fmt.Errorf("saving two-factor policy: %w", err)
+	}
+
+	return policy, nil
+}
+
+// secondFactor completes a login whose first factor is verified: it issues
+// tokens or challenges users with TOTP and users the policy requires to
+// enroll.
+func (s *Service) secondFactor(ctx context.Context, user core.User, client core.Client) (core.AuthResult, error) {
+	if user.TwoFactor != nil && user.TwoFactor.Enabled {
+		return s.createChallenge(ctx, user, nil)
+	}
+
+	required, err := s.twoFactorRequired(ctx, user)
+	if err != nil {
+		return core.AuthResult{}, err
+	}
+
+	if required {
+		enrollment, err := s.startEnrollment(ctx, user)
+		if err != nil {
+			return core.AuthResult{}, err
+		}
+
+		return s.createChallenge(ctx, user, &enrollment)
+	}
+
+	tokens, err := s.issueTokens(ctx, user, uuid.NewString(), time.Now(), client)
+	if err != nil {
+		return core.AuthResult{}, err
+	}
+
+	return core.AuthResult{TokenPair: &tokens}, nil
+}
+
+func (s *Service) createChallenge(
+	ctx context.Context,
+	user core.User,
+	enrollment *core.TOTPEnrollment,
+) (core.AuthResult, error) {
+	secret, err := generateSecret(challengeSize)
+	if err != nil {
+		return core.AuthResult{}, fmt.Errorf("generating challenge: %w", err)
+	}
+
+	ttl := s.ChallengeTTL
+	if ttl <= 0 {
+		ttl = defaultChallengeTTL
+	}
+
+	challenge := core.AuthChallenge{
+		ID:        hashToken(secret),
+		UserID:    user.ID,
+		Enroll:    enrollment != nil,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+
+	if err := s.TwoFactorRepo.CreateAuthChallenge(ctx, challenge); err != nil {
+		return core.AuthResult{}, fmt.Errorf("creating auth challenge: %w", err)
+	}
+
+	return core.AuthResult{
+		TwoFactor: &core.TwoFactorChallenge{
+			Challenge:  secret,
+			ExpiresIn:  int64(ttl.Seconds()),
+			Enrollment: enrollment,
+		},
+	}, nil
+}
+
+func (s *Service) startEnrollment(ctx context.Context, user core.User) (core.TOTPEnrollment, error) {
+	issuer := s.TOTPIssuer
+	if len(issuer) == 0 {
+		issuer = defaultTOTPIssuer
+	}
+
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      issuer,
+		AccountName: user.Login,
+		Period:      totpOpts.Period,
+		Digits:      totpOpts.Digits,
+		Algorithm:   totpOpts.Algorithm,
+	})
+	if err != nil {
+		return core.TOTPEnrollment{}, fmt.Errorf("generating totp secret: %w", err)
+	}
+
+	sealed, err := keyring.Seal(s.SecretKey, []byte(key.Secret()))
+	if err != nil {
+		return core.TOTPEnrollment{}, fmt.Errorf("sealing totp secret: %w", err)
+	}
+
+	if err := s.UserRepo.SetTwoFactor(ctx, user.ID, core.TwoFactor{Secret: sealed}); err != nil {
+		return core.TOTPEnrollment{}, fmt.Errorf("saving totp secret: %w", err)
+	}
+
+	qr, err := qrDataURL(key)
+	if err != nil {
+		return core.TOTPEnrollment{}, err
+	}
+
+	return core.TOTPEnrollment{
+		Secret: key.Secret(),
+		URI:    key.URL(),
+		QR:     qr,
+	}, nil
+}
+
+func (s *Service) confirmTOTP(ctx context.Context, user core.User, code string) ([]string, error) {
+	if user.TwoFactor == nil {
+		return nil, e.ErrTwoFactorNotEnabled
+	}
+
+	step, err := s.totpStep(*user.TwoFactor, normalizeCode(code))
+	if err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	twoFactor := core.TwoFactor{
+		Secret:        user.TwoFactor.Secret,
+		Enabled:       true,
+		LastStep:      step,
+		RecoveryCodes: hashes,
+	}
+
+	if err := s.UserRepo.SetTwoFactor(ctx, user.ID, twoFactor); err != nil {
+		return nil, fmt.Errorf("enabling two-factor: %w", err)
+	}
+
+	return codes, nil
+}
+
+// verifySecondFactor accepts a TOTP code once or an unused recovery code.
+// Attempts are throttled per user like logins, whichever action asks for the
+// code.
+func (s *Service) verifySecondFactor(ctx context.Context, user core.User, code string) error {
+	if user.TwoFactor == nil || !user.TwoFactor.Enabled {
+		return e.ErrTwoFactorNotEnabled
+	}
+
+	key := twoFactorKeyPrefix + user.ID
+
+	if err := s.reserveAttempt(ctx, map[string]AttemptLimit{key: s.loginThrottle().Login}); err != nil {
+		return err
+	}
+
+	if err := s.useSecondFactor(ctx, user, code); err != nil {
+		return err
+	}
+
+	if s.AttemptRepo != nil {
+		if err := s.AttemptRepo.ClearLoginAttempts(ctx, key); err != nil {
+			return fmt.Errorf("clearing two-factor attempts: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) useSecondFactor(ctx context.Context, user core.User, code string) error {
+	code = normalizeCode(code)
+	if len(code) == 0 {
+		return e.ErrInvalidTOTP
+	}
+
+	if isTOTPCode(code) {
+		step, err := s.totpStep(*user.TwoFactor, code)
+		if err != nil {
+			return err
+		}
+
+		return s.UserRepo.UseTOTPStep(ctx, user.ID, step)
+	}
+
+	return s.UserRepo.UseRecoveryCode(ctx, user.ID, hashToken(code))
+}
+
+// totpStep returns the time step the code belongs to, a step before or after
+// the current one is accepted for clock drift.
+func (s *Service) totpStep(twoFactor core.TwoFactor, code string) (int64, error) {
+	if !isTOTPCode(code) {
+		return 0, e.ErrInvalidTOTP
+	}
+
+	secret, err := keyring.Open(s.SecretKey, twoFactor.Secret)
+	if err != nil {
+		return 0, fmt.Errorf("opening totp secret: %w", err)
+	}
+
+	now := time.Now()
+
+	for _, skew := range []int64{0, -1, 1} {
+		t := now.Add(time.Duration(skew*totpPeriod) * time.Second)
+
+		expected, err := totp.GenerateCodeCustom(string(secret), t, totpOpts)
+		if err != nil {
+			return 0, fmt.Errorf("generating totp code: %w", err)
+		}
+
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return t.Unix() / totpPeriod, nil
+		}
+	}
+
+	return 0, e.ErrInvalidTOTP
+}
+
+func (s *Service) twoFactorRequired(ctx context.Context, user core.User) (bool, error) {
+	policy, err := s.TwoFactorRepo.TwoFactorPolicy(ctx)
+	if err != nil {
+		return false, fmt.Errorf("getting two-factor policy: %w", err)
+	}
+
+	if !policy.Required {
+		return false, nil
+	}
+	if len(policy.Roles) == 0 {
+		return true, nil
+	}
+
+	role := user.Role
+	if len(role) == 0 {
+		role = core.RoleUser
+	}
+
+	return slices.Contains(policy.Roles, role), nil
+}
+
+func (s *Service) challengeAttempts() int {
+	if s.ChallengeAttempts <= 0 {
+		return defaultChallengeAttempts
+	}
+
+	return s.ChallengeAttempts
+}
+
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+
+	b := make([]byte, recoveryCodeSize)
+
+	for range recoveryCodeCount {
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, fmt.Errorf("generating recovery code: %w", err)
+		}
+
+		code := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b))
+
+		codes = append(codes, code[:len(code)/2]+"-"+code[len(code)/2:])
+		hashes = append(hashes, hashToken(code))
+	}
+
+	return codes, hashes, nil
+}
+
+func normalizeCode(code string) string {
+	return strings.ToLower(strings.NewReplacer(" ", "", "-", "").Replace(code))
+}
+
+func isTOTPCode(code string) bool {
+	if len(code) != totpDigits {
+		return false
+	}
+
+	for _, ch := range code {
+		if ch < '0' || ch > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func qrDataURL(key *otp.Key) (string, error) {
+	img, err := key.Image(qrSize, qrSize)
+	if err != nil {
+		return "", fmt.Errorf("creating qr code: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return "", fmt.Errorf("encoding qr code: %w", err)
+	}
+
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
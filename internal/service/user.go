@@ -2,8 +2,9 @@ package service
 
 import (
 	"context"
-	"errors"
 	"fmt"
+	"net/mail"
+	"strings"
 	"time"
 	"unicode"
 
@@ -11,9 +12,10 @@ import (
 	"github.com/GroVlAn/doc-store/internal/core/e"
 	"github.com/golang-jwt/jwt"
 	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
 )
 
+const maxLoginLen = 128
+
 func (s *Service) Register(user core.User) error {
 	ctx, cancel := context.WithTimeout(context.Background(), s.DefaultTimeout)
 	defer cancel()
@@ -29,60 +31,86 @@ func (s *Service) Register(user core.User) error {
 	return nil
 }
 
-func (s *Service) Auth(user core.User) (string, error) {
+// Auth verifies the password with the configured authenticators and starts
+// a new token family, users with 2FA get a challenge for CompleteTwoFactor
+// instead of tokens. Logins and IPs with many failures are throttled.
+func (s *Service) Auth(user core.User, client core.Client) (core.AuthResult, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), s.DefaultTimeout)
 	defer cancel()
 
-	userFromDB, err := s.UserRepo.User(ctx, user.Login)
-	if err != nil {
-		return "", fmt.Errorf("getting user: %w", err)
+	if err := s.reserveLogin(ctx, user.Login, client); err != nil {
+		return core.AuthResult{}, err
 	}
 
-	if err := s.verifyPassword(userFromDB, user); err != nil {
-		return "", err
+	identity, err := s.authenticate(ctx, user.Login, user.Password)
+	if err := s.recordLogin(ctx, user.Login, client, err); err != nil {
+		return core.AuthResult{}, err
 	}
-
-	accessToken, err := s.createAccessToken(userFromDB)
 	if err != nil {
-		return "", err
+		return core.AuthResult{}, err
 	}
 
-	if err = s.saveAccessToken(ctx, accessToken); err != nil {
-		return "", err
+	userFromDB, err := s.identityUser(ctx, identity)
+	if err != nil {
+		return core.AuthResult{}, err
 	}
 
-	return accessToken.Token, nil
+	return s.secondFactor(ctx, userFromDB, client)
 }
 
-func (s *Service) VerifyAccessToken(token string) error {
+// Authenticate verifies the access token or API key and returns the caller
+// it was issued to.
+func (s *Service) Authenticate(token string, client core.Client) (core.Principal, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), s.DefaultTimeout)
 	defer cancel()
 
-	if err := s.checkExistToken(ctx, token); err != nil {
-		return err
+	if strings.HasPrefix(token, core.APIKeyPrefix) {
+		return s.authenticateAPIKey(ctx, token, client)
+	}
+
+	accessToken, err := s.existToken(ctx, token)
+	if err != nil {
+		return core.Principal{}, err
 	}
 
 	tokenDetails, err := s.parseToken(token)
 	if err != nil {
-		return err
+		return core.Principal{}, err
 	}
 
 	if err := s.checkExpiredToken(ctx, token, tokenDetails); err != nil {
-		return err
+		return core.Principal{}, err
 	}
 
 	if err := s.checkUserByToken(ctx, tokenDetails); err != nil {
-		return e.ErrUserNotFound
+		return core.Principal{}, e.ErrUserNotFound
 	}
 
-	return nil
+	if err := s.touchToken(ctx, accessToken); err != nil {
+		return core.Principal{}, err
+	}
+
+	userID, _ := tokenDetails["user_id"].(string)
+	login, _ := tokenDetails["login"].(string)
+
+	return core.Principal{UserID: userID, Login: login, SessionID: accessToken.FamilyID}, nil
 }
 
+// Logout revokes the token together with the refresh tokens of its family.
 func (s *Service) Logout(token string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), s.DefaultTimeout)
 	defer cancel()
 
-	err := s.TokenRepo.DeleteToken(ctx, token)
+	accessToken, err := s.TokenRepo.Token(ctx, token)
+	if err == nil && len(accessToken.FamilyID) > 0 {
+		if err := s.TokenRepo.DeleteTokenFamily(ctx, accessToken.FamilyID); err != nil {
+			return fmt.Errorf("deleting token family: %w", err)
+		}
+
+		return nil
+	}
+
+	err = s.TokenRepo.DeleteToken(ctx, token)
 	if err != nil {
 		return fmt.Errorf("deleting token: %w", err)
 	}
@@ -115,12 +143,13 @@ func (s *Service) checkExpiredToken(ctx context.Context, token string, tokenDeta
 	return nil
 }
 
-func (s *Service) checkExistToken(ctx context.Context, token string) error {
-	if _, err := s.TokenRepo.Token(ctx, token); err != nil {
-		return &e.ErrInvalidToken{Msg: "invalid token", Err: fmt.Errorf("getting token: %w", err)}
+func (s *Service) existToken(ctx context.Context, token string) (core.AccessToken, error) {
+	accessToken, err := s.TokenRepo.Token(ctx, token)
+	if err != nil {
+		return core.AccessToken{}, &e.ErrInvalidToken{Msg: "invalid token", Err: fmt.Errorf("getting token: %w", err)}
 	}
 
-	return nil
+	return accessToken, nil
 }
 
 func (s *Service) parseToken(token string) (jwt.MapClaims, error) {
@@ -129,9 +158,7 @@ func (s *Service) parseToken(token string) (jwt.MapClaims, error) {
 	jwtToken, err := jwt.ParseWithClaims(
 		token,
 		tokenClaims,
-		func(token *jwt.Token) (interface{}, error) {
-			return []byte(s.SecretKey), nil
-		},
+		s.verificationKey,
 	)
 	if err != nil {
 		return jwt.MapClaims{}, &e.ErrInvalidToken{Msg: "invalid token", Err: fmt.Errorf("parsing access token: %w", err)}
@@ -153,6 +180,20 @@ func (s *Service) deleteTokenWithError(ctx context.Context, token string) error
 	return &e.ErrInvalidToken{Msg: "invalid token"}
 }
 
+// validateLogin rejects a login which is empty, too long or contains spaces
+// or control characters.
+func validateLogin(login string) error {
+	if len(login) == 0 || len(login) > maxLoginLen {
+		return e.ErrInvalidLogin
+	}
+
+	if strings.ContainsFunc(login, func(r rune) bool { return unicode.IsSpace(r) || unicode.IsControl(r) }) {
+		return e.ErrInvalidLogin
+	}
+
+	return nil
+}
+
 func (s *Service) verifyNewUser(ctx context.Context, user core.User) error {
 	userFromDB, err := s.UserRepo.User(ctx, user.Login)
 	if err != nil && err != e.ErrUserNotFound {
@@ -162,8 +203,8 @@ func (s *Service) verifyNewUser(ctx context.Context, user core.User) error {
 		return e.ErrUserAlreadyExist
 	}
 
-	if len(user.Login) == 0 {
-		return e.ErrInvalidLogin
+	if err := validateLogin(user.Login); err != nil {
+		return err
 	}
 
 	valid := s.validatePassword(user.Password)
@@ -171,16 +212,22 @@ func (s *Service) verifyNewUser(ctx context.Context, user core.User) error {
 		return e.ErrInvalidPassword
 	}
 
+	if len(user.Email) > 0 {
+		if _, err := mail.ParseAddress(user.Email); err != nil {
+			return e.ErrInvalidEmail
+		}
+	}
+
 	return nil
 }
 
 func (s *Service) createUser(ctx context.Context, user core.User) error {
 	user.ID = uuid.NewString()
-	password, err := bcrypt.GenerateFromPassword([]byte(user.Password), s.HashCost)
+	password, err := s.hashPassword(ctx, user.Password)
 	if err != nil {
-		return fmt.Errorf("generating password: %w", err)
+		return err
 	}
-	user.Password = string(password)
+	user.Password = password
 
 	err = s.UserRepo.CreateUser(ctx, user)
 	if err != nil {
@@ -218,22 +265,10 @@ func (s *Service) validatePassword(pswd string) bool {
 	return isNumber && isLower && isUpper && isSymbol
 }
 
-func (s *Service) verifyPassword(userFromDB, user core.User) error {
-	err := bcrypt.CompareHashAndPassword([]byte(userFromDB.Password), []byte(user.Password))
-	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
-		return e.ErrInvalidPassword
-	}
-	if err != nil {
-		return fmt.Errorf("comparing hash adn password: %w", err)
-	}
-
-	return nil
-}
-
-func (s *Service) createAccessToken(user core.User) (core.AccessToken, error) {
+func (s *Service) createAccessToken(user core.User, familyID string) (core.AccessToken, error) {
 	accessToken := core.AccessToken{}
 	accessToken.StartTTL = time.Now()
-	accessToken.EndTTl = accessToken.StartTTL.Add(s.TokenEndTTL)
+	accessToken.EndTTl = accessToken.StartTTL.Add(s.AccessTokenTTL)
 
 	payload := jwt.MapClaims{
 		"user_id": user.ID,
@@ -241,15 +276,15 @@ func (s *Service) createAccessToken(user core.User) (core.AccessToken, error) {
 		"exp":     accessToken.EndTTl.Unix(),
 	}
 
-	jwtToken := jwt.NewWithClaims(jwt.SigningMethodHS256, payload)
-
-	t, err := jwtToken.SignedString([]byte(s.SecretKey))
+	t, err := s.signToken(payload)
 	if err != nil {
 		return core.AccessToken{}, fmt.Errorf("creating access token: %w", err)
 	}
 
 	accessToken.ID = uuid.NewString()
 	accessToken.Token = t
+	accessToken.Kind = core.TokenKindAccess
+	accessToken.FamilyID = familyID
 	accessToken.UserID = user.ID
 
 	return accessToken, nil
@@ -0,0 +1,192 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/GroVlAn/doc-store/internal/core"
+	"github.com/GroVlAn/doc-store/internal/core/e"
+	"github.com/GroVlAn/doc-store/internal/diff"
+)
+
+const (
+	maxDiffSize = 1 << 20
+)
+
+// DocumentVersions lists the stored versions of the document followed by its
+// current content.
+func (s *Service) DocumentVersions(principal core.Principal, documentID string) ([]core.DocumentVersion, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.DefaultTimeout)
+	defer cancel()
+
+	document, err := s.DocumentRepo.Document(ctx, principal.Login, documentID)
+	if err != nil {
+		return nil, e.ErrNoDocuments
+	}
+
+	versions, err := s.VersionRepo.Versions(ctx, documentID)
+	if err != nil {
+		return nil, fmt.Errorf("getting document versions: %w", err)
+	}
+
+	return append(versions, currentVersion(document)), nil
+}
+
+// DiffDocument compares the document content at two revisions. JSON
+// documents get a structural diff, text files a unified line diff. By default
+// the current revision is compared with the previous one.
+func (s *Service) DiffDocument(principal core.Principal, documentID string, from int64, to int64) (core.DocumentDiff, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.DefaultTimeout)
+	defer cancel()
+
+	document, err := s.DocumentRepo.Document(ctx, principal.Login, documentID)
+	if err != nil {
+		return core.DocumentDiff{}, e.ErrNoDocuments
+	}
+
+	if to == 0 {
+		to = document.Revision
+	}
+	if from == 0 {
+		from = to - 1
+	}
+
+	if from < 1 || to < 1 || from > document.Revision || to > document.Revision {
+		return core.DocumentDiff{}, e.ErrVersionNotFound
+	}
+
+	versionFrom, err := s.versionAt(ctx, document, from)
+	if err != nil {
+		return core.DocumentDiff{}, err
+	}
+
+	versionTo, err := s.versionAt(ctx, document, to)
+	if err != nil {
+		return core.DocumentDiff{}, err
+	}
+
+	documentDiff := core.DocumentDiff{
+		DocumentID: documentID,
+		From:       from,
+		To:         to,
+	}
+
+	switch {
+	case versionFrom.Json != nil && versionTo.Json != nil:
+		documentDiff.Changes, err = diff.JSON(versionFrom.Json, versionTo.Json)
+		if err != nil {
+			return core.DocumentDiff{}, fmt.Errorf("%w: %w", e.ErrNotDiffable, err)
+		}
+	case versionFrom.File && versionTo.File:
+		ownerID := fileOwnerID(document, principal.UserID)
+
+		textFrom, err := s.versionText(ownerID, versionFrom)
+		if err != nil {
+			return core.DocumentDiff{}, err
+		}
+
+		textTo, err := s.versionText(ownerID, versionTo)
+		if err != nil {
+			return core.DocumentDiff{}, err
+		}
+
+		documentDiff.Unified = diff.Unified(
+			fmt.Sprintf("%s@%d", versionFrom.Name, from),
+			fmt.Sprintf("%s@%d", versionTo.Name, to),
+			string(textFrom),
+			string(textTo),
+		)
+	default:
+		return core.DocumentDiff{}, e.ErrNotDiffable
+	}
+
+	return documentDiff, nil
+}
+
+// saveVersion keeps the content of the document before it is replaced.
+func (s *Service) saveVersion(ctx context.Context, document core.Document, userID string) error {
+	version := core.DocumentVersion{
+		ID:         fmt.Sprintf("%s:%d", document.ID, document.Revision),
+		DocumentID: document.ID,
+		Revision:   document.Revision,
+		Name:       document.Name,
+		Mime:       document.Mime,
+		Json:       document.Json,
+		Created:    document.Created,
+	}
+
+	ownerID := fileOwnerID(document, userID)
+	if s.hasFile(ownerID, document) {
+		err := s.FileRepo.SaveVersionFile(ownerID, document.Name, document.ID, document.Revision)
+		if err != nil {
+			return fmt.Errorf("saving version file: %w", err)
+		}
+
+		version.File = true
+	}
+
+	if err := s.VersionRepo.SaveVersion(ctx, version); err != nil {
+		return fmt.Errorf("saving document version: %w", err)
+	}
+
+	return nil
+}
+
+// versionAt returns the content of the document at the revision, a version
+// without ID is the current content.
+func (s *Service) versionAt(ctx context.Context, document core.Document, revision int64) (core.DocumentVersion, error) {
+	version, err := s.VersionRepo.VersionAt(ctx, document.ID, revision)
+	if errors.Is(err, e.ErrNoDocuments) {
+		return currentVersion(document), nil
+	}
+	if err != nil {
+		return core.DocumentVersion{}, fmt.Errorf("getting document version: %w", err)
+	}
+
+	return version, nil
+}
+
+func (s *Service) versionText(ownerID string, version core.DocumentVersion) ([]byte, error) {
+	var (
+		filePath string
+		err      error
+	)
+
+	if len(version.ID) == 0 {
+		filePath, err = s.FileRepo.File(ownerID, version.Name)
+	} else {
+		filePath, err = s.FileRepo.VersionFile(version.DocumentID, version.Revision)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("loading file: %w", err)
+	}
+
+	text, err := s.FileRepo.ReadFile(filePath, maxDiffSize)
+	if errors.Is(err, e.ErrFileTooLarge) {
+		return nil, fmt.Errorf("%w: %w", e.ErrNotDiffable, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading file: %w", err)
+	}
+
+	if !utf8.Valid(text) || bytes.IndexByte(text, 0) >= 0 {
+		return nil, fmt.Errorf("%w: %s is not a text file", e.ErrNotDiffable, version.Name)
+	}
+
+	return text, nil
+}
+
+func currentVersion(document core.Document) core.DocumentVersion {
+	return core.DocumentVersion{
+		DocumentID: document.ID,
+		Revision:   document.Revision,
+		Name:       document.Name,
+		Mime:       document.Mime,
+		File:       document.File,
+		Json:       document.Json,
+		Created:    document.Created,
+	}
+}
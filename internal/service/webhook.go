@@ -0,0 +1,360 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/GroVlAn/doc-store/internal/core"
+	"github.com/GroVlAn/doc-store/internal/core/e"
+	"github.com/google/uuid"
+)
+
+const (
+	webhookSecretLen   = 32
+	webhookBatchSize   = 100
+	webhookDeliveryLog = 100
+	webhookMaxBackoff  = time.Hour
+	webhookSignature   = "X-DocStore-Signature"
+	webhookEventHeader = "X-DocStore-Event"
+	webhookDeliveryID  = "X-DocStore-Delivery"
+)
+
+// sharedAddrSpace is the carrier-grade NAT range, it isn't covered by
+// netip.Addr.IsPrivate.
+var sharedAddrSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+var webhookEvents = []string{
+	core.EventDocumentCreated,
+	core.EventDocumentReplaced,
+	core.EventDocumentUpdated,
+	core.EventDocumentShared,
+	core.EventDocumentDeleted,
+}
+
+func (s *Service) CreateWebhook(principal core.Principal, webhook core.Webhook) (core.Webhook, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.DefaultTimeout)
+	defer cancel()
+
+	if err := validateWebhook(webhook, s.WebhookAllowPrivate); err != nil {
+		return core.Webhook{}, err
+	}
+
+	if len(webhook.Secret) == 0 {
+		secret, err := generateSecret(webhookSecretLen)
+		if err != nil {
+			return core.Webhook{}, fmt.Errorf("generating webhook secret: %w", err)
+		}
+
+		webhook.Secret = secret
+	}
+
+	webhook.ID = uuid.NewString()
+	webhook.Token = ""
+	webhook.UserID = principal.UserID
+	webhook.Login = principal.Login
+	webhook.Created = time.Now()
+
+	if err := s.WebhookRepo.CreateWebhook(ctx, webhook); err != nil {
+		return core.Webhook{}, fmt.Errorf("creating webhook: %w", err)
+	}
+
+	return webhook, nil
+}
+
+func (s *Service) Webhooks(principal core.Principal) ([]core.Webhook, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.DefaultTimeout)
+	defer cancel()
+
+	webhooks, err := s.WebhookRepo.Webhooks(ctx, principal.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("getting webhooks: %w", err)
+	}
+
+	for i := range webhooks {
+		webhooks[i].Secret = ""
+	}
+
+	return webhooks, nil
+}
+
+func (s *Service) DeleteWebhook(principal core.Principal, webhookID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.DefaultTimeout)
+	defer cancel()
+
+	if err := s.WebhookRepo.DeleteWebhook(ctx, principal.UserID, webhookID); err != nil {
+		return fmt.Errorf("deleting webhook: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Service) WebhookDeliveries(principal core.Principal, webhookID string) ([]core.DeliveryAttempt, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.DefaultTimeout)
+	defer cancel()
+
+	webhook, err := s.WebhookRepo.Webhook(ctx, webhookID)
+	if err != nil {
+		return nil, fmt.Errorf("getting webhook: %w", err)
+	}
+
+	if webhook.UserID != principal.UserID {
+		return nil, e.ErrWebhookNotFound
+	}
+
+	attempts, err := s.WebhookRepo.DeliveryAttempts(ctx, webhookID, webhookDeliveryLog)
+	if err != nil {
+		return nil, fmt.Errorf("getting delivery attempts: %w", err)
+	}
+
+	return attempts, nil
+}
+
+// DeliverWebhooks sends due deliveries from the outbox, rescheduling failed
+// ones with exponential backoff until they run out of attempts.
+func (s *Service) DeliverWebhooks(ctx context.Context) error {
+	var errs []error
+
+	for range webhookBatchSize {
+		delivered, err := s.deliverNext(ctx)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		if !delivered {
+			break
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (s *Service) deliverNext(ctx context.Context) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.DefaultTimeout+s.WebhookTimeout)
+	defer cancel()
+
+	delivery, err := s.WebhookRepo.ClaimDelivery(ctx, time.Now(), s.WebhookTimeout+s.DefaultTimeout)
+	if errors.Is(err, e.ErrNoDeliveries) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("claiming webhook delivery: %w", err)
+	}
+
+	webhook, err := s.WebhookRepo.Webhook(ctx, delivery.WebhookID)
+	if errors.Is(err, e.ErrWebhookNotFound) {
+		delivery.Status = core.DeliveryFailed
+		delivery.LastError = err.Error()
+
+		return true, s.WebhookRepo.UpdateDelivery(ctx, delivery)
+	}
+	if err != nil {
+		return true, fmt.Errorf("getting webhook: %w", err)
+	}
+
+	attempt := s.sendWebhook(ctx, webhook, delivery)
+
+	delivery.Attempts++
+	delivery.LastError = attempt.Error
+
+	switch {
+	case len(attempt.Error) == 0:
+		delivery.Status = core.DeliveryDelivered
+	case delivery.Attempts >= s.WebhookMaxAttempts:
+		delivery.Status = core.DeliveryFailed
+	default:
+		delivery.NextAttempt = time.Now().Add(webhookBackoff(s.WebhookBackoff, delivery.Attempts))
+	}
+
+	if err := s.WebhookRepo.CreateDeliveryAttempt(ctx, attempt); err != nil {
+		return true, fmt.Errorf("logging delivery attempt: %w", err)
+	}
+
+	if err := s.WebhookRepo.UpdateDelivery(ctx, delivery); err != nil {
+		return true, fmt.Errorf("updating webhook delivery: %w", err)
+	}
+
+	return true, nil
+}
+
+func (s *Service) sendWebhook(ctx context.Context, webhook core.Webhook, delivery core.WebhookDelivery) core.DeliveryAttempt {
+	attempt := core.DeliveryAttempt{
+		ID:         uuid.NewString(),
+		DeliveryID: delivery.ID,
+		WebhookID:  webhook.ID,
+		Event:      delivery.Event,
+		Attempt:    delivery.Attempts + 1,
+		Time:       time.Now(),
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.WebhookTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		attempt.Error = fmt.Sprintf("creating request: %s", err)
+
+		return attempt
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhookEventHeader, delivery.Event)
+	req.Header.Set(webhookDeliveryID, delivery.ID)
+	req.Header.Set(webhookSignature, signPayload(webhook.Secret, delivery.Payload))
+
+	resp, err := s.WebhookClient.Do(req)
+	attempt.Duration = time.Since(attempt.Time)
+	if err != nil {
+		attempt.Error = fmt.Sprintf("sending request: %s", err)
+
+		return attempt
+	}
+	defer resp.Body.Close()
+
+	attempt.StatusCode = resp.StatusCode
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		attempt.Error = fmt.Sprintf("unexpected status: %s", resp.Status)
+	}
+
+	return attempt
+}
+
+// enqueueEvent writes deliveries for every webhook of the users who can see
+// the document into the outbox.
+func (s *Service) enqueueEvent(ctx context.Context, event string, actor string, document core.Document) error {
+	webhooks, err := s.WebhookRepo.SubscribedWebhooks(ctx, document.Grant, event)
+	if err != nil {
+		return fmt.Errorf("getting subscribed webhooks: %w", err)
+	}
+
+	if len(webhooks) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	deliveries := make([]core.WebhookDelivery, 0, len(webhooks))
+
+	for _, webhook := range webhooks {
+		deliveryID := uuid.NewString()
+
+		payload, err := json.Marshal(core.WebhookPayload{
+			ID:       deliveryID,
+			Event:    event,
+			Actor:    actor,
+			Time:     now,
+			Document: document,
+		})
+		if err != nil {
+			return fmt.Errorf("marshaling webhook payload: %w", err)
+		}
+
+		deliveries = append(deliveries, core.WebhookDelivery{
+			ID:          deliveryID,
+			WebhookID:   webhook.ID,
+			Event:       event,
+			Payload:     payload,
+			Status:      core.DeliveryPending,
+			NextAttempt: now,
+			Created:     now,
+		})
+	}
+
+	if err := s.WebhookRepo.CreateDeliveries(ctx, deliveries); err != nil {
+		return fmt.Errorf("enqueueing webhook deliveries: %w", err)
+	}
+
+	return nil
+}
+
+// NewWebhookClient returns the client deliveries are sent with. Unless
+// allowPrivate is set it refuses to connect to loopback, private and
+// link-local addresses. The dialled address is checked, so neither DNS nor
+// redirects get around it.
+func NewWebhookClient(allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{}
+	if !allowPrivate {
+		dialer.Control = func(network string, address string, _ syscall.RawConn) error {
+			addr, err := netip.ParseAddrPort(address)
+			if err != nil || !publicAddr(addr.Addr()) {
+				return e.ErrWebhookAddress
+			}
+
+			return nil
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{Transport: transport}
+}
+
+func validateWebhook(webhook core.Webhook, allowPrivate bool) error {
+	u, err := url.Parse(webhook.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Hostname()) == 0 {
+		return e.ErrInvalidWebhook
+	}
+
+	// literal addresses are rejected early, host names are checked when dialled
+	if addr, err := netip.ParseAddr(u.Hostname()); err == nil && !allowPrivate && !publicAddr(addr) {
+		return e.ErrWebhookAddress
+	}
+
+	if len(webhook.Events) == 0 {
+		return e.ErrInvalidWebhook
+	}
+
+	for _, event := range webhook.Events {
+		if !slices.Contains(webhookEvents, event) {
+			return e.ErrInvalidWebhook
+		}
+	}
+
+	return nil
+}
+
+// publicAddr reports whether addr is reachable on the internet, as opposed
+// to the host itself or its networks.
+func publicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+
+	return addr.IsGlobalUnicast() && !addr.IsPrivate() && !sharedAddrSpace.Contains(addr)
+}
+
+func webhookBackoff(base time.Duration, attempts int) time.Duration {
+	backoff := base
+	for i := 1; i < attempts && backoff < webhookMaxBackoff; i++ {
+		backoff *= 2
+	}
+
+	return min(backoff, webhookMaxBackoff)
+}
+
+func signPayload(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func generateSecret(size int) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
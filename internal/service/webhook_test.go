@@ -0,0 +1,318 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/GroVlAn/doc-store/internal/core"
+	"github.com/GroVlAn/doc-store/internal/core/e"
+)
+
+const (
+	testWebhookSecret  = "webhook-secret"
+	testWebhookBackoff = time.Minute
+)
+
+// fakeWebhookRepo keeps webhooks, the outbox and the delivery log in memory.
+// Methods the delivery doesn't use panic through the nil interface.
+type fakeWebhookRepo struct {
+	webhookRepo
+
+	mu         sync.Mutex
+	webhooks   map[string]core.Webhook
+	deliveries []core.WebhookDelivery
+	attempts   []core.DeliveryAttempt
+}
+
+func (r *fakeWebhookRepo) Webhook(_ context.Context, webhookID string) (core.Webhook, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	webhook, ok := r.webhooks[webhookID]
+	if !ok {
+		return core.Webhook{}, e.ErrWebhookNotFound
+	}
+
+	return webhook, nil
+}
+
+func (r *fakeWebhookRepo) ClaimDelivery(_ context.Context, now time.Time, lease time.Duration) (core.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, delivery := range r.deliveries {
+		if delivery.Status == core.DeliveryPending && !delivery.NextAttempt.After(now) {
+			r.deliveries[i].NextAttempt = now.Add(lease)
+
+			return delivery, nil
+		}
+	}
+
+	return core.WebhookDelivery{}, e.ErrNoDeliveries
+}
+
+func (r *fakeWebhookRepo) UpdateDelivery(_ context.Context, delivery core.WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.deliveries {
+		if r.deliveries[i].ID == delivery.ID {
+			r.deliveries[i] = delivery
+		}
+	}
+
+	return nil
+}
+
+func (r *fakeWebhookRepo) CreateDeliveryAttempt(_ context.Context, attempt core.DeliveryAttempt) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.attempts = append(r.attempts, attempt)
+
+	return nil
+}
+
+// due makes the pending deliveries due now, as if the backoff had passed.
+func (r *fakeWebhookRepo) due() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.deliveries {
+		r.deliveries[i].NextAttempt = time.Time{}
+	}
+}
+
+func (r *fakeWebhookRepo) delivery() core.WebhookDelivery {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.deliveries[0]
+}
+
+// newWebhookStandIn returns a service delivering one pending event to the
+// URL, and its repository.
+func newWebhookStandIn(url string, maxAttempts int, client httpClient) (*Service, *fakeWebhookRepo) {
+	repo := &fakeWebhookRepo{
+		webhooks: map[string]core.Webhook{
+			"webhook-1": {ID: "webhook-1", URL: url, Secret: testWebhookSecret},
+		},
+		deliveries: []core.WebhookDelivery{{
+			ID:        "delivery-1",
+			WebhookID: "webhook-1",
+			Event:     core.EventDocumentCreated,
+			Payload:   []byte(`{"id":"delivery-1","event":"document.created"}`),
+			Status:    core.DeliveryPending,
+		}},
+	}
+
+	s := New(Deps{
+		WebhookRepo:        repo,
+		WebhookClient:      client,
+		DefaultTimeout:     time.Second,
+		WebhookTimeout:     time.Second,
+		WebhookBackoff:     testWebhookBackoff,
+		WebhookMaxAttempts: maxAttempts,
+	})
+
+	return s, repo
+}
+
+// statusSequence is a webhook receiver answering with the statuses in order,
+// the last one repeats.
+type statusSequence struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (ss *statusSequence) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	status := ss.statuses[min(len(ss.requests), len(ss.statuses)-1)]
+	ss.requests = append(ss.requests, r)
+	ss.bodies = append(ss.bodies, body)
+
+	w.WriteHeader(status)
+}
+
+func (ss *statusSequence) count() int {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	return len(ss.requests)
+}
+
+func TestDeliverWebhooksSigned(t *testing.T) {
+	receiver := &statusSequence{statuses: []int{http.StatusNoContent}}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	s, repo := newWebhookStandIn(server.URL, 3, server.Client())
+
+	if err := s.DeliverWebhooks(context.Background()); err != nil {
+		t.Fatalf("DeliverWebhooks() error = %v", err)
+	}
+
+	if receiver.count() != 1 {
+		t.Fatalf("requests = %d, want 1", receiver.count())
+	}
+
+	req, body := receiver.requests[0], receiver.bodies[0]
+
+	mac := hmac.New(sha256.New, []byte(testWebhookSecret))
+	mac.Write(body)
+	wantSignature := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	if got := req.Header.Get(webhookSignature); got != wantSignature {
+		t.Errorf("signature = %q, want %q", got, wantSignature)
+	}
+	if got := req.Header.Get(webhookEventHeader); got != core.EventDocumentCreated {
+		t.Errorf("event header = %q, want %q", got, core.EventDocumentCreated)
+	}
+	if got := req.Header.Get(webhookDeliveryID); got != "delivery-1" {
+		t.Errorf("delivery header = %q, want %q", got, "delivery-1")
+	}
+	if string(body) != string(repo.delivery().Payload) {
+		t.Errorf("body = %s, want %s", body, repo.delivery().Payload)
+	}
+
+	delivery := repo.delivery()
+	if delivery.Status != core.DeliveryDelivered || delivery.Attempts != 1 {
+		t.Errorf("delivery status = %s attempts = %d, want %s 1", delivery.Status, delivery.Attempts, core.DeliveryDelivered)
+	}
+
+	if len(repo.attempts) != 1 || repo.attempts[0].StatusCode != http.StatusNoContent || len(repo.attempts[0].Error) > 0 {
+		t.Errorf("attempts = %+v, want one successful attempt", repo.attempts)
+	}
+}
+
+func TestDeliverWebhooksRetry(t *testing.T) {
+	receiver := &statusSequence{statuses: []int{http.StatusInternalServerError, http.StatusOK}}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	s, repo := newWebhookStandIn(server.URL, 3, server.Client())
+
+	before := time.Now()
+	if err := s.DeliverWebhooks(context.Background()); err != nil {
+		t.Fatalf("DeliverWebhooks() error = %v", err)
+	}
+
+	delivery := repo.delivery()
+	if delivery.Status != core.DeliveryPending || delivery.Attempts != 1 || len(delivery.LastError) == 0 {
+		t.Fatalf("delivery = %+v, want a pending delivery with an error", delivery)
+	}
+	if delivery.NextAttempt.Before(before.Add(testWebhookBackoff)) {
+		t.Errorf("next attempt = %v, want after %v", delivery.NextAttempt, before.Add(testWebhookBackoff))
+	}
+
+	// the backoff hasn't passed, nothing is due
+	if err := s.DeliverWebhooks(context.Background()); err != nil {
+		t.Fatalf("DeliverWebhooks() error = %v", err)
+	}
+	if receiver.count() != 1 {
+		t.Fatalf("requests = %d, want 1 before the backoff passed", receiver.count())
+	}
+
+	repo.due()
+
+	if err := s.DeliverWebhooks(context.Background()); err != nil {
+		t.Fatalf("DeliverWebhooks() error = %v", err)
+	}
+
+	delivery = repo.delivery()
+	if delivery.Status != core.DeliveryDelivered || delivery.Attempts != 2 || len(delivery.LastError) > 0 {
+		t.Errorf("delivery = %+v, want delivered on the second attempt", delivery)
+	}
+
+	wantStatuses := []int{http.StatusInternalServerError, http.StatusOK}
+	if len(repo.attempts) != len(wantStatuses) {
+		t.Fatalf("attempts = %d, want %d", len(repo.attempts), len(wantStatuses))
+	}
+	for i, attempt := range repo.attempts {
+		if attempt.Attempt != i+1 || attempt.StatusCode != wantStatuses[i] {
+			t.Errorf("attempt %d = %+v, want attempt %d with status %d", i, attempt, i+1, wantStatuses[i])
+		}
+	}
+}
+
+func TestDeliverWebhooksGivesUp(t *testing.T) {
+	receiver := &statusSequence{statuses: []int{http.StatusBadGateway}}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	s, repo := newWebhookStandIn(server.URL, 2, server.Client())
+
+	for range 2 {
+		if err := s.DeliverWebhooks(context.Background()); err != nil {
+			t.Fatalf("DeliverWebhooks() error = %v", err)
+		}
+		repo.due()
+	}
+
+	delivery := repo.delivery()
+	if delivery.Status != core.DeliveryFailed || delivery.Attempts != 2 {
+		t.Errorf("delivery status = %s attempts = %d, want %s 2", delivery.Status, delivery.Attempts, core.DeliveryFailed)
+	}
+
+	if err := s.DeliverWebhooks(context.Background()); err != nil {
+		t.Fatalf("DeliverWebhooks() error = %v", err)
+	}
+	if receiver.count() != 2 {
+		t.Errorf("requests = %d, want 2", receiver.count())
+	}
+}
+
+func TestDeliverWebhooksRefusesLoopback(t *testing.T) {
+	receiver := &statusSequence{statuses: []int{http.StatusOK}}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	s, repo := newWebhookStandIn(server.URL, 3, NewWebhookClient(false))
+
+	if err := s.DeliverWebhooks(context.Background()); err != nil {
+		t.Fatalf("DeliverWebhooks() error = %v", err)
+	}
+
+	if receiver.count() != 0 {
+		t.Errorf("requests = %d, want 0", receiver.count())
+	}
+
+	if len(repo.attempts) != 1 || !strings.Contains(repo.attempts[0].Error, e.ErrWebhookAddress.Error()) {
+		t.Errorf("attempts = %+v, want one refused attempt", repo.attempts)
+	}
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 1, want: 30 * time.Second},
+		{attempts: 2, want: time.Minute},
+		{attempts: 4, want: 4 * time.Minute},
+		{attempts: 7, want: 32 * time.Minute},
+		{attempts: 8, want: webhookMaxBackoff},
+		{attempts: 100, want: webhookMaxBackoff},
+	}
+
+	for _, tt := range tests {
+		if got := webhookBackoff(30*time.Second, tt.attempts); got != tt.want {
+			t.Errorf("webhookBackoff(30s, %d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+type Job func(ctx context.Context) error
+
+// Worker runs a job periodically until its context is canceled.
+type Worker struct {
+	l        zerolog.Logger
+	name     string
+	interval time.Duration
+	job      Job
+}
+
+func New(l zerolog.Logger, name string, interval time.Duration, job Job) *Worker {
+	return &Worker{
+		l:        l,
+		name:     name,
+		interval: interval,
+		job:      job,
+	}
+}
+
+func (w *Worker) Run(ctx context.Context) {
+	if w.interval <= 0 {
+		w.l.Warn().Msgf("worker %s disabled: interval is not set", w.name)
+
+		return
+	}
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := w.job(ctx); err != nil {
+			w.l.Error().Err(err).Msgf("worker %s failed", w.name)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}